@@ -0,0 +1,42 @@
+// Command mock-oidc runs a local OpenID Connect provider that approves every
+// login, for trying out single sign-on without a real identity provider.
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ardani17/taskmanager/internal/oidc/mockissuer"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	addr := getEnv("MOCK_OIDC_ADDR", ":9999")
+	issuerURL := getEnv("MOCK_OIDC_ISSUER", "http://localhost:9999")
+
+	user := mockissuer.User{
+		Email: getEnv("MOCK_OIDC_EMAIL", "dev@example.com"),
+		Name:  getEnv("MOCK_OIDC_NAME", "Mock Developer"),
+	}
+	if groups := getEnv("MOCK_OIDC_GROUPS", ""); groups != "" {
+		user.Groups = strings.Split(groups, ",")
+	}
+
+	issuer, err := mockissuer.New(issuerURL, user)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create mock issuer")
+	}
+
+	log.Info().Str("addr", addr).Str("issuer", issuerURL).Str("email", user.Email).Msg("Mock OIDC provider listening")
+	if err := http.ListenAndServe(addr, issuer); err != nil {
+		log.Fatal().Err(err).Msg("Mock OIDC provider failed")
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/config"
+	"github.com/ardani17/taskmanager/internal/handlers"
+	"github.com/ardani17/taskmanager/internal/mailer"
+	"github.com/ardani17/taskmanager/internal/middleware"
+	"github.com/ardani17/taskmanager/internal/oidc"
+	"github.com/ardani17/taskmanager/internal/policy"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// newRouter wires the repositories, services and handlers of the API on db
+// and returns its router. redisClient may be nil.
+func newRouter(cfg *config.Config, db *repository.DB, redisClient *redis.Client, mail mailer.Mailer) (http.Handler, error) {
+	// Initialize repositories
+	userRepo := repository.NewDeveloperRepository(db)
+	taskRepo := repository.NewTaskRepository(db)
+	projectRepo := repository.NewProjectRepository(db)
+	projectMemberRepo := repository.NewProjectMemberRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	accountTokenRepo := repository.NewAccountTokenRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	apiTokenRepo := repository.NewAPITokenRepository(db)
+	oidcStateRepo := repository.NewOIDCStateRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	activityRepo := repository.NewActivityRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	savedViewRepo := repository.NewSavedViewRepository(db)
+	taskLinkRepo := repository.NewTaskLinkRepository(db)
+	searcher := repository.NewSearcher(db)
+	unitOfWork := repository.NewUnitOfWork(db)
+
+	// Initialize services
+	tokenExpiry, err := time.ParseDuration(cfg.JWTExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_EXPIRY: %w", err)
+	}
+	refreshExpiry, err := time.ParseDuration(cfg.JWTRefreshExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_REFRESH_EXPIRY: %w", err)
+	}
+	var jwtService *services.JWTService
+	if cfg.JWTKeyDir != "" {
+		keys, err := services.LoadKeySet(cfg.JWTKeyDir, cfg.JWTSigningKeyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWT signing keys: %w", err)
+		}
+		jwtService = services.NewJWTServiceWithKeys(keys, cfg.JWTIssuer, tokenExpiry, refreshExpiry)
+	} else {
+		if cfg.JWTSecret == config.DefaultJWTSecret {
+			log.Warn().Msg("JWT_SECRET is the public default; set it or JWT_KEY_DIR before deploying")
+		}
+		jwtService = services.NewJWTServiceWithExpiry(cfg.JWTSecret, cfg.JWTIssuer, tokenExpiry, refreshExpiry)
+	}
+	log.Info().Str("algorithm", jwtService.Algorithm()).Msg("JWT signing configured")
+	sessionService := services.NewSessionService(jwtService, sessionRepo, userRepo)
+	accountService := services.NewAccountService(accountTokenRepo, userRepo, sessionService, mail, cfg.AppURL)
+	twoFactorService := services.NewTwoFactorService(twoFactorRepo, userRepo, jwtService, cfg.TOTPIssuer)
+	apiTokenService := services.NewAPITokenService(apiTokenRepo)
+	invitationService := services.NewInvitationService(invitationRepo, userRepo, projectRepo, teamRepo, mail, cfg.AppURL)
+	oidcProvider := oidc.NewProvider(oidc.Config{
+		IssuerURL:    cfg.OIDCIssuerURL,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       strings.Fields(cfg.OIDCScopes),
+	})
+	roleMapping, err := oidc.ParseRoleMapping(cfg.OIDCRoleClaim, cfg.OIDCRoleMapping, cfg.OIDCDefaultRole)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OIDC_ROLE_MAPPING or OIDC_DEFAULT_ROLE: %w", err)
+	}
+	oidcService := services.NewOIDCService(oidcProvider, roleMapping, oidcStateRepo, userRepo)
+	if !cfg.LocalLoginEnabled && !oidcService.Enabled() {
+		return nil, errors.New("LOCAL_LOGIN_ENABLED=false requires OIDC_ISSUER_URL and OIDC_CLIENT_ID, otherwise nobody can log in")
+	}
+	loginGuard, err := newLoginGuard(cfg, redisClient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid login lockout settings: %w", err)
+	}
+	limitAuth, limitAPI, err := newRateLimiters(cfg, redisClient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate limit settings: %w", err)
+	}
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	accessPolicy := policy.NewPolicy(projectMemberRepo)
+
+	// Initialize handlers
+	authHandler := handlers.NewAuthHandler(sessionService, accountService, twoFactorService, loginGuard, userRepo, activityRepo, unitOfWork, cfg.LocalLoginEnabled, cfg.RegistrationOpen)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService, sessionService, loginGuard, userRepo, activityRepo)
+	apiTokenHandler := handlers.NewAPITokenHandler(apiTokenService, userRepo)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, sessionService, twoFactorService, userRepo, unitOfWork)
+	invitationHandler := handlers.NewInvitationHandler(invitationService, sessionService, twoFactorService, userRepo, unitOfWork, accessPolicy, cfg.LocalLoginEnabled)
+	userHandler := handlers.NewUserHandler(userRepo, auditRepo, unitOfWork, sessionService, apiTokenService, accessPolicy)
+	taskHandler := handlers.NewTaskHandler(taskRepo, taskLinkRepo, auditRepo, unitOfWork, accessPolicy)
+	projectHandler := handlers.NewProjectHandler(projectRepo, projectMemberRepo, auditRepo, unitOfWork, accessPolicy)
+	projectMemberHandler := handlers.NewProjectMemberHandler(projectMemberRepo, projectRepo, userRepo, unitOfWork, accessPolicy)
+	teamHandler := handlers.NewTeamHandler(teamRepo, userRepo, unitOfWork, accessPolicy)
+	activityHandler := handlers.NewActivityHandler(activityRepo, accessPolicy)
+	searchHandler := handlers.NewSearchHandler(searcher, accessPolicy)
+	savedViewHandler := handlers.NewSavedViewHandler(savedViewRepo, taskRepo, projectRepo, accessPolicy)
+	taskLinkHandler := handlers.NewTaskLinkHandler(taskLinkRepo, taskRepo, projectRepo, unitOfWork, accessPolicy)
+	jwksHandler := handlers.NewJWKSHandler(jwtService)
+
+	// Create router
+	r := chi.NewRouter()
+
+	// Setup middleware
+	setupMiddleware(r, cfg, jwtService, trustedProxies)
+
+	// Setup routes
+	setupRoutes(r, jwksHandler, authHandler, twoFactorHandler, apiTokenHandler, oidcHandler, invitationHandler, userHandler, taskHandler, projectHandler, projectMemberHandler, teamHandler, activityHandler, searchHandler, savedViewHandler, taskLinkHandler, middleware.AuthMiddleware(jwtService, sessionService, apiTokenService), limitAuth, limitAPI)
+
+	return r, nil
+}
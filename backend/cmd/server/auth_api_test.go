@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/config"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/services"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRegisterLoginAndLogout(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("ann")
+
+	var me models.Developer
+	s.do(http.MethodGet, "/api/v1/auth/me", user.Token, nil).expect(http.StatusOK).data(&me)
+	if me.ID != user.ID || me.Email != user.Email {
+		t.Fatalf("me = %d %s, want %d %s", me.ID, me.Email, user.ID, user.Email)
+	}
+
+	token := s.login(user.Email)
+	s.do(http.MethodPost, "/api/v1/auth/logout", token.AccessToken, nil).expect(http.StatusOK)
+	s.do(http.MethodGet, "/api/v1/auth/me", token.AccessToken, nil).expect(http.StatusUnauthorized)
+
+	// Logging out of one session leaves the others alone
+	s.do(http.MethodGet, "/api/v1/auth/me", user.Token, nil).expect(http.StatusOK)
+}
+
+func TestTokenIssuerAndAudience(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("ann")
+
+	claims := func(token string) *services.JWTClaims {
+		t.Helper()
+		claims := &services.JWTClaims{}
+		if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+			t.Fatalf("parse token: %v", err)
+		}
+		return claims
+	}
+	access, refresh := claims(user.Token), claims(user.RefreshToken)
+	if access.Issuer != s.cfg.JWTIssuer || fmt.Sprint(access.Audience) != "["+s.cfg.JWTIssuer+":access]" {
+		t.Fatalf("access token iss = %q, aud = %v", access.Issuer, access.Audience)
+	}
+	if refresh.Issuer != s.cfg.JWTIssuer || fmt.Sprint(refresh.Audience) != "["+s.cfg.JWTIssuer+":refresh]" {
+		t.Fatalf("refresh token iss = %q, aud = %v", refresh.Issuer, refresh.Audience)
+	}
+
+	// Tokens of another issuer are rejected, even when signed with the same secret
+	other := services.NewJWTServiceWithExpiry(s.cfg.JWTSecret, "elsewhere", time.Hour, time.Hour)
+	pair, err := other.GenerateToken(access.DeveloperID, access.Email, access.Role, access.SessionID)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	s.do(http.MethodGet, "/api/v1/auth/me", pair.AccessToken, nil).expect(http.StatusUnauthorized)
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("ann")
+
+	var data models.LoginResponseData
+	s.do(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: user.RefreshToken}).
+		expect(http.StatusOK).
+		data(&data)
+	refreshed := data.Token
+	s.do(http.MethodGet, "/api/v1/auth/me", refreshed.AccessToken, nil).expect(http.StatusOK)
+
+	// Reusing a rotated refresh token revokes the session
+	s.do(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: user.RefreshToken}).
+		expect(http.StatusUnauthorized)
+	s.do(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: refreshed.RefreshToken}).
+		expect(http.StatusUnauthorized)
+}
+
+func TestLoginWithWrongPassword(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("ann")
+
+	s.do(http.MethodPost, "/api/v1/auth/login", "", models.LoginRequest{Email: user.Email, Password: "Wrong123!"}).
+		expect(http.StatusUnauthorized)
+}
+
+func TestTwoFactorLoginLockout(t *testing.T) {
+	// enroll turns on two-factor authentication for a new developer and
+	// returns their recovery codes
+	enroll := func(s *testServer) (*testUser, []string) {
+		t.Helper()
+		user := s.register("ann")
+
+		var setup models.TwoFactorSetup
+		s.do(http.MethodPost, "/api/v1/auth/2fa/setup", user.Token, nil).expect(http.StatusOK).data(&setup)
+		code, err := utils.TOTPCode(setup.Secret, utils.TOTPStep(time.Now()))
+		if err != nil {
+			t.Fatalf("TOTP code: %v", err)
+		}
+
+		var enabled struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		}
+		s.do(http.MethodPost, "/api/v1/auth/2fa/enable", user.Token, models.TwoFactorCodeRequest{Code: code}).
+			expect(http.StatusOK).
+			data(&enabled)
+		return user, enabled.RecoveryCodes
+	}
+	challenge := func(s *testServer, user *testUser) string {
+		t.Helper()
+		var data struct {
+			ChallengeToken string `json:"challenge_token"`
+		}
+		s.do(http.MethodPost, "/api/v1/auth/login", "", models.LoginRequest{Email: user.Email, Password: testPassword}).
+			expect(http.StatusOK).
+			data(&data)
+		return data.ChallengeToken
+	}
+	secondFactor := func(s *testServer, token, code string) *testResponse {
+		t.Helper()
+		return s.do(http.MethodPost, "/api/v1/auth/login/2fa", "", models.TwoFactorLoginRequest{ChallengeToken: token, Code: code})
+	}
+
+	// Wrong codes lock the developer out like wrong passwords
+	s := newTestServer(t)
+	user, recoveryCodes := enroll(s)
+	token := challenge(s, user)
+	for i := 1; i < s.cfg.LoginMaxFailures; i++ {
+		secondFactor(s, token, "wrong-code").expect(http.StatusUnauthorized)
+	}
+	secondFactor(s, token, "wrong-code").expect(http.StatusTooManyRequests)
+	secondFactor(s, challenge(s, user), recoveryCodes[0]).expect(http.StatusTooManyRequests)
+
+	// A challenge is burned after a few wrong codes and works only once
+	s = newTestServer(t, func(cfg *config.Config) {
+		cfg.LoginMaxFailures = 100
+		cfg.LoginIPMaxFailures = 100
+	})
+	user, recoveryCodes = enroll(s)
+	token = challenge(s, user)
+	for i := 0; i < 5; i++ {
+		secondFactor(s, token, "wrong-code").expect(http.StatusUnauthorized)
+	}
+	secondFactor(s, token, recoveryCodes[0]).expect(http.StatusUnauthorized)
+
+	token = challenge(s, user)
+	secondFactor(s, token, recoveryCodes[0]).expect(http.StatusOK)
+	secondFactor(s, token, recoveryCodes[1]).expect(http.StatusUnauthorized)
+}
+
+func TestForwardedForNeedsTrustedProxy(t *testing.T) {
+	configure := func(trustedProxies string) func(cfg *config.Config) {
+		return func(cfg *config.Config) {
+			cfg.LoginMaxFailures = 100
+			cfg.LoginIPMaxFailures = 3
+			cfg.TrustedProxies = trustedProxies
+		}
+	}
+	// failLogin sends a wrong password from the harness address, claiming to
+	// be forwarded for the given client
+	failLogin := func(s *testServer, forwardedFor string) int {
+		t.Helper()
+		body, _ := json.Marshal(models.LoginRequest{Email: "nobody@example.com", Password: "Wrong123!"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		rec := httptest.NewRecorder()
+		s.handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// A client cannot dodge the IP lockout with a new forwarded address
+	s := newTestServer(t, configure(""))
+	codes := []int{}
+	for i := 1; i <= 3; i++ {
+		codes = append(codes, failLogin(s, fmt.Sprintf("203.0.113.%d", i)))
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("spoofed addresses were not locked out together: %v", codes)
+	}
+
+	// Behind a trusted proxy every forwarded client has its own lockout
+	s = newTestServer(t, configure("192.0.2.0/24"))
+	codes = []int{}
+	for i := 1; i <= 3; i++ {
+		codes = append(codes, failLogin(s, "198.51.100.7, 203.0.113.7"))
+	}
+	codes = append(codes, failLogin(s, "203.0.113.8"))
+	if codes[2] != http.StatusTooManyRequests || codes[3] != http.StatusUnauthorized {
+		t.Fatalf("forwarded clients were not locked out separately: %v", codes)
+	}
+}
+
+func TestRegistrationClosed(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.RegistrationOpen = false
+	})
+
+	s.do(http.MethodPost, "/api/v1/auth/register", "", models.RegisterRequest{
+		Name:     "ann",
+		Email:    "ann@example.com",
+		Password: testPassword,
+	}).expect(http.StatusForbidden)
+}
+
+func TestProtectedRoutesRequireAToken(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, path := range []string{"/api/v1/tasks", "/api/v1/projects", "/api/v1/users", "/api/v1/auth/me"} {
+		s.do(http.MethodGet, path, "", nil).expect(http.StatusUnauthorized)
+		s.do(http.MethodGet, path, "not-a-token", nil).expect(http.StatusUnauthorized)
+	}
+}
+
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("ann")
+	admin := s.admin("root")
+
+	s.do(http.MethodGet, "/api/v1/activity/security", user.Token, nil).expect(http.StatusForbidden)
+	s.do(http.MethodGet, "/api/v1/activity/security", admin.Token, nil).expect(http.StatusOK)
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("ann")
+	project := s.createProject(user, "Scoped")
+
+	reader := s.apiToken(user, models.ScopeReadTasks)
+	writer := s.apiToken(user, models.ScopeWriteTasks)
+	projects := s.apiToken(user, models.ScopeWriteProjects)
+	view := models.SavedViewRequest{Name: "Open", Query: "status:todo"}
+	invitation := models.CreateInvitationRequest{Email: "new@example.com", ProjectID: &project.ID}
+
+	// Search and saved views need the task scopes
+	s.do(http.MethodGet, "/api/v1/search?q=scoped", reader, nil).expect(http.StatusOK)
+	s.do(http.MethodGet, "/api/v1/views", reader, nil).expect(http.StatusOK)
+	s.do(http.MethodPost, "/api/v1/views", reader, view).expect(http.StatusForbidden)
+	s.do(http.MethodPost, "/api/v1/views", writer, view).expect(http.StatusCreated)
+	s.do(http.MethodGet, "/api/v1/search?q=scoped", projects, nil).expect(http.StatusForbidden)
+	s.do(http.MethodGet, "/api/v1/views", projects, nil).expect(http.StatusForbidden)
+
+	// Invitations need write:projects, even to list them
+	s.do(http.MethodGet, "/api/v1/invitations", writer, nil).expect(http.StatusForbidden)
+	s.do(http.MethodPost, "/api/v1/invitations", writer, invitation).expect(http.StatusForbidden)
+	s.do(http.MethodGet, "/api/v1/invitations", projects, nil).expect(http.StatusOK)
+	s.do(http.MethodPost, "/api/v1/invitations", projects, invitation).expect(http.StatusCreated)
+}
+
+func TestPasswordReset(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("ann")
+	const newPassword = "Changed456!"
+
+	// Unknown addresses get the same answer, but no email
+	s.do(http.MethodPost, "/api/v1/auth/forgot-password", "", models.ForgotPasswordRequest{Email: "nobody@example.com"}).
+		expect(http.StatusOK)
+	s.do(http.MethodPost, "/api/v1/auth/forgot-password", "", models.ForgotPasswordRequest{Email: user.Email}).
+		expect(http.StatusOK)
+	token := s.mail.lastToken(t, user.Email)
+
+	s.do(http.MethodPost, "/api/v1/auth/reset-password", "", models.ResetPasswordRequest{Token: "not-a-token", Password: newPassword}).
+		expect(http.StatusBadRequest)
+	s.do(http.MethodPost, "/api/v1/auth/reset-password", "", models.ResetPasswordRequest{Token: token, Password: "weak"}).
+		expect(http.StatusBadRequest)
+	s.do(http.MethodPost, "/api/v1/auth/reset-password", "", models.ResetPasswordRequest{Token: token, Password: newPassword}).
+		expect(http.StatusOK)
+
+	// The link works once and the old sessions and password are gone
+	s.do(http.MethodPost, "/api/v1/auth/reset-password", "", models.ResetPasswordRequest{Token: token, Password: "Another789!"}).
+		expect(http.StatusBadRequest)
+	s.do(http.MethodGet, "/api/v1/auth/me", user.Token, nil).expect(http.StatusUnauthorized)
+	s.do(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: user.RefreshToken}).
+		expect(http.StatusUnauthorized)
+	s.do(http.MethodPost, "/api/v1/auth/login", "", models.LoginRequest{Email: user.Email, Password: testPassword}).
+		expect(http.StatusUnauthorized)
+	s.do(http.MethodPost, "/api/v1/auth/login", "", models.LoginRequest{Email: user.Email, Password: newPassword}).
+		expect(http.StatusOK)
+}
+
+func TestEmailVerification(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("ann")
+	if user.EmailVerified {
+		t.Fatal("a new developer starts out verified")
+	}
+
+	// Registration sends the first link; a resend sends a fresh one
+	s.mail.lastToken(t, user.Email)
+	s.do(http.MethodPost, "/api/v1/auth/verify-email/resend", user.Token, nil).expect(http.StatusOK)
+	token := s.mail.lastToken(t, user.Email)
+
+	s.do(http.MethodPost, "/api/v1/auth/verify-email", "", models.VerifyEmailRequest{Token: "not-a-token"}).
+		expect(http.StatusBadRequest)
+	s.do(http.MethodPost, "/api/v1/auth/verify-email", "", models.VerifyEmailRequest{Token: token}).
+		expect(http.StatusOK)
+	s.do(http.MethodPost, "/api/v1/auth/verify-email", "", models.VerifyEmailRequest{Token: token}).
+		expect(http.StatusBadRequest)
+
+	var me models.Developer
+	s.do(http.MethodGet, "/api/v1/auth/me", user.Token, nil).expect(http.StatusOK).data(&me)
+	if !me.EmailVerified {
+		t.Fatal("email is not verified")
+	}
+	s.do(http.MethodPost, "/api/v1/auth/verify-email/resend", user.Token, nil).expect(http.StatusConflict)
+}
+
+func TestSessions(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("ann")
+	other := s.login(user.Email)
+
+	var sessions []*models.Session
+	s.do(http.MethodGet, "/api/v1/auth/sessions", user.Token, nil).expect(http.StatusOK).data(&sessions)
+	if len(sessions) != 2 || sessions[0].Current == sessions[1].Current {
+		t.Fatalf("sessions: %+v", sessions)
+	}
+	otherID := sessions[0].ID
+	if sessions[0].Current {
+		otherID = sessions[1].ID
+	}
+
+	// Sessions of other developers look missing
+	stranger := s.register("bob")
+	s.do(http.MethodDelete, "/api/v1/auth/sessions/"+otherID, stranger.Token, nil).expect(http.StatusNotFound)
+	s.do(http.MethodDelete, "/api/v1/auth/sessions/not-a-session", user.Token, nil).expect(http.StatusBadRequest)
+
+	s.do(http.MethodDelete, "/api/v1/auth/sessions/"+otherID, user.Token, nil).expect(http.StatusOK)
+	s.do(http.MethodGet, "/api/v1/auth/me", other.AccessToken, nil).expect(http.StatusUnauthorized)
+	s.do(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: other.RefreshToken}).
+		expect(http.StatusUnauthorized)
+	s.do(http.MethodGet, "/api/v1/auth/sessions", user.Token, nil).expect(http.StatusOK).data(&sessions)
+	if len(sessions) != 1 || !sessions[0].Current {
+		t.Fatalf("sessions after revoking the other one: %+v", sessions)
+	}
+}
+
+func TestAdminForceLogout(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.admin("root")
+	user := s.register("ann")
+	apiToken := s.apiToken(user, models.ScopeReadTasks)
+	sessionsPath := fmt.Sprintf("/api/v1/users/%d/sessions", user.ID)
+	logoutPath := fmt.Sprintf("/api/v1/users/%d/force-logout", user.ID)
+
+	s.do(http.MethodGet, "/api/v1/tasks", apiToken, nil).expect(http.StatusOK)
+	s.do(http.MethodGet, sessionsPath, user.Token, nil).expect(http.StatusForbidden)
+	s.do(http.MethodPost, logoutPath, user.Token, nil).expect(http.StatusForbidden)
+
+	var sessions []*models.Session
+	s.do(http.MethodGet, sessionsPath, admin.Token, nil).expect(http.StatusOK).data(&sessions)
+	if len(sessions) != 1 || sessions[0].DeveloperID != user.ID || sessions[0].Current {
+		t.Fatalf("sessions of %d: %+v", user.ID, sessions)
+	}
+
+	var revoked struct {
+		Revoked          int `json:"revoked"`
+		RevokedAPITokens int `json:"revoked_api_tokens"`
+	}
+	s.do(http.MethodPost, logoutPath, admin.Token, nil).expect(http.StatusOK).data(&revoked)
+	if revoked.Revoked != 1 || revoked.RevokedAPITokens != 1 {
+		t.Fatalf("force logout revoked %+v", revoked)
+	}
+
+	// Sessions, refresh tokens and API tokens stop working
+	s.do(http.MethodGet, "/api/v1/auth/me", user.Token, nil).expect(http.StatusUnauthorized)
+	s.do(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: user.RefreshToken}).
+		expect(http.StatusUnauthorized)
+	s.do(http.MethodGet, "/api/v1/tasks", apiToken, nil).expect(http.StatusUnauthorized)
+	s.do(http.MethodGet, sessionsPath, admin.Token, nil).expect(http.StatusOK).data(&sessions)
+	if len(sessions) != 0 {
+		t.Fatalf("sessions after force logout: %+v", sessions)
+	}
+
+	s.do(http.MethodPost, "/api/v1/users/99999/force-logout", admin.Token, nil).expect(http.StatusNotFound)
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ardani17/taskmanager/internal/config"
+	"github.com/ardani17/taskmanager/internal/mailer"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/rs/zerolog"
+)
+
+// testPassword is the password of every user created by the harness
+const testPassword = "Secret123!"
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+// testServer is the API wired by newRouter on its own SQLite database, with
+// requests served in-process
+type testServer struct {
+	t       *testing.T
+	handler http.Handler
+	db      *repository.DB
+	mail    *captureMailer
+	cfg     *config.Config
+}
+
+// newTestServer builds the API on a fresh SQLite database in a temporary
+// directory. configure may adjust the configuration before it is used.
+func newTestServer(t *testing.T, configure ...func(cfg *config.Config)) *testServer {
+	t.Helper()
+
+	cfg := config.Load()
+	cfg.AppEnv = "test"
+	cfg.DBDriver = repository.DriverSQLite
+	cfg.DBPath = filepath.Join(t.TempDir(), "taskmanager.db")
+	cfg.RedisHost = ""
+	cfg.RateLimitEnabled = false
+	cfg.JWTSecret = "test-secret"
+	cfg.JWTKeyDir = ""
+	cfg.LocalLoginEnabled = true
+	cfg.RegistrationOpen = true
+	cfg.OIDCIssuerURL = ""
+	cfg.AppURL = "http://app.test"
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	db, err := repository.NewDB(cfg)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := ensureSchema(db, cfg); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+
+	mail := &captureMailer{}
+	handler, err := newRouter(cfg, db, nil, mail)
+	if err != nil {
+		t.Fatalf("build router: %v", err)
+	}
+
+	return &testServer{t: t, handler: handler, db: db, mail: mail, cfg: cfg}
+}
+
+// testResponse is a recorded API response
+type testResponse struct {
+	t      *testing.T
+	Code   int
+	Header http.Header
+	Body   []byte
+}
+
+// do sends a request to the API. body, if not nil, is sent as JSON; token,
+// if not empty, as a bearer token.
+func (s *testServer) do(method, path, token string, body interface{}) *testResponse {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("encode request body: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	return &testResponse{t: s.t, Code: rec.Code, Header: rec.Header(), Body: rec.Body.Bytes()}
+}
+
+// expect fails the test unless the response has the given status code
+func (r *testResponse) expect(code int) *testResponse {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Fatalf("expected status %d, got %d: %s", code, r.Code, r.Body)
+	}
+	return r
+}
+
+// decode unmarshals the response body into v
+func (r *testResponse) decode(v interface{}) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		r.t.Fatalf("decode response %s: %v", r.Body, err)
+	}
+}
+
+// data unmarshals the "data" field of the response body into v
+func (r *testResponse) data(v interface{}) {
+	r.t.Helper()
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	r.decode(&envelope)
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		r.t.Fatalf("decode response data %s: %v", envelope.Data, err)
+	}
+}
+
+// errorMessage returns the message of an error response
+func (r *testResponse) errorMessage() string {
+	r.t.Helper()
+	var envelope struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	r.decode(&envelope)
+	return envelope.Error.Message
+}
+
+// testUser is a developer created through the API with a valid access token
+type testUser struct {
+	*models.Developer
+	Token        string
+	RefreshToken string
+}
+
+var userSeq atomic.Int64
+
+// register creates a developer through POST /auth/register. The name is
+// also used for a unique email address.
+func (s *testServer) register(name string) *testUser {
+	s.t.Helper()
+
+	email := fmt.Sprintf("%s.%d@example.com", name, userSeq.Add(1))
+	res := s.do(http.MethodPost, "/api/v1/auth/register", "", models.RegisterRequest{
+		Name:     name,
+		Email:    email,
+		Password: testPassword,
+	}).expect(http.StatusCreated)
+
+	var data models.LoginResponseData
+	res.data(&data)
+	return &testUser{Developer: data.Developer, Token: data.Token.AccessToken, RefreshToken: data.Token.RefreshToken}
+}
+
+// login logs a developer in and returns a fresh access token
+func (s *testServer) login(email string) *models.TokenData {
+	s.t.Helper()
+
+	res := s.do(http.MethodPost, "/api/v1/auth/login", "", models.LoginRequest{
+		Email:    email,
+		Password: testPassword,
+	}).expect(http.StatusOK)
+
+	var data models.LoginResponseData
+	res.data(&data)
+	return data.Token
+}
+
+// admin registers a developer and promotes them to admin. Roles are carried
+// by access tokens, so the admin logs in again after the promotion.
+func (s *testServer) admin(name string) *testUser {
+	s.t.Helper()
+
+	user := s.register(name)
+	developers := repository.NewDeveloperRepository(s.db)
+	if err := developers.UpdateRole(context.Background(), user.ID, models.RoleAdmin); err != nil {
+		s.t.Fatalf("promote %s to admin: %v", name, err)
+	}
+	user.Role = models.RoleAdmin
+
+	token := s.login(user.Email)
+	user.Token, user.RefreshToken = token.AccessToken, token.RefreshToken
+	return user
+}
+
+// apiToken creates a personal API token for user with the given scopes and
+// returns its secret
+func (s *testServer) apiToken(user *testUser, scopes ...string) string {
+	s.t.Helper()
+
+	var data struct {
+		Token string `json:"token"`
+	}
+	s.do(http.MethodPost, "/api/v1/auth/tokens", user.Token, models.CreateAPITokenRequest{
+		Name:   "test",
+		Scopes: scopes,
+	}).expect(http.StatusCreated).data(&data)
+	return data.Token
+}
+
+// createProject creates a project owned by user
+func (s *testServer) createProject(user *testUser, name string) *models.Project {
+	s.t.Helper()
+
+	res := s.do(http.MethodPost, "/api/v1/projects", user.Token, models.CreateProjectRequest{
+		Name: name,
+	}).expect(http.StatusCreated)
+
+	project := &models.Project{}
+	res.data(project)
+	return project
+}
+
+// addMember adds a developer to a project with the given role, as user
+func (s *testServer) addMember(user *testUser, projectID, developerID int, role string) {
+	s.t.Helper()
+
+	s.do(http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/members", projectID), user.Token, models.AddProjectMemberRequest{
+		DeveloperID: developerID,
+		Role:        role,
+	}).expect(http.StatusOK)
+}
+
+// createTask creates a task as user. req is filled in with a title when it
+// has none.
+func (s *testServer) createTask(user *testUser, req models.CreateTaskRequest) *models.Task {
+	s.t.Helper()
+
+	if req.Title == "" {
+		req.Title = fmt.Sprintf("Task %d", userSeq.Add(1))
+	}
+	res := s.do(http.MethodPost, "/api/v1/tasks", user.Token, req).expect(http.StatusCreated)
+
+	task := &models.Task{}
+	res.data(task)
+	return task
+}
+
+// captureMailer keeps sent messages so tests can follow the links in them.
+// While fail is set, sending fails with it instead.
+type captureMailer struct {
+	mu       sync.Mutex
+	messages []*mailer.Message
+	fail     error
+}
+
+// Send implements mailer.Mailer
+func (m *captureMailer) Send(msg *mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail != nil {
+		return m.fail
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// failWith makes the mailer fail with err, or succeed again if err is nil
+func (m *captureMailer) failWith(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fail = err
+}
+
+var linkTokenPattern = regexp.MustCompile(`[?&]token=([^\s&]+)`)
+
+// lastToken returns the token in the link of the last message sent to
+// an address
+func (m *captureMailer) lastToken(t *testing.T, to string) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To != to {
+			continue
+		}
+		match := linkTokenPattern.FindStringSubmatch(m.messages[i].Body)
+		if match == nil {
+			t.Fatalf("message to %s has no token link: %s", to, m.messages[i].Body)
+		}
+		token, err := url.QueryUnescape(match[1])
+		if err != nil {
+			t.Fatalf("unescape token %q: %v", match[1], err)
+		}
+		return token
+	}
+
+	t.Fatalf("no message sent to %s", to)
+	return ""
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/config"
+	"github.com/ardani17/taskmanager/internal/services"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKeys writes an RSA key "rsa-1" and an Ed25519 key "ed-1" to a new
+// key directory and returns it with the PEM encoded RSA public key
+func writeKeys(t *testing.T) (dir string, rsaPublic []byte) {
+	t.Helper()
+	dir = t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("encode Ed25519 key: %v", err)
+	}
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatalf("encode RSA public key: %v", err)
+	}
+
+	write := func(name string, block *pem.Block) {
+		if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatalf("write key %s: %v", name, err)
+		}
+	}
+	write("rsa-1.pem", &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	write("ed-1.pem", &pem.Block{Type: "PRIVATE KEY", Bytes: edDER})
+
+	return dir, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPublicDER})
+}
+
+func TestAsymmetricSigning(t *testing.T) {
+	dir, rsaPublic := writeKeys(t)
+
+	for _, tt := range []struct {
+		keyID string
+		alg   string
+	}{
+		{keyID: "rsa-1", alg: "RS256"},
+		{keyID: "ed-1", alg: "EdDSA"},
+	} {
+		t.Run(tt.alg, func(t *testing.T) {
+			s := newTestServer(t, func(cfg *config.Config) {
+				cfg.JWTKeyDir = dir
+				cfg.JWTSigningKeyID = tt.keyID
+			})
+			user := s.register("ann")
+			s.do(http.MethodGet, "/api/v1/auth/me", user.Token, nil).expect(http.StatusOK)
+
+			// Every key is published, without its private half
+			res := s.do(http.MethodGet, "/.well-known/jwks.json", "", nil).expect(http.StatusOK)
+			if res.Header.Get("Cache-Control") == "" {
+				t.Fatal("key set is not cacheable")
+			}
+			var set jose.JSONWebKeySet
+			res.decode(&set)
+			if len(set.Keys) != 2 {
+				t.Fatalf("published %d keys, want 2", len(set.Keys))
+			}
+			for _, key := range set.Keys {
+				if !key.IsPublic() || key.Use != "sig" {
+					t.Fatalf("published key %s: public %v, use %q", key.KeyID, key.IsPublic(), key.Use)
+				}
+			}
+
+			// The key set alone verifies the tokens
+			claims := &services.JWTClaims{}
+			token, err := jwt.ParseWithClaims(user.Token, claims, func(token *jwt.Token) (interface{}, error) {
+				keys := set.Key(token.Header["kid"].(string))
+				if len(keys) != 1 {
+					t.Fatalf("no published key %v", token.Header["kid"])
+				}
+				return keys[0].Key, nil
+			}, jwt.WithValidMethods([]string{tt.alg}))
+			if err != nil {
+				t.Fatalf("verify token with the key set: %v", err)
+			}
+			if token.Header["kid"] != tt.keyID {
+				t.Fatalf("token signed with %v, want %s", token.Header["kid"], tt.keyID)
+			}
+
+			// A public key is no HMAC secret
+			forger := services.NewJWTServiceWithExpiry(string(rsaPublic), s.cfg.JWTIssuer, time.Hour, time.Hour)
+			forged, err := forger.GenerateToken(claims.DeveloperID, claims.Email, claims.Role, claims.SessionID)
+			if err != nil {
+				t.Fatalf("generate token: %v", err)
+			}
+			s.do(http.MethodGet, "/api/v1/auth/me", forged.AccessToken, nil).expect(http.StatusUnauthorized)
+		})
+	}
+
+	// Tokens signed with a shared secret have no keys to publish
+	s := newTestServer(t)
+	var set jose.JSONWebKeySet
+	s.do(http.MethodGet, "/.well-known/jwks.json", "", nil).expect(http.StatusOK).decode(&set)
+	if len(set.Keys) != 0 {
+		t.Fatalf("published %d keys for HS256", len(set.Keys))
+	}
+}
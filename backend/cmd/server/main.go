@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,12 +11,17 @@ import (
 
 	"github.com/ardani17/taskmanager/internal/config"
 	"github.com/ardani17/taskmanager/internal/handlers"
+	"github.com/ardani17/taskmanager/internal/lockout"
+	"github.com/ardani17/taskmanager/internal/mailer"
 	"github.com/ardani17/taskmanager/internal/middleware"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/ratelimit"
 	"github.com/ardani17/taskmanager/internal/repository"
 	"github.com/ardani17/taskmanager/internal/services"
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -27,6 +33,16 @@ func main() {
 	// Setup logger
 	setupLogger(cfg.IsDevelopment())
 
+	// Migration subcommands: server migrate <up|down|status|goto>
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(cfg, os.Args[2:])
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+
 	log.Info().
 		Str("port", cfg.AppPort).
 		Str("env", cfg.AppEnv).
@@ -39,30 +55,39 @@ func main() {
 	}
 	defer db.Close()
 
-	// Initialize repositories
-	userRepo := repository.NewDeveloperRepository(db)
-	taskRepo := repository.NewTaskRepository(db)
-	projectRepo := repository.NewProjectRepository(db)
-	activityRepo := repository.NewActivityRepository(db)
-
-	// Initialize services
-	jwtService := services.NewJWTService(cfg.JWTSecret)
-
-	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(jwtService, userRepo)
-	userHandler := handlers.NewUserHandler(userRepo)
-	taskHandler := handlers.NewTaskHandler(taskRepo, activityRepo)
-	projectHandler := handlers.NewProjectHandler(projectRepo, activityRepo)
-	activityHandler := handlers.NewActivityHandler(activityRepo)
+	// Check schema version
+	if err := ensureSchema(db, cfg); err != nil {
+		log.Fatal().Err(err).Msg("Database schema check failed")
+	}
 
-	// Create router
-	r := chi.NewRouter()
+	// Connect to Redis when configured
+	redisClient, err := newRedisClient(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to Redis")
+	}
+	if redisClient != nil {
+		defer redisClient.Close()
+	}
 
-	// Setup middleware
-	setupMiddleware(r, cfg, jwtService)
+	// Configure mail delivery
+	mail, err := mailer.New(mailer.Config{
+		Driver:   cfg.MailDriver,
+		From:     cfg.MailFrom,
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		Dir:      cfg.MailDir,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure mailer")
+	}
 
-	// Setup routes
-	setupRoutes(r, authHandler, userHandler, taskHandler, projectHandler, activityHandler, jwtService)
+	// Wire repositories, services and handlers into the router
+	r, err := newRouter(cfg, db, redisClient, mail)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up the API")
+	}
 
 	// Create server
 	server := &http.Server{
@@ -113,12 +138,99 @@ func setupLogger(isDevelopment bool) {
 	}
 }
 
-func setupMiddleware(r chi.Router, cfg *config.Config, jwtService *services.JWTService) {
+// newRedisClient connects to Redis, or returns nil when REDIS_HOST is not set
+func newRedisClient(cfg *config.Config) (*redis.Client, error) {
+	if cfg.RedisHost == "" {
+		return nil, nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisHost + ":" + cfg.RedisPort,
+		Password: cfg.RedisPassword,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	log.Info().Str("host", cfg.RedisHost).Str("port", cfg.RedisPort).Msg("Connected to Redis")
+	return client, nil
+}
+
+// newLoginGuard creates the login lockout guard. Failures are counted in Redis
+// when it is available, so every server sees the same counters.
+func newLoginGuard(cfg *config.Config, redisClient *redis.Client) (*lockout.Guard, error) {
+	base, err := time.ParseDuration(cfg.LoginLockoutBase)
+	if err != nil {
+		return nil, err
+	}
+	maxDelay, err := time.ParseDuration(cfg.LoginLockoutMax)
+	if err != nil {
+		return nil, err
+	}
+	window, err := time.ParseDuration(cfg.LoginFailureWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	var store lockout.Store = lockout.NewMemoryStore()
+	if redisClient != nil {
+		store = lockout.NewRedisStore(redisClient)
+	}
+
+	account := lockout.Policy{MaxFailures: cfg.LoginMaxFailures, BaseDelay: base, MaxDelay: maxDelay, Window: window}
+	ip := lockout.Policy{MaxFailures: cfg.LoginIPMaxFailures, BaseDelay: base, MaxDelay: maxDelay, Window: window}
+	return lockout.NewGuard(store, account, ip), nil
+}
+
+// newRateLimiters creates the rate limiting middleware for the credential
+// endpoints (per client IP) and for the authenticated API (per developer or
+// API token, with separate read and write budgets)
+func newRateLimiters(cfg *config.Config, redisClient *redis.Client) (limitAuth, limitAPI func(http.Handler) http.Handler, err error) {
+	if !cfg.RateLimitEnabled {
+		log.Warn().Msg("Rate limiting is disabled")
+		noLimit := func(next http.Handler) http.Handler { return next }
+		return noLimit, noLimit, nil
+	}
+
+	authLimit, err := ratelimit.ParseLimit(cfg.RateLimitAuth)
+	if err != nil {
+		return nil, nil, err
+	}
+	readLimit, err := ratelimit.ParseLimit(cfg.RateLimitRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	writeLimit, err := ratelimit.ParseLimit(cfg.RateLimitWrite)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if redisClient != nil {
+		store = ratelimit.NewRedisStore(redisClient)
+	}
+
+	log.Info().
+		Str("auth", authLimit.String()).
+		Str("read", readLimit.String()).
+		Str("write", writeLimit.String()).
+		Msg("Rate limiting configured")
+
+	return middleware.RateLimit(store, "auth", authLimit),
+		middleware.RateLimitByMethod(store, "api", readLimit, writeLimit),
+		nil
+}
+
+func setupMiddleware(r chi.Router, cfg *config.Config, jwtService *services.JWTService, trustedProxies []*net.IPNet) {
 	// Request ID
 	r.Use(chiMiddleware.RequestID)
 
-	// Real IP
-	r.Use(chiMiddleware.RealIP)
+	// Real IP, as forwarded by trusted proxies
+	r.Use(middleware.RealIP(trustedProxies))
 
 	// Logger
 	r.Use(middleware.Logger)
@@ -134,7 +246,7 @@ func setupMiddleware(r chi.Router, cfg *config.Config, jwtService *services.JWTS
 		AllowedOrigins:   []string{"*"}, // TODO: Restrict in production
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -147,16 +259,31 @@ func setupMiddleware(r chi.Router, cfg *config.Config, jwtService *services.JWTS
 
 func setupRoutes(
 	r chi.Router,
+	jwksHandler *handlers.JWKSHandler,
 	authHandler *handlers.AuthHandler,
+	twoFactorHandler *handlers.TwoFactorHandler,
+	apiTokenHandler *handlers.APITokenHandler,
+	oidcHandler *handlers.OIDCHandler,
+	invitationHandler *handlers.InvitationHandler,
 	userHandler *handlers.UserHandler,
 	taskHandler *handlers.TaskHandler,
 	projectHandler *handlers.ProjectHandler,
+	projectMemberHandler *handlers.ProjectMemberHandler,
+	teamHandler *handlers.TeamHandler,
 	activityHandler *handlers.ActivityHandler,
-	jwtService *services.JWTService,
+	searchHandler *handlers.SearchHandler,
+	savedViewHandler *handlers.SavedViewHandler,
+	taskLinkHandler *handlers.TaskLinkHandler,
+	requireAuth func(http.Handler) http.Handler,
+	limitAuth func(http.Handler) http.Handler,
+	limitAPI func(http.Handler) http.Handler,
 ) {
 	// Health check (public)
 	r.Get("/health", handlers.Health)
 
+	// Token verification keys (public)
+	r.Get("/.well-known/jwks.json", jwksHandler.JWKS)
+
 	// API v1 routes
 	r.Route("/api/v1", func(r chi.Router) {
 		// API info (public)
@@ -165,29 +292,69 @@ func setupRoutes(
 
 		// Auth routes (public)
 		r.Route("/auth", func(r chi.Router) {
-			r.Post("/register", authHandler.Register)
-			r.Post("/login", authHandler.Login)
-			r.Post("/refresh", authHandler.RefreshToken)
-			
+			// Credential endpoints are limited per client IP
+			r.Group(func(r chi.Router) {
+				r.Use(limitAuth)
+				r.Post("/register", authHandler.Register)
+				r.Post("/login", authHandler.Login)
+				r.Post("/login/2fa", twoFactorHandler.Login)
+				r.Post("/login/2fa/setup", twoFactorHandler.LoginSetup)
+				r.Post("/refresh", authHandler.RefreshToken)
+				r.Post("/forgot-password", authHandler.ForgotPassword)
+				r.Post("/reset-password", authHandler.ResetPassword)
+				r.Post("/verify-email", authHandler.VerifyEmail)
+				r.Post("/invitations/accept", invitationHandler.Accept)
+
+				// Single sign-on
+				r.Get("/oidc/login", oidcHandler.Login)
+				r.Get("/oidc/callback", oidcHandler.Callback)
+			})
+
 			// Protected auth routes
 			r.Group(func(r chi.Router) {
-				r.Use(middleware.AuthMiddleware(jwtService))
+				r.Use(requireAuth)
+				r.Use(limitAPI)
 				r.Get("/me", authHandler.Me)
 				r.Post("/logout", authHandler.Logout)
+				r.Post("/logout-all", authHandler.LogoutAll)
+				r.Get("/sessions", authHandler.ListSessions)
+				r.Delete("/sessions/{id}", authHandler.RevokeSession)
+				r.Post("/verify-email/resend", authHandler.ResendVerification)
+
+				// Two-factor authentication
+				r.Get("/2fa", twoFactorHandler.Status)
+				r.Post("/2fa/setup", twoFactorHandler.Setup)
+				r.Post("/2fa/enable", twoFactorHandler.Enable)
+				r.Post("/2fa/disable", twoFactorHandler.Disable)
+				r.Post("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+
+				// Personal API tokens
+				r.Get("/tokens", apiTokenHandler.List)
+				r.Post("/tokens", apiTokenHandler.Create)
+				r.Delete("/tokens/{id}", apiTokenHandler.Revoke)
 			})
 		})
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.AuthMiddleware(jwtService))
+			r.Use(requireAuth)
+			r.Use(limitAPI)
 
 			// Users
 			r.Route("/users", func(r chi.Router) {
 				r.Get("/", userHandler.List)
 				r.Get("/{id}", userHandler.Get)
 				r.Put("/{id}", userHandler.Update)
-				r.Delete("/{id}", userHandler.Delete)
+				r.With(middleware.RequireRole(models.RoleAdmin)).Delete("/{id}", userHandler.Delete)
 				r.Patch("/{id}/status", userHandler.UpdateStatus)
+				r.Get("/{id}/history", userHandler.History)
+
+				// Session administration is limited to admins
+				r.Group(func(r chi.Router) {
+					r.Use(middleware.RequireRole(models.RoleAdmin))
+					r.Get("/{id}/sessions", userHandler.ListSessions)
+					r.Post("/{id}/force-logout", userHandler.ForceLogout)
+				})
 			})
 
 			// Projects
@@ -197,6 +364,32 @@ func setupRoutes(
 				r.Get("/{id}", projectHandler.Get)
 				r.Put("/{id}", projectHandler.Update)
 				r.Delete("/{id}", projectHandler.Delete)
+				r.Get("/{id}/history", projectHandler.History)
+				r.Get("/{id}/dependencies", taskLinkHandler.Graph)
+
+				// Members
+				r.Get("/{id}/members", projectMemberHandler.List)
+				r.Post("/{id}/members", projectMemberHandler.Add)
+				r.Delete("/{id}/members/{developerID}", projectMemberHandler.Remove)
+			})
+
+			// Teams
+			r.Route("/teams", func(r chi.Router) {
+				r.Get("/", teamHandler.List)
+				r.Get("/dashboard", teamHandler.Dashboard)
+				r.Get("/{id}", teamHandler.Get)
+				r.Get("/{id}/dashboard", teamHandler.TeamDashboard)
+				r.Get("/{id}/members", teamHandler.ListMembers)
+
+				// Team management is limited to admins
+				r.Group(func(r chi.Router) {
+					r.Use(middleware.RequireRole(models.RoleAdmin))
+					r.Post("/", teamHandler.Create)
+					r.Put("/{id}", teamHandler.Update)
+					r.Delete("/{id}", teamHandler.Delete)
+					r.Post("/{id}/members", teamHandler.AddMember)
+					r.Delete("/{id}/members/{developerID}", teamHandler.RemoveMember)
+				})
 			})
 
 			// Tasks
@@ -207,10 +400,46 @@ func setupRoutes(
 				r.Put("/{id}", taskHandler.Update)
 				r.Delete("/{id}", taskHandler.Delete)
 				r.Patch("/{id}/status", taskHandler.UpdateStatus)
+				r.Get("/{id}/history", taskHandler.History)
+				r.Get("/{id}/children", taskHandler.Children)
+				r.Post("/{id}/move", taskHandler.Move)
+
+				// Links
+				r.Get("/{id}/links", taskLinkHandler.List)
+				r.Post("/{id}/links", taskLinkHandler.Create)
+				r.Delete("/{id}/links/{linkID}", taskLinkHandler.Delete)
+			})
+
+			// Saved views of the task list
+			r.Route("/views", func(r chi.Router) {
+				r.Get("/", savedViewHandler.List)
+				r.Post("/", savedViewHandler.Create)
+				r.Get("/{id}", savedViewHandler.Get)
+				r.Put("/{id}", savedViewHandler.Update)
+				r.Delete("/{id}", savedViewHandler.Delete)
+				r.Get("/{id}/tasks", savedViewHandler.Tasks)
+			})
+
+			// Invitations
+			r.Route("/invitations", func(r chi.Router) {
+				r.Get("/", invitationHandler.List)
+				r.Post("/", invitationHandler.Create)
+				r.Delete("/{id}", invitationHandler.Revoke)
 			})
 
 			// Activity
 			r.Get("/activity", activityHandler.List)
+			r.With(middleware.RequireRole(models.RoleAdmin)).Get("/activity/security", activityHandler.Security)
+
+			// Search
+			r.Get("/search", searchHandler.Search)
+
+			// Settings (admin only)
+			r.Route("/settings", func(r chi.Router) {
+				r.Use(middleware.RequireRole(models.RoleAdmin))
+				r.Get("/two-factor", twoFactorHandler.GetSettings)
+				r.Put("/two-factor", twoFactorHandler.UpdateSettings)
+			})
 		})
 	})
 
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ardani17/taskmanager/internal/config"
+	"github.com/ardani17/taskmanager/internal/migrate"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/migrations"
+	"github.com/rs/zerolog/log"
+)
+
+const migrateUsage = `Usage: server migrate <command> [arg]
+
+Commands:
+  up             Apply all pending migrations
+  down [n]       Roll back the last n migrations (default 1)
+  status         Show applied and pending migrations
+  goto <version> Migrate up or down to the given version`
+
+// runMigrateCommand handles "server migrate ..." and exits when done
+func runMigrateCommand(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	db, err := repository.NewDB(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+	defer db.Close()
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load migrations")
+	}
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			n, err = strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatal().Str("arg", args[1]).Msg("Invalid number of migrations")
+			}
+		}
+		err = migrator.Down(n)
+	case "goto":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil || version < 0 {
+			log.Fatal().Str("arg", args[1]).Msg("Invalid migration version")
+		}
+		err = migrator.Goto(version)
+	case "status":
+		err = printMigrationStatus(migrator)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal().Err(err).Str("command", args[0]).Msg("Migration failed")
+	}
+}
+
+// newMigrator creates a migrator with the migrations of the database's driver
+func newMigrator(db *repository.DB) (*migrate.Migrator, error) {
+	fsys, err := migrations.ForDriver(db.Driver())
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db.DB, db.Driver(), fsys)
+}
+
+func printMigrationStatus(migrator *migrate.Migrator) error {
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Current version: %d", version)
+	if dirty {
+		fmt.Print(" (dirty)")
+	}
+	fmt.Printf("\nLatest version:  %d\n\n", migrator.Latest())
+
+	for _, s := range statuses {
+		state := "pending"
+		if s.Applied {
+			state = "applied"
+		}
+		fmt.Printf("  %03d  %-8s %s\n", s.Version, state, s.Name)
+	}
+	return nil
+}
+
+// ensureSchema refuses to start the server against an outdated schema,
+// or brings it up to date when auto-migration is enabled. SQLite databases,
+// which belong to a single server, are always brought up to date.
+func ensureSchema(db *repository.DB, cfg *config.Config) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w (version %d)", migrate.ErrDirty, version)
+	}
+
+	latest := migrator.Latest()
+	switch {
+	case version == latest:
+		log.Info().Int64("version", version).Msg("Database schema is up to date")
+		return nil
+	case version > latest:
+		log.Warn().Int64("version", version).Int64("latest", latest).Msg("Database schema is newer than this binary")
+		return nil
+	case cfg.AutoMigrate || db.Driver() == repository.DriverSQLite:
+		log.Info().Int64("from", version).Int64("to", latest).Msg("Applying pending migrations")
+		return migrator.Up()
+	default:
+		return fmt.Errorf("database schema is at version %d but %d is required; run \"server migrate up\" or set DB_AUTO_MIGRATE=true", version, latest)
+	}
+}
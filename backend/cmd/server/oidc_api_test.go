@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ardani17/taskmanager/internal/config"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/oidc/mockissuer"
+)
+
+// newSSOServer builds the API with single sign-on through a mock issuer
+// and password login turned off. Members of the "platform-admins" group
+// become admins.
+func newSSOServer(t *testing.T) *testServer {
+	t.Helper()
+
+	issuer, err := mockissuer.New("", mockissuer.User{Email: "dev@example.com", Name: "Dev"})
+	if err != nil {
+		t.Fatalf("create mock issuer: %v", err)
+	}
+	srv := httptest.NewServer(issuer)
+	t.Cleanup(srv.Close)
+	issuer.URL = srv.URL
+
+	return newTestServer(t, func(cfg *config.Config) {
+		cfg.OIDCIssuerURL = srv.URL
+		cfg.OIDCClientID = "taskmanager"
+		cfg.OIDCClientSecret = "secret"
+		cfg.OIDCRedirectURL = "http://api.test/api/v1/auth/oidc/callback"
+		cfg.OIDCScopes = ""
+		cfg.OIDCRoleClaim = "groups"
+		cfg.OIDCRoleMapping = "platform-admins=admin"
+		cfg.OIDCDefaultRole = models.RoleDeveloper
+		cfg.LocalLoginEnabled = false
+	})
+}
+
+// ssoCallback logs in at the mock issuer as the user described by params
+// (email, name, groups) and returns the callback URL it redirects to
+func (s *testServer) ssoCallback(params url.Values) *url.URL {
+	s.t.Helper()
+
+	var data struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+	s.do(http.MethodGet, "/api/v1/auth/oidc/login?redirect=false", "", nil).expect(http.StatusOK).data(&data)
+
+	authURL, err := url.Parse(data.AuthorizationURL)
+	if err != nil {
+		s.t.Fatalf("parse authorization URL: %v", err)
+	}
+	q := authURL.Query()
+	if q.Get("state") == "" || q.Get("nonce") == "" || q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		s.t.Fatalf("authorization URL lacks state, nonce or PKCE: %s", authURL)
+	}
+	for key, values := range params {
+		q[key] = values
+	}
+	authURL.RawQuery = q.Encode()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(authURL.String())
+	if err != nil {
+		s.t.Fatalf("log in at mock issuer: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		s.t.Fatalf("mock issuer answered %d", res.StatusCode)
+	}
+
+	callback, err := res.Location()
+	if err != nil {
+		s.t.Fatalf("mock issuer redirect: %v", err)
+	}
+	if callback.Query().Get("state") != q.Get("state") {
+		s.t.Fatalf("mock issuer returned state %q, sent %q", callback.Query().Get("state"), q.Get("state"))
+	}
+	return callback
+}
+
+// ssoLogin logs in through the mock issuer and returns the developer it
+// provisioned with their tokens
+func (s *testServer) ssoLogin(email, groups string) *testUser {
+	s.t.Helper()
+
+	callback := s.ssoCallback(url.Values{"email": {email}, "groups": {groups}})
+	var data models.LoginResponseData
+	s.do(http.MethodGet, callback.RequestURI(), "", nil).expect(http.StatusOK).data(&data)
+	if data.Token == nil {
+		s.t.Fatalf("single sign-on of %s returned no tokens", email)
+	}
+	return &testUser{Developer: data.Developer, Token: data.Token.AccessToken, RefreshToken: data.Token.RefreshToken}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	s := newSSOServer(t)
+
+	// The first login provisions the account, verified by the provider
+	dev := s.ssoLogin("ann@example.com", "engineering")
+	if dev.Role != models.RoleDeveloper || !dev.EmailVerified || dev.Email != "ann@example.com" {
+		t.Fatalf("provisioned developer: %+v", dev.Developer)
+	}
+	me := &models.Developer{}
+	s.do(http.MethodGet, "/api/v1/auth/me", dev.Token, nil).expect(http.StatusOK).data(me)
+	if me.ID != dev.ID {
+		t.Fatalf("token belongs to developer %d, not %d", me.ID, dev.ID)
+	}
+
+	// Later logins reuse the account and apply the role mapping again
+	again := s.ssoLogin("ann@example.com", "engineering,platform-admins")
+	if again.ID != dev.ID || again.Role != models.RoleAdmin {
+		t.Fatalf("second login: %+v", again.Developer)
+	}
+	var history []*models.AuditEntry
+	s.do(http.MethodGet, fmt.Sprintf("/api/v1/users/%d/history", dev.ID), again.Token, nil).expect(http.StatusOK).data(&history)
+	if len(history) != 2 || history[0].Action != models.AuditUpdate || history[1].Action != models.AuditCreate {
+		t.Fatalf("history of provisioned developer: %+v", history)
+	}
+
+	// A state is used once and must come from this server
+	callback := s.ssoCallback(url.Values{"email": {"bob@example.com"}})
+	s.do(http.MethodGet, callback.RequestURI(), "", nil).expect(http.StatusOK)
+	s.do(http.MethodGet, callback.RequestURI(), "", nil).expect(http.StatusBadRequest)
+
+	callback = s.ssoCallback(url.Values{"email": {"cat@example.com"}})
+	q := callback.Query()
+	q.Set("state", "forged")
+	s.do(http.MethodGet, "/api/v1/auth/oidc/callback?"+q.Encode(), "", nil).expect(http.StatusBadRequest)
+
+	// Password login, registration and password reset are turned off
+	var body struct {
+		Error struct {
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}
+	s.do(http.MethodPost, "/api/v1/auth/login", "", models.LoginRequest{Email: "ann@example.com", Password: testPassword}).
+		expect(http.StatusForbidden).decode(&body)
+	if body.Error.Reason != "local_login_disabled" {
+		t.Fatalf("login refused with reason %q", body.Error.Reason)
+	}
+	s.do(http.MethodPost, "/api/v1/auth/register", "", models.RegisterRequest{Name: "eve", Email: "eve@example.com", Password: testPassword}).
+		expect(http.StatusForbidden)
+	s.do(http.MethodPost, "/api/v1/auth/forgot-password", "", map[string]string{"email": "ann@example.com"}).
+		expect(http.StatusForbidden)
+}
+
+func TestOIDCLoginRequiresTwoFactor(t *testing.T) {
+	s := newSSOServer(t)
+	admin := s.ssoLogin("root@example.com", "platform-admins")
+
+	s.do(http.MethodPut, "/api/v1/settings/two-factor", admin.Token, models.TwoFactorSettings{
+		RequiredRoles: []string{models.RoleDeveloper},
+	}).expect(http.StatusOK)
+
+	// Signing in at the identity provider is not a second factor
+	callback := s.ssoCallback(url.Values{"email": {"ann@example.com"}})
+	var data struct {
+		Token              *models.TokenData `json:"token"`
+		TwoFactorRequired  bool              `json:"two_factor_required"`
+		EnrollmentRequired bool              `json:"enrollment_required"`
+		ChallengeToken     string            `json:"challenge_token"`
+	}
+	s.do(http.MethodGet, callback.RequestURI(), "", nil).expect(http.StatusOK).data(&data)
+	if data.Token != nil || !data.TwoFactorRequired || !data.EnrollmentRequired || data.ChallengeToken == "" {
+		t.Fatalf("single sign-on without a second factor: %+v", data)
+	}
+	s.do(http.MethodPost, "/api/v1/auth/login/2fa/setup", "", map[string]string{"challenge_token": data.ChallengeToken}).
+		expect(http.StatusOK)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+func TestProjectKeepsAnOwner(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	member := s.register("bob")
+	project := s.createProject(owner, "Website")
+	s.addMember(owner, project.ID, member.ID, models.ProjectRoleMember)
+
+	path := fmt.Sprintf("/api/v1/projects/%d/members/%d", project.ID, owner.ID)
+	s.do(http.MethodDelete, path, owner.Token, nil).expect(http.StatusConflict)
+	s.do(http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/members", project.ID), owner.Token, models.AddProjectMemberRequest{
+		DeveloperID: owner.ID,
+		Role:        models.ProjectRoleMember,
+	}).expect(http.StatusConflict)
+
+	s.addMember(owner, project.ID, member.ID, models.ProjectRoleOwner)
+	s.do(http.MethodDelete, path, member.Token, nil).expect(http.StatusOK)
+}
+
+func TestInvitationOnboarding(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.admin("root")
+	project := s.createProject(admin, "Website")
+
+	email := "carol@example.com"
+	s.do(http.MethodPost, "/api/v1/invitations", admin.Token, models.CreateInvitationRequest{
+		Email:       email,
+		ProjectID:   &project.ID,
+		ProjectRole: models.ProjectRoleMember,
+	}).expect(http.StatusCreated)
+
+	accept := models.AcceptInvitationRequest{
+		Token:    s.mail.lastToken(t, email),
+		Name:     "Carol",
+		Password: testPassword,
+	}
+	var data models.LoginResponseData
+	s.do(http.MethodPost, "/api/v1/auth/invitations/accept", "", accept).expect(http.StatusCreated).data(&data)
+	if !data.Developer.EmailVerified {
+		t.Fatal("invited developer's email is not verified")
+	}
+
+	// The invitee joined the project and the invitation is used up
+	s.do(http.MethodGet, fmt.Sprintf("/api/v1/projects/%d", project.ID), data.Token.AccessToken, nil).
+		expect(http.StatusOK)
+	s.do(http.MethodPost, "/api/v1/auth/invitations/accept", "", accept).expect(http.StatusBadRequest)
+}
+
+func TestUnsentInvitationIsRevoked(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.admin("root")
+
+	email := "dave@example.com"
+	s.mail.failWith(errors.New("connection refused"))
+	s.do(http.MethodPost, "/api/v1/invitations", admin.Token, models.CreateInvitationRequest{Email: email}).
+		expect(http.StatusBadGateway)
+
+	var invitations []*models.Invitation
+	s.do(http.MethodGet, "/api/v1/invitations", admin.Token, nil).expect(http.StatusOK).data(&invitations)
+	if len(invitations) != 1 || invitations[0].Status != models.InvitationRevoked {
+		t.Fatalf("invitations after a failed email: %+v", invitations)
+	}
+
+	// Once mail works again the invitee can be invited anew
+	s.mail.failWith(nil)
+	s.do(http.MethodPost, "/api/v1/invitations", admin.Token, models.CreateInvitationRequest{Email: email}).
+		expect(http.StatusCreated)
+	s.do(http.MethodPost, "/api/v1/auth/invitations/accept", "", models.AcceptInvitationRequest{
+		Token:    s.mail.lastToken(t, email),
+		Name:     "Dave",
+		Password: testPassword,
+	}).expect(http.StatusCreated)
+}
+
+func TestTeamDashboardCountsVisibleProjects(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.admin("root")
+	owner := s.register("ann")
+	outsider := s.register("bob")
+
+	team := &models.Team{}
+	s.do(http.MethodPost, "/api/v1/teams", admin.Token, models.CreateTeamRequest{Name: "Platform"}).
+		expect(http.StatusCreated).data(team)
+	project := &models.Project{}
+	s.do(http.MethodPost, "/api/v1/projects", owner.Token, models.CreateProjectRequest{Name: "Billing", TeamID: &team.ID}).
+		expect(http.StatusCreated).data(project)
+	s.createTask(owner, models.CreateTaskRequest{ProjectID: &project.ID})
+
+	total := func(user *testUser) int {
+		t.Helper()
+		stats := &models.TeamTaskStats{}
+		s.do(http.MethodGet, fmt.Sprintf("/api/v1/teams/%d/dashboard", team.ID), user.Token, nil).expect(http.StatusOK).data(stats)
+		var all []*models.TeamTaskStats
+		s.do(http.MethodGet, "/api/v1/teams/dashboard", user.Token, nil).expect(http.StatusOK).data(&all)
+		if len(all) != 1 || all[0].Total != stats.Total {
+			t.Fatalf("dashboards disagree: %+v and %+v", all, stats)
+		}
+		return stats.Total
+	}
+	if n := total(owner); n != 1 {
+		t.Fatalf("owner counts %d tasks", n)
+	}
+	if n := total(admin); n != 1 {
+		t.Fatalf("admin counts %d tasks", n)
+	}
+	if n := total(outsider); n != 0 {
+		t.Fatalf("outsider counts %d tasks", n)
+	}
+}
+
+func TestTeamListPages(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.admin("root")
+
+	for _, name := range []string{"Delta", "Alpha", "Charlie", "Bravo", "Echo"} {
+		s.do(http.MethodPost, "/api/v1/teams", admin.Token, models.CreateTeamRequest{Name: name}).expect(http.StatusCreated)
+	}
+
+	var names []string
+	var body struct {
+		Data       []*models.Team `json:"data"`
+		NextCursor *string        `json:"next_cursor"`
+		Total      int            `json:"total"`
+	}
+	path := "/api/v1/teams?limit=2&include_total=true"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("too many pages")
+		}
+		body.NextCursor = nil
+		s.do(http.MethodGet, path, admin.Token, nil).expect(http.StatusOK).decode(&body)
+		for _, team := range body.Data {
+			names = append(names, team.Name)
+		}
+		if body.NextCursor == nil {
+			break
+		}
+		path = "/api/v1/teams?limit=2&include_total=true&cursor=" + *body.NextCursor
+	}
+	if fmt.Sprint(names) != "[Alpha Bravo Charlie Delta Echo]" || body.Total != 5 {
+		t.Fatalf("teams = %v (total %d)", names, body.Total)
+	}
+
+	s.do(http.MethodGet, "/api/v1/teams?cursor=bogus", admin.Token, nil).expect(http.StatusBadRequest)
+
+	// Cursors of other lists are rejected
+	var tasks struct {
+		NextCursor *string `json:"next_cursor"`
+	}
+	s.createTask(admin, models.CreateTaskRequest{})
+	s.createTask(admin, models.CreateTaskRequest{})
+	s.do(http.MethodGet, "/api/v1/tasks?limit=1", admin.Token, nil).expect(http.StatusOK).decode(&tasks)
+	s.do(http.MethodGet, "/api/v1/teams?cursor="+*tasks.NextCursor, admin.Token, nil).expect(http.StatusBadRequest)
+}
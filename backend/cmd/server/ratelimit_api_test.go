@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ardani17/taskmanager/internal/config"
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+func TestRateLimits(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.RateLimitEnabled = true
+		cfg.RateLimitAuth = "3/m"
+		cfg.RateLimitRead = "5/m"
+		cfg.RateLimitWrite = "2/m"
+		cfg.LoginMaxFailures = 100
+		cfg.LoginIPMaxFailures = 100
+	})
+	// expectBudget fails the test unless the response reports the limit and
+	// the requests left
+	expectBudget := func(res *testResponse, limit, remaining int) {
+		t.Helper()
+		if res.Header.Get("RateLimit-Limit") != strconv.Itoa(limit) ||
+			res.Header.Get("RateLimit-Remaining") != strconv.Itoa(remaining) ||
+			res.Header.Get("RateLimit-Policy") != strconv.Itoa(limit)+";w=60" ||
+			res.Header.Get("RateLimit-Reset") == "" {
+			t.Fatalf("rate limit headers %v, want %d of %d left", res.Header, remaining, limit)
+		}
+	}
+	// expectLimited fails the test unless the request was turned away
+	expectLimited := func(res *testResponse) {
+		t.Helper()
+		res.expect(http.StatusTooManyRequests)
+		retryAfter, err := strconv.Atoi(res.Header.Get("Retry-After"))
+		if err != nil || retryAfter < 1 {
+			t.Fatalf("Retry-After = %q", res.Header.Get("Retry-After"))
+		}
+		var body struct {
+			Error struct {
+				RetryAfter int `json:"retry_after"`
+			} `json:"error"`
+		}
+		res.decode(&body)
+		if body.Error.RetryAfter != retryAfter {
+			t.Fatalf("retry_after = %d, Retry-After = %d", body.Error.RetryAfter, retryAfter)
+		}
+	}
+
+	// Credential endpoints share a budget per client IP
+	user := s.register("ann")
+	wrongPassword := models.LoginRequest{Email: user.Email, Password: "Wrong123!"}
+	expectBudget(s.do(http.MethodPost, "/api/v1/auth/login", "", wrongPassword).expect(http.StatusUnauthorized), 3, 1)
+	expectBudget(s.do(http.MethodPost, "/api/v1/auth/login", "", wrongPassword).expect(http.StatusUnauthorized), 3, 0)
+	expectLimited(s.do(http.MethodPost, "/api/v1/auth/login", "", models.LoginRequest{Email: user.Email, Password: testPassword}))
+
+	// API reads and writes have budgets of their own, per developer
+	apiToken := s.apiToken(user, models.ScopeReadTasks)
+	for remaining := 4; remaining >= 0; remaining-- {
+		expectBudget(s.do(http.MethodGet, "/api/v1/auth/me", user.Token, nil).expect(http.StatusOK), 5, remaining)
+	}
+	expectLimited(s.do(http.MethodGet, "/api/v1/tasks", user.Token, nil))
+	expectBudget(s.do(http.MethodPost, "/api/v1/projects", user.Token, models.CreateProjectRequest{Name: "Busy"}).
+		expect(http.StatusCreated), 2, 0)
+	expectLimited(s.do(http.MethodPost, "/api/v1/projects", user.Token, models.CreateProjectRequest{Name: "Busier"}))
+
+	// An API token is limited apart from the developer's sessions
+	expectBudget(s.do(http.MethodGet, "/api/v1/tasks", apiToken, nil).expect(http.StatusOK), 5, 4)
+}
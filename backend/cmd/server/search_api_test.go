@@ -0,0 +1,174 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+func TestSearch(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	outsider := s.register("bob")
+	admin := s.admin("root")
+
+	website := s.createProject(owner, "Website redesign")
+	task := s.createTask(owner, models.CreateTaskRequest{
+		Title:       "Write landing copy",
+		Description: "Hero <b>text</b> for the pricing pages",
+		ProjectID:   &website.ID,
+	})
+	secret := s.createProject(outsider, "Secret plans")
+	hidden := s.createTask(outsider, models.CreateTaskRequest{Title: "Landing secretly", ProjectID: &secret.ID})
+
+	search := func(user *testUser, query string) []*models.SearchResult {
+		t.Helper()
+		var results []*models.SearchResult
+		s.do(http.MethodGet, "/api/v1/search?"+query, user.Token, nil).expect(http.StatusOK).data(&results)
+		return results
+	}
+	find := func(results []*models.SearchResult, resultType string, id int) *models.SearchResult {
+		for _, res := range results {
+			if res.Type == resultType && res.ID == id {
+				return res
+			}
+		}
+		return nil
+	}
+
+	results := search(owner, "q=landing")
+	res := find(results, models.SearchTypeTask, task.ID)
+	if res == nil {
+		t.Fatalf("task not found in %d results", len(results))
+	}
+	if !strings.Contains(res.Snippet, "<mark>landing</mark>") || strings.Contains(res.Snippet, "<b>") {
+		t.Fatalf("snippet = %q", res.Snippet)
+	}
+	if res.ProjectID == nil || *res.ProjectID != website.ID {
+		t.Fatalf("project_id = %v, want %d", res.ProjectID, website.ID)
+	}
+	if find(results, models.SearchTypeTask, hidden.ID) != nil {
+		t.Fatalf("results include tasks of other projects")
+	}
+	for _, res := range results {
+		if res.Type == models.SearchTypeActivity && (res.TaskID == nil || *res.TaskID != task.ID) {
+			t.Fatalf("results include activity %d of task %v", res.ID, res.TaskID)
+		}
+	}
+
+	// Words are stemmed and types can be narrowed
+	results = search(owner, "q=page&type=task")
+	if len(results) != 1 || results[0].ID != task.ID {
+		t.Fatalf("searching page found %d results", len(results))
+	}
+	results = search(owner, "q="+url.QueryEscape("redesign OR pricing")+"&type=project,task")
+	if find(results, models.SearchTypeProject, website.ID) == nil || find(results, models.SearchTypeTask, task.ID) == nil {
+		t.Fatalf("searching redesign OR pricing found %d results", len(results))
+	}
+	if results = search(owner, "q="+url.QueryEscape("landing -copy")+"&type=task"); len(results) != 0 {
+		t.Fatalf("excluded word matched %d results", len(results))
+	}
+
+	// Admins see everything
+	if find(search(admin, "q=landing"), models.SearchTypeTask, hidden.ID) == nil {
+		t.Fatalf("admin does not find the other project's task")
+	}
+
+	s.do(http.MethodGet, "/api/v1/search?q=", owner.Token, nil).expect(http.StatusBadRequest)
+	s.do(http.MethodGet, "/api/v1/search?q=landing&type=user", owner.Token, nil).expect(http.StatusBadRequest)
+	if results := search(owner, "q=%22%22"); len(results) != 0 {
+		t.Fatalf("empty phrase matched %d results", len(results))
+	}
+}
+
+func TestSearchHidesPrivateProjectActivity(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	member := s.register("cat")
+	outsider := s.register("bob")
+
+	private := s.createProject(owner, "Zeppelin acquisition")
+	s.addMember(owner, private.ID, member.ID, models.ProjectRoleViewer)
+
+	search := func(user *testUser) []*models.SearchResult {
+		t.Helper()
+		var results []*models.SearchResult
+		s.do(http.MethodGet, "/api/v1/search?q=zeppelin", user.Token, nil).expect(http.StatusOK).data(&results)
+		return results
+	}
+	activities := func(results []*models.SearchResult) int {
+		count := 0
+		for _, res := range results {
+			if res.Type == models.SearchTypeActivity {
+				if res.ProjectID == nil || *res.ProjectID != private.ID {
+					t.Fatalf("activity %d has project %v", res.ID, res.ProjectID)
+				}
+				count++
+			}
+		}
+		return count
+	}
+
+	// The project's creation and new member are visible to its members only
+	if n := activities(search(owner)); n != 2 {
+		t.Fatalf("owner found %d activities", n)
+	}
+	if n := activities(search(member)); n != 2 {
+		t.Fatalf("member found %d activities", n)
+	}
+	if results := search(outsider); len(results) != 0 {
+		t.Fatalf("outsider found %d results", len(results))
+	}
+}
+
+func TestActivityFeedHidesPrivateProjects(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	member := s.register("cat")
+	outsider := s.register("bob")
+	admin := s.admin("root")
+
+	private := s.createProject(owner, "Zeppelin acquisition")
+	s.addMember(owner, private.ID, member.ID, models.ProjectRoleViewer)
+	s.createTask(owner, models.CreateTaskRequest{Title: "Negotiate zeppelin price", ProjectID: &private.ID})
+	s.createTask(outsider, models.CreateTaskRequest{Title: "Water the plants"})
+
+	feed := func(user *testUser) []*models.Activity {
+		t.Helper()
+		var activities []*models.Activity
+		s.do(http.MethodGet, "/api/v1/activity?limit=100", user.Token, nil).expect(http.StatusOK).data(&activities)
+		return activities
+	}
+	mentions := func(activities []*models.Activity) int {
+		count := 0
+		for _, a := range activities {
+			if strings.Contains(strings.ToLower(a.Description), "zeppelin") {
+				count++
+			}
+		}
+		return count
+	}
+
+	// The project's creation, new member and task are visible to its
+	// members and admins only
+	for _, user := range []*testUser{owner, member, admin} {
+		if n := mentions(feed(user)); n != 3 {
+			t.Fatalf("%s found %d activities of the project", user.Name, n)
+		}
+	}
+	activities := feed(outsider)
+	if n := mentions(activities); n != 0 {
+		t.Fatalf("outsider found %d activities of the project", n)
+	}
+	if len(activities) == 0 {
+		t.Fatal("outsider does not see their own activity")
+	}
+	for _, a := range activities {
+		if a.DeveloperID == nil || *a.DeveloperID != outsider.ID {
+			t.Fatalf("outsider sees activity %d of developer %v", a.ID, a.DeveloperID)
+		}
+	}
+}
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+func TestTaskLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	project := s.createProject(owner, "Website")
+
+	task := s.createTask(owner, models.CreateTaskRequest{
+		Title:          "Write copy",
+		ProjectID:      &project.ID,
+		EstimatedHours: 3.5,
+	})
+	if task.Status != "todo" || task.Priority != "medium" {
+		t.Fatalf("new task has status %q and priority %q", task.Status, task.Priority)
+	}
+	path := fmt.Sprintf("/api/v1/tasks/%d", task.ID)
+
+	var fetched models.Task
+	s.do(http.MethodGet, path, owner.Token, nil).expect(http.StatusOK).data(&fetched)
+	if fetched.Title != "Write copy" || fetched.EstimatedHours != 3.5 {
+		t.Fatalf("fetched task = %+v", fetched)
+	}
+
+	s.do(http.MethodPatch, path+"/status", owner.Token, map[string]string{"status": "done"}).
+		expect(http.StatusOK)
+	s.do(http.MethodGet, path, owner.Token, nil).expect(http.StatusOK).data(&fetched)
+	if fetched.Status != "done" {
+		t.Fatalf("status = %q, want %q", fetched.Status, "done")
+	}
+
+	var history []*models.AuditEntry
+	s.do(http.MethodGet, path+"/history", owner.Token, nil).expect(http.StatusOK).data(&history)
+	if len(history) != 2 || history[0].Action != models.AuditUpdate || history[1].Action != models.AuditCreate {
+		t.Fatalf("history has %d entries", len(history))
+	}
+	if change := history[0].Changes["status"]; change.Before != "todo" || change.After != "done" {
+		t.Fatalf("status change = %+v", change)
+	}
+	if history[0].ActorID == nil || *history[0].ActorID != owner.ID {
+		t.Fatalf("history actor = %v, want %d", history[0].ActorID, owner.ID)
+	}
+	if history[0].IPAddress != "" || history[0].RequestID != "" || history[0].Actor.Email != "" {
+		t.Fatalf("history shows where the change came from: %+v", history[0])
+	}
+
+	// Admins see where changes came from
+	admin := s.admin("root")
+	s.do(http.MethodGet, path+"/history", admin.Token, nil).expect(http.StatusOK).data(&history)
+	if history[0].IPAddress != "192.0.2.1" || history[0].RequestID == "" || history[0].Actor.Email != owner.Email {
+		t.Fatalf("admin history entry = %+v", history[0])
+	}
+
+	s.do(http.MethodDelete, path, owner.Token, nil).expect(http.StatusOK)
+	s.do(http.MethodGet, path, owner.Token, nil).expect(http.StatusNotFound)
+	s.do(http.MethodDelete, path, owner.Token, nil).expect(http.StatusNotFound)
+}
+
+func TestTaskEditRights(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	member := s.register("cat")
+	outsider := s.register("bob")
+	update := models.UpdateTaskRequest{Priority: "high"}
+
+	// Tasks without a project belong to their creator and assignee
+	loose := s.createTask(owner, models.CreateTaskRequest{Title: "Loose end"})
+	if loose.CreatedBy == nil || *loose.CreatedBy != owner.ID {
+		t.Fatalf("created_by = %v, want %d", loose.CreatedBy, owner.ID)
+	}
+	path := fmt.Sprintf("/api/v1/tasks/%d", loose.ID)
+	s.do(http.MethodPut, path, outsider.Token, update).expect(http.StatusForbidden)
+	s.do(http.MethodPut, path, owner.Token, models.UpdateTaskRequest{AssigneeID: &member.ID}).expect(http.StatusOK)
+	s.do(http.MethodPut, path, member.Token, update).expect(http.StatusOK)
+
+	// Assignees lose edit rights on project tasks with their membership
+	project := s.createProject(owner, "Website")
+	s.addMember(owner, project.ID, member.ID, models.ProjectRoleMember)
+	task := s.createTask(owner, models.CreateTaskRequest{ProjectID: &project.ID, AssigneeID: &member.ID})
+	path = fmt.Sprintf("/api/v1/tasks/%d", task.ID)
+	s.do(http.MethodPut, path, member.Token, update).expect(http.StatusOK)
+	s.addMember(owner, project.ID, member.ID, models.ProjectRoleViewer)
+	s.do(http.MethodPut, path, member.Token, update).expect(http.StatusForbidden)
+}
+
+func TestTaskVisibility(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	outsider := s.register("bob")
+	project := s.createProject(owner, "Website")
+	task := s.createTask(owner, models.CreateTaskRequest{ProjectID: &project.ID})
+	path := fmt.Sprintf("/api/v1/tasks/%d", task.ID)
+
+	s.do(http.MethodGet, path, outsider.Token, nil).expect(http.StatusForbidden)
+	var tasks []*models.Task
+	s.do(http.MethodGet, "/api/v1/tasks", outsider.Token, nil).expect(http.StatusOK).data(&tasks)
+	if len(tasks) != 0 {
+		t.Fatalf("outsider sees %d tasks", len(tasks))
+	}
+
+	s.addMember(owner, project.ID, outsider.ID, models.ProjectRoleViewer)
+	s.do(http.MethodGet, path, outsider.Token, nil).expect(http.StatusOK)
+	s.do(http.MethodGet, "/api/v1/tasks", outsider.Token, nil).expect(http.StatusOK).data(&tasks)
+	if len(tasks) != 1 {
+		t.Fatalf("viewer sees %d tasks, want 1", len(tasks))
+	}
+
+	// Viewers can look but not touch
+	s.do(http.MethodPatch, path+"/status", outsider.Token, map[string]string{"status": "done"}).
+		expect(http.StatusForbidden)
+	s.do(http.MethodDelete, path, outsider.Token, nil).expect(http.StatusForbidden)
+}
+
+func TestTaskListPagination(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	var ids []int
+	for i := 0; i < 5; i++ {
+		ids = append(ids, s.createTask(owner, models.CreateTaskRequest{}).ID)
+	}
+
+	type listPage struct {
+		Data       []*models.Task `json:"data"`
+		NextCursor *string        `json:"next_cursor"`
+		PrevCursor *string        `json:"prev_cursor"`
+		Total      *int           `json:"total"`
+	}
+	get := func(query string, want ...int) listPage {
+		t.Helper()
+		var page listPage
+		s.do(http.MethodGet, "/api/v1/tasks?"+query, owner.Token, nil).expect(http.StatusOK).decode(&page)
+		var got []int
+		for _, task := range page.Data {
+			got = append(got, task.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("%s: got tasks %v, want %v", query, got, want)
+		}
+		return page
+	}
+
+	first := get("limit=2&include_total=true", ids[4], ids[3])
+	if first.Total == nil || *first.Total != 5 {
+		t.Fatalf("total = %v, want 5", first.Total)
+	}
+	if first.PrevCursor != nil || first.NextCursor == nil {
+		t.Fatalf("first page cursors: prev %v, next %v", first.PrevCursor, first.NextCursor)
+	}
+
+	second := get("limit=2&cursor="+*first.NextCursor, ids[2], ids[1])
+	if second.Total != nil {
+		t.Fatalf("total included without include_total")
+	}
+	last := get("limit=2&cursor="+*second.NextCursor, ids[0])
+	if last.NextCursor != nil {
+		t.Fatalf("last page has a next cursor")
+	}
+
+	// Paging back returns the same pages
+	get("limit=2&cursor="+*last.PrevCursor, ids[2], ids[1])
+	back := get("limit=2&cursor="+*second.PrevCursor, ids[4], ids[3])
+	if back.PrevCursor != nil {
+		t.Fatalf("first page reached backwards has a prev cursor")
+	}
+
+	res := s.do(http.MethodGet, "/api/v1/tasks?limit=2&cursor="+*second.NextCursor, owner.Token, nil).expect(http.StatusOK)
+	link := res.Header.Get("Link")
+	if !strings.Contains(link, `rel="prev"`) || strings.Contains(link, `rel="next"`) {
+		t.Fatalf("Link = %q", link)
+	}
+
+	s.do(http.MethodGet, "/api/v1/tasks?cursor=bogus", owner.Token, nil).expect(http.StatusBadRequest)
+}
+
+func TestTaskFilters(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	bob := s.register("bob")
+	website := s.createProject(owner, "Website")
+	app := s.createProject(owner, "App")
+	s.addMember(owner, app.ID, bob.ID, models.ProjectRoleMember)
+
+	date := func(s string) *time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return &d
+	}
+	overdue := s.createTask(owner, models.CreateTaskRequest{
+		Title: "Write copy", Priority: "high", ProjectID: &website.ID, AssigneeID: &owner.ID,
+		DueDate: date("2020-01-10"), EstimatedHours: 5,
+	})
+	later := s.createTask(owner, models.CreateTaskRequest{
+		Title: "Pick fonts", Priority: "low", ProjectID: &website.ID, DueDate: date("2099-12-01"), EstimatedHours: 1,
+	})
+	undated := s.createTask(owner, models.CreateTaskRequest{
+		Title: "Ship app", Status: "done", ProjectID: &app.ID, AssigneeID: &bob.ID, EstimatedHours: 8,
+	})
+	finished := s.createTask(owner, models.CreateTaskRequest{
+		Title: "Buy domain", Priority: "high", Status: "done", AssigneeID: &owner.ID, DueDate: date("2020-01-05"),
+	})
+
+	list := func(query string) ([]int, string) {
+		t.Helper()
+		var page struct {
+			Data       []*models.Task `json:"data"`
+			NextCursor *string        `json:"next_cursor"`
+		}
+		s.do(http.MethodGet, "/api/v1/tasks?"+query, owner.Token, nil).expect(http.StatusOK).decode(&page)
+		ids := []int{}
+		for _, task := range page.Data {
+			ids = append(ids, task.ID)
+		}
+		next := ""
+		if page.NextCursor != nil {
+			next = *page.NextCursor
+		}
+		return ids, next
+	}
+	q := func(query string) string { return "q=" + url.QueryEscape(query) }
+
+	for _, tc := range []struct {
+		query string
+		want  []int
+	}{
+		{q("assignee:me"), []int{finished.ID, overdue.ID}},
+		{q("assignee:none"), []int{later.ID}},
+		{q("assignee:!me"), []int{undated.ID, later.ID}},
+		{"assignee_id=" + strconv.Itoa(bob.ID), []int{undated.ID}},
+		{q("project:none"), []int{finished.ID}},
+		{"project=" + strconv.Itoa(website.ID) + "&status=todo", []int{later.ID, overdue.ID}},
+		{q("is:overdue"), []int{overdue.ID}},
+		{q("due:2020-01-01..2020-01-31 status:!done"), []int{overdue.ID}},
+		{q("due:none"), []int{undated.ID}},
+		{q("due:<=2020-01-10"), []int{finished.ID, overdue.ID}},
+		{q("estimate:>=5"), []int{undated.ID, overdue.ID}},
+		{q("priority:high,medium sort:due"), []int{finished.ID, overdue.ID, undated.ID}},
+		{q(`"write COPY"`), []int{overdue.ID}},
+		{"sort=-due", []int{later.ID, overdue.ID, finished.ID, undated.ID}},
+		{"sort=priority,title", []int{later.ID, undated.ID, finished.ID, overdue.ID}},
+	} {
+		if got, _ := list(tc.query); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: got tasks %v, want %v", tc.query, got, tc.want)
+		}
+	}
+
+	// Paging through a sort order with NULLs, one task at a time, both ways
+	want := []int{finished.ID, overdue.ID, later.ID, undated.ID}
+	var cursors []string
+	cursor := ""
+	for i, id := range want {
+		ids, next := list("sort=due&limit=1&cursor=" + cursor)
+		if len(ids) != 1 || ids[0] != id {
+			t.Fatalf("page %d: got %v, want [%d]", i, ids, id)
+		}
+		cursors = append(cursors, cursor)
+		cursor = next
+	}
+	if cursor != "" {
+		t.Fatalf("last page has a next cursor")
+	}
+	var page struct {
+		Data       []*models.Task `json:"data"`
+		PrevCursor *string        `json:"prev_cursor"`
+	}
+	for i := len(want) - 1; i > 0; i-- {
+		s.do(http.MethodGet, "/api/v1/tasks?sort=due&limit=1&cursor="+cursors[i], owner.Token, nil).
+			expect(http.StatusOK).decode(&page)
+		s.do(http.MethodGet, "/api/v1/tasks?sort=due&limit=1&cursor="+*page.PrevCursor, owner.Token, nil).
+			expect(http.StatusOK).decode(&page)
+		if len(page.Data) != 1 || page.Data[0].ID != want[i-1] {
+			t.Fatalf("page %d backwards: got %d tasks, want task %d", i-1, len(page.Data), want[i-1])
+		}
+	}
+
+	// A cursor only works in the order it was issued for
+	s.do(http.MethodGet, "/api/v1/tasks?sort=title&cursor="+cursors[1], owner.Token, nil).expect(http.StatusBadRequest)
+
+	for _, query := range []string{q("colour:red"), "status=blocked", q("due:yesterday"), q(`"open`), "sort=assignee"} {
+		s.do(http.MethodGet, "/api/v1/tasks?"+query, owner.Token, nil).expect(http.StatusBadRequest)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	project := s.createProject(owner, "Launch")
+	other := s.createProject(owner, "Later")
+
+	epic := s.createTask(owner, models.CreateTaskRequest{Title: "Launch epic", ProjectID: &project.ID, EstimatedHours: 1, AutoComplete: true})
+	design := s.createTask(owner, models.CreateTaskRequest{Title: "Design", ParentID: &epic.ID, EstimatedHours: 3})
+	build := s.createTask(owner, models.CreateTaskRequest{Title: "Build", ParentID: &epic.ID, EstimatedHours: 5})
+	backend := s.createTask(owner, models.CreateTaskRequest{Title: "Backend", ParentID: &build.ID, EstimatedHours: 8})
+	if design.ProjectID == nil || *design.ProjectID != project.ID || design.ParentID == nil || *design.ParentID != epic.ID {
+		t.Fatalf("subtask = %+v", design)
+	}
+	s.do(http.MethodPost, "/api/v1/tasks", owner.Token, models.CreateTaskRequest{Title: "Stray", ParentID: &epic.ID, ProjectID: &other.ID}).
+		expect(http.StatusBadRequest)
+
+	get := func(id int) *models.Task {
+		t.Helper()
+		task := &models.Task{}
+		s.do(http.MethodGet, fmt.Sprintf("/api/v1/tasks/%d", id), owner.Token, nil).expect(http.StatusOK).data(task)
+		return task
+	}
+	setStatus := func(id int, status string) {
+		t.Helper()
+		s.do(http.MethodPatch, fmt.Sprintf("/api/v1/tasks/%d/status", id), owner.Token, map[string]string{"status": status}).
+			expect(http.StatusOK)
+	}
+	move := func(id int, req models.MoveTaskRequest) *testResponse {
+		t.Helper()
+		return s.do(http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/move", id), owner.Token, req)
+	}
+
+	// Rollups cover every depth and add up the hours of the task itself
+	setStatus(backend.ID, "done")
+	rollup := get(epic.ID).Rollup
+	if rollup == nil || rollup.Subtasks != 3 || rollup.Done != 1 || rollup.Progress != 33 || rollup.EstimatedHours != 17 {
+		t.Fatalf("rollup = %+v", rollup)
+	}
+	if get(design.ID).Rollup != nil {
+		t.Fatalf("task without subtasks has a rollup")
+	}
+
+	var children []*models.Task
+	s.do(http.MethodGet, fmt.Sprintf("/api/v1/tasks/%d/children?sort=title", epic.ID), owner.Token, nil).expect(http.StatusOK).data(&children)
+	if len(children) != 2 || children[0].ID != build.ID || children[1].ID != design.ID {
+		t.Fatalf("children = %v", children)
+	}
+
+	// A task cannot move under itself or its subtasks
+	move(epic.ID, models.MoveTaskRequest{ParentID: &backend.ID}).expect(http.StatusBadRequest)
+	move(epic.ID, models.MoveTaskRequest{ParentID: &epic.ID}).expect(http.StatusBadRequest)
+
+	// Moving a subtree to another project takes its subtasks along
+	moved := &models.Task{}
+	move(build.ID, models.MoveTaskRequest{ProjectID: &other.ID}).expect(http.StatusOK).data(moved)
+	if moved.ParentID != nil || *moved.ProjectID != other.ID {
+		t.Fatalf("moved = %+v", moved)
+	}
+	if task := get(backend.ID); *task.ProjectID != other.ID {
+		t.Fatalf("subtask stayed in project %d", *task.ProjectID)
+	}
+	s.do(http.MethodPut, fmt.Sprintf("/api/v1/tasks/%d", backend.ID), owner.Token, models.UpdateTaskRequest{ProjectID: &project.ID}).
+		expect(http.StatusBadRequest)
+
+	// The epic completes with its last open subtask
+	setStatus(design.ID, "done")
+	if task := get(epic.ID); task.Status != "done" {
+		t.Fatalf("epic status = %s", task.Status)
+	}
+	if task := get(build.ID); task.Status == "done" {
+		t.Fatalf("task without auto_complete was completed")
+	}
+
+	// Deleting a parent promotes its subtasks
+	s.do(http.MethodDelete, fmt.Sprintf("/api/v1/tasks/%d", build.ID), owner.Token, nil).expect(http.StatusOK)
+	if task := get(backend.ID); task.ParentID != nil {
+		t.Fatalf("subtask of a deleted task has parent %d", *task.ParentID)
+	}
+}
+
+func TestTaskDependencies(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	project := s.createProject(owner, "Launch")
+
+	design := s.createTask(owner, models.CreateTaskRequest{Title: "Design", ProjectID: &project.ID, EstimatedHours: 8})
+	migrate := s.createTask(owner, models.CreateTaskRequest{Title: "Migrate", ProjectID: &project.ID, EstimatedHours: 4})
+	review := s.createTask(owner, models.CreateTaskRequest{Title: "Review", ProjectID: &project.ID, EstimatedHours: 1})
+	docs := s.createTask(owner, models.CreateTaskRequest{Title: "Docs", ProjectID: &project.ID, EstimatedHours: 2})
+
+	link := func(id int, linkType string, other int) *testResponse {
+		t.Helper()
+		return s.do(http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/links", id), owner.Token,
+			models.CreateTaskLinkRequest{Type: linkType, TaskID: other})
+	}
+	setStatus := func(id int, body map[string]interface{}) *testResponse {
+		t.Helper()
+		return s.do(http.MethodPatch, fmt.Sprintf("/api/v1/tasks/%d/status", id), owner.Token, body)
+	}
+
+	// blocked_by is stored as the inverse blocks link
+	created := &models.TaskLink{}
+	link(migrate.ID, models.LinkBlockedBy, design.ID).expect(http.StatusCreated).data(created)
+	if created.SourceID != design.ID || created.TargetID != migrate.ID || created.Type != models.LinkBlocks {
+		t.Fatalf("link = %+v", created)
+	}
+	last := &models.TaskLink{}
+	link(migrate.ID, models.LinkBlocks, review.ID).expect(http.StatusCreated).data(last)
+	link(docs.ID, models.LinkRelatesTo, design.ID).expect(http.StatusCreated)
+
+	// A cycle is reported like the dependency graph's, each task blocking the next
+	var cycle struct {
+		Error struct {
+			Cycle []int `json:"cycle"`
+		} `json:"error"`
+	}
+	link(review.ID, models.LinkBlocks, design.ID).expect(http.StatusConflict).decode(&cycle)
+	if want := []int{design.ID, migrate.ID, review.ID}; fmt.Sprint(cycle.Error.Cycle) != fmt.Sprint(want) {
+		t.Fatalf("cycle = %v, want %v", cycle.Error.Cycle, want)
+	}
+	link(design.ID, models.LinkBlocks, migrate.ID).expect(http.StatusConflict)
+	link(design.ID, models.LinkBlocks, design.ID).expect(http.StatusBadRequest)
+
+	var links []*models.TaskLink
+	s.do(http.MethodGet, fmt.Sprintf("/api/v1/tasks/%d/links", migrate.ID), owner.Token, nil).expect(http.StatusOK).data(&links)
+	if len(links) != 2 {
+		t.Fatalf("links = %v", links)
+	}
+
+	// Open blockers hold a task back unless forced
+	res := setStatus(migrate.ID, map[string]interface{}{"status": "in_progress"}).expect(http.StatusConflict)
+	var blocked struct {
+		Error struct {
+			BlockedBy []int `json:"blocked_by"`
+		} `json:"error"`
+	}
+	res.decode(&blocked)
+	if len(blocked.Error.BlockedBy) != 1 || blocked.Error.BlockedBy[0] != design.ID {
+		t.Fatalf("blocked_by = %v", blocked.Error.BlockedBy)
+	}
+	s.do(http.MethodPut, fmt.Sprintf("/api/v1/tasks/%d", migrate.ID), owner.Token, models.UpdateTaskRequest{Status: "done"}).
+		expect(http.StatusConflict)
+	setStatus(migrate.ID, map[string]interface{}{"status": "review"}).expect(http.StatusOK)
+	setStatus(migrate.ID, map[string]interface{}{"status": "in_progress", "force": true}).expect(http.StatusOK)
+
+	// The chain of blockers is the critical path; the unrelated task has slack
+	graph := &models.DependencyGraph{}
+	s.do(http.MethodGet, fmt.Sprintf("/api/v1/projects/%d/dependencies", project.ID), owner.Token, nil).expect(http.StatusOK).data(graph)
+	if fmt.Sprint(graph.CriticalPath) != fmt.Sprint([]int{design.ID, migrate.ID, review.ID}) || graph.DurationHours != 13 {
+		t.Fatalf("critical path = %v (%v hours)", graph.CriticalPath, graph.DurationHours)
+	}
+	if len(graph.Nodes) != 4 || len(graph.Edges) != 3 {
+		t.Fatalf("graph has %d nodes and %d edges", len(graph.Nodes), len(graph.Edges))
+	}
+	for _, node := range graph.Nodes {
+		if node.TaskID == docs.ID && (node.Critical || node.Slack == nil || *node.Slack != 11) {
+			t.Fatalf("docs node = %+v", node)
+		}
+	}
+
+	// Done blockers no longer count
+	setStatus(design.ID, map[string]interface{}{"status": "done"}).expect(http.StatusOK)
+	s.do(http.MethodDelete, fmt.Sprintf("/api/v1/tasks/%d/links/%d", review.ID, last.ID), owner.Token, nil).expect(http.StatusOK)
+	setStatus(review.ID, map[string]interface{}{"status": "done"}).expect(http.StatusOK)
+	setStatus(migrate.ID, map[string]interface{}{"status": "done"}).expect(http.StatusOK)
+
+	// A blocked parent is not completed with its subtasks
+	parent := s.createTask(owner, models.CreateTaskRequest{Title: "Release", ProjectID: &project.ID, AutoComplete: true})
+	child := s.createTask(owner, models.CreateTaskRequest{Title: "Tag", ParentID: &parent.ID})
+	link(parent.ID, models.LinkBlockedBy, docs.ID).expect(http.StatusCreated)
+	setStatus(child.ID, map[string]interface{}{"status": "done"}).expect(http.StatusOK)
+	got := &models.Task{}
+	s.do(http.MethodGet, fmt.Sprintf("/api/v1/tasks/%d", parent.ID), owner.Token, nil).expect(http.StatusOK).data(got)
+	if got.Status != "todo" {
+		t.Fatalf("blocked parent status = %q", got.Status)
+	}
+	// Blocking another task needs edit rights on it, view rights suffice otherwise
+	viewer := s.register("cat")
+	s.addMember(owner, project.ID, viewer.ID, models.ProjectRoleViewer)
+	own := s.createProject(viewer, "Side project")
+	side := s.createTask(viewer, models.CreateTaskRequest{Title: "Side", ProjectID: &own.ID})
+	viewerLink := func(linkType string) *testResponse {
+		t.Helper()
+		return s.do(http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/links", side.ID), viewer.Token,
+			models.CreateTaskLinkRequest{Type: linkType, TaskID: parent.ID})
+	}
+	viewerLink(models.LinkBlocks).expect(http.StatusForbidden)
+	viewerLink(models.LinkBlockedBy).expect(http.StatusCreated)
+}
+
+func TestDependencyCycle(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	project := s.createProject(owner, "Loop")
+
+	var ids []int
+	for _, title := range []string{"First", "Second", "Third"} {
+		ids = append(ids, s.createTask(owner, models.CreateTaskRequest{Title: title, ProjectID: &project.ID}).ID)
+	}
+
+	// Links made before cycles were checked, or by racing requests
+	for i, id := range ids {
+		_, err := s.db.ExecContext(context.Background(),
+			"INSERT INTO task_links (source_id, target_id, type, created_at) VALUES ($1, $2, $3, $4)",
+			id, ids[(i+1)%len(ids)], models.LinkBlocks, time.Now())
+		if err != nil {
+			t.Fatalf("insert link: %v", err)
+		}
+	}
+
+	res := s.do(http.MethodGet, fmt.Sprintf("/api/v1/projects/%d/dependencies", project.ID), owner.Token, nil).expect(http.StatusConflict)
+	var body struct {
+		Error struct {
+			Cycle []int `json:"cycle"`
+		} `json:"error"`
+	}
+	res.decode(&body)
+	if fmt.Sprint(body.Error.Cycle) != fmt.Sprint(ids) {
+		t.Fatalf("cycle = %v, want %v", body.Error.Cycle, ids)
+	}
+}
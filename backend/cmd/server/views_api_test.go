@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+func TestSavedViews(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("ann")
+	member := s.register("bob")
+	viewer := s.register("cat")
+	outsider := s.register("dan")
+
+	project := s.createProject(owner, "Website")
+	s.addMember(owner, project.ID, member.ID, models.ProjectRoleMember)
+	s.addMember(owner, project.ID, viewer.ID, models.ProjectRoleViewer)
+
+	urgent := s.createTask(owner, models.CreateTaskRequest{Title: "Fix login", Priority: "high", ProjectID: &project.ID})
+	later := s.createTask(owner, models.CreateTaskRequest{Title: "Polish footer", Priority: "low", ProjectID: &project.ID})
+	mine := s.createTask(outsider, models.CreateTaskRequest{Title: "Fix my printer", Priority: "high"})
+
+	createView := func(user *testUser, req models.SavedViewRequest) *models.SavedView {
+		t.Helper()
+		view := &models.SavedView{}
+		s.do(http.MethodPost, "/api/v1/views", user.Token, req).expect(http.StatusCreated).data(view)
+		return view
+	}
+	taskIDs := func(user *testUser, path string) []int {
+		t.Helper()
+		var tasks []*models.Task
+		s.do(http.MethodGet, path, user.Token, nil).expect(http.StatusOK).data(&tasks)
+		ids := make([]int, len(tasks))
+		for i, task := range tasks {
+			ids[i] = task.ID
+		}
+		return ids
+	}
+
+	// A view shared with the project runs for its members
+	shared := createView(member, models.SavedViewRequest{
+		Name:      "Open work",
+		Query:     fmt.Sprintf("project:%d status:!done sort:priority", project.ID),
+		Columns:   []string{"title", "priority", "due_date"},
+		ProjectID: &project.ID,
+	})
+	if len(shared.Columns) != 3 || shared.DeveloperID != member.ID {
+		t.Fatalf("view = %+v", shared)
+	}
+	path := fmt.Sprintf("/api/v1/views/%d/tasks", shared.ID)
+	if ids := taskIDs(viewer, path); fmt.Sprint(ids) != fmt.Sprint([]int{later.ID, urgent.ID}) {
+		t.Fatalf("view tasks = %v", ids)
+	}
+	if ids := taskIDs(viewer, path+"?sort=-priority"); fmt.Sprint(ids) != fmt.Sprint([]int{urgent.ID, later.ID}) {
+		t.Fatalf("view tasks sorted by -priority = %v", ids)
+	}
+	if ids := taskIDs(viewer, path+"?q=footer"); fmt.Sprint(ids) != fmt.Sprint([]int{later.ID}) {
+		t.Fatalf("view tasks narrowed to footer = %v", ids)
+	}
+	s.do(http.MethodGet, path, outsider.Token, nil).expect(http.StatusForbidden)
+
+	// Personal views are private, and run with the caller's visibility
+	personal := createView(outsider, models.SavedViewRequest{Name: "Fixes", Query: "fix"})
+	if ids := taskIDs(outsider, fmt.Sprintf("/api/v1/views/%d/tasks", personal.ID)); fmt.Sprint(ids) != fmt.Sprint([]int{mine.ID}) {
+		t.Fatalf("personal view tasks = %v", ids)
+	}
+	s.do(http.MethodGet, fmt.Sprintf("/api/v1/views/%d", personal.ID), owner.Token, nil).expect(http.StatusForbidden)
+
+	var views []*models.SavedView
+	s.do(http.MethodGet, "/api/v1/views", viewer.Token, nil).expect(http.StatusOK).data(&views)
+	if len(views) != 1 || views[0].ID != shared.ID {
+		t.Fatalf("viewer lists %d views", len(views))
+	}
+	s.do(http.MethodGet, "/api/v1/views", outsider.Token, nil).expect(http.StatusOK).data(&views)
+	if len(views) != 1 || views[0].ID != personal.ID {
+		t.Fatalf("outsider lists %d views", len(views))
+	}
+
+	// Viewers and outsiders cannot share views, and queries are checked
+	res := s.do(http.MethodPost, "/api/v1/views", viewer.Token, models.SavedViewRequest{Name: "Mine", ProjectID: &project.ID}).expect(http.StatusForbidden)
+	if msg := res.errorMessage(); msg != "Project viewers cannot share views" {
+		t.Fatalf("error = %q", msg)
+	}
+	s.do(http.MethodPost, "/api/v1/views", outsider.Token, models.SavedViewRequest{Name: "Theirs", ProjectID: &project.ID}).expect(http.StatusForbidden)
+	s.do(http.MethodPost, "/api/v1/views", owner.Token, models.SavedViewRequest{Name: "Bad", Query: "status:lost"}).expect(http.StatusBadRequest)
+	s.do(http.MethodPost, "/api/v1/views", owner.Token, models.SavedViewRequest{Name: "Bad", Columns: []string{"title", "title"}}).expect(http.StatusBadRequest)
+
+	// The view's owner and the project owner manage shared views
+	update := models.SavedViewRequest{Name: "Open work", Query: fmt.Sprintf("project:%d priority:high", project.ID), ProjectID: &project.ID}
+	s.do(http.MethodPut, fmt.Sprintf("/api/v1/views/%d", shared.ID), viewer.Token, update).expect(http.StatusForbidden)
+	s.do(http.MethodPut, fmt.Sprintf("/api/v1/views/%d", shared.ID), owner.Token, update).expect(http.StatusOK)
+	if ids := taskIDs(member, path); fmt.Sprint(ids) != fmt.Sprint([]int{urgent.ID}) {
+		t.Fatalf("updated view tasks = %v", ids)
+	}
+	s.do(http.MethodDelete, fmt.Sprintf("/api/v1/views/%d", shared.ID), member.Token, nil).expect(http.StatusOK)
+	s.do(http.MethodGet, path, member.Token, nil).expect(http.StatusNotFound)
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"strconv"
 
@@ -8,25 +9,93 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// DefaultJWTSecret is the development fallback for JWT_SECRET. It is public,
+// so production refuses to start with it.
+const DefaultJWTSecret = "super-secret-key-change-in-production"
+
 type Config struct {
 	// Server
 	AppPort string
 	AppEnv  string
 
-	// Database
+	// Database; DBDriver is "postgres" or "sqlite", which keeps everything in
+	// the file at DBPath and ignores the connection settings
+	DBDriver   string
+	DBPath     string
 	DBHost     string
 	DBPort     string
 	DBUser     string
 	DBPassword string
 	DBName     string
 
-	// Redis
-	RedisHost string
-	RedisPort string
+	// Longest a single database statement may run, e.g. "30s"; "0" disables the limit
+	DBStatementTimeout string
+
+	// Migrations
+	AutoMigrate bool
+
+	// Redis; shared state such as login lockouts stays in memory when RedisHost is empty
+	RedisHost     string
+	RedisPort     string
+	RedisPassword string
+
+	// Reverse proxies, as CIDRs, whose X-Forwarded-For and X-Real-IP headers
+	// are trusted; requests from other peers are identified by their address
+	TrustedProxies string
+
+	// Rate limiting, as <requests>/<period>; buckets live in Redis when RedisHost is set
+	RateLimitEnabled bool
+	RateLimitAuth    string
+	RateLimitRead    string
+	RateLimitWrite   string
+
+	// Login lockout
+	LoginMaxFailures   int
+	LoginIPMaxFailures int
+	LoginLockoutBase   string
+	LoginLockoutMax    string
+	LoginFailureWindow string
 
 	// JWT
-	JWTSecret string
-	JWTExpiry string
+	JWTSecret        string
+	JWTExpiry        string
+	JWTRefreshExpiry string
+	JWTIssuer        string
+
+	// Asymmetric JWT signing keys; when JWTKeyDir is set, JWTSecret is unused
+	JWTKeyDir       string
+	JWTSigningKeyID string
+
+	// Two-factor authentication
+	TOTPIssuer string
+
+	// Local (email and password) login; can be turned off when single sign-on is used
+	LocalLoginEnabled bool
+
+	// Open registration; when off, accounts are created through invitations
+	RegistrationOpen bool
+
+	// OpenID Connect single sign-on
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       string
+	OIDCRoleClaim    string
+	OIDCRoleMapping  string
+	OIDCDefaultRole  string
+
+	// Frontend base URL, used for links in emails
+	AppURL string
+
+	// Mail
+	MailDriver   string
+	MailFrom     string
+	MailDir      string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
 }
 
 var AppConfig *Config
@@ -41,19 +110,78 @@ func Load() *Config {
 		AppEnv:  getEnv("APP_ENV", "development"),
 
 		// Database
+		DBDriver:   getEnv("DB_DRIVER", "postgres"),
+		DBPath:     getEnv("DB_PATH", "taskmanager.db"),
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5433"),
 		DBUser:     getEnv("DB_USER", "taskmanager"),
 		DBPassword: getEnv("DB_PASSWORD", "taskmanager123"),
 		DBName:     getEnv("DB_NAME", "taskmanager"),
 
+		DBStatementTimeout: getEnv("DB_STATEMENT_TIMEOUT", "30s"),
+
+		// Migrations
+		AutoMigrate: getEnvAsBool("DB_AUTO_MIGRATE", false),
+
 		// Redis
-		RedisHost: getEnv("REDIS_HOST", "localhost"),
-		RedisPort: getEnv("REDIS_PORT", "6380"),
+		RedisHost:     getEnv("REDIS_HOST", ""),
+		RedisPort:     getEnv("REDIS_PORT", "6380"),
+		RedisPassword: getEnv("REDIS_PASSWORD", ""),
+
+		// Reverse proxies
+		TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
+
+		// Rate limiting
+		RateLimitEnabled: getEnvAsBool("RATE_LIMIT_ENABLED", true),
+		RateLimitAuth:    getEnv("RATE_LIMIT_AUTH", "20/m"),
+		RateLimitRead:    getEnv("RATE_LIMIT_READ", "600/m"),
+		RateLimitWrite:   getEnv("RATE_LIMIT_WRITE", "120/m"),
+
+		// Login lockout
+		LoginMaxFailures:   getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures: getEnvAsInt("LOGIN_IP_MAX_FAILURES", 20),
+		LoginLockoutBase:   getEnv("LOGIN_LOCKOUT_BASE", "1m"),
+		LoginLockoutMax:    getEnv("LOGIN_LOCKOUT_MAX", "1h"),
+		LoginFailureWindow: getEnv("LOGIN_FAILURE_WINDOW", "24h"),
 
 		// JWT
-		JWTSecret: getEnv("JWT_SECRET", "super-secret-key-change-in-production"),
-		JWTExpiry: getEnv("JWT_EXPIRY", "24h"),
+		JWTSecret:        getEnv("JWT_SECRET", DefaultJWTSecret),
+		JWTExpiry:        getEnv("JWT_EXPIRY", "24h"),
+		JWTRefreshExpiry: getEnv("JWT_REFRESH_EXPIRY", "168h"),
+		JWTIssuer:        getEnv("JWT_ISSUER", "taskmanager"),
+		JWTKeyDir:        getEnv("JWT_KEY_DIR", ""),
+		JWTSigningKeyID:  getEnv("JWT_SIGNING_KEY_ID", ""),
+
+		// Two-factor authentication
+		TOTPIssuer: getEnv("TOTP_ISSUER", "Task Manager"),
+
+		// Local login
+		LocalLoginEnabled: getEnvAsBool("LOCAL_LOGIN_ENABLED", true),
+
+		// Registration
+		RegistrationOpen: getEnvAsBool("REGISTRATION_OPEN", true),
+
+		// OpenID Connect
+		OIDCIssuerURL:    getEnv("OIDC_ISSUER_URL", ""),
+		OIDCClientID:     getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:  getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/v1/auth/oidc/callback"),
+		OIDCScopes:       getEnv("OIDC_SCOPES", "openid email profile"),
+		OIDCRoleClaim:    getEnv("OIDC_ROLE_CLAIM", "groups"),
+		OIDCRoleMapping:  getEnv("OIDC_ROLE_MAPPING", ""),
+		OIDCDefaultRole:  getEnv("OIDC_DEFAULT_ROLE", "developer"),
+
+		// Frontend
+		AppURL: getEnv("APP_URL", "http://localhost:3000"),
+
+		// Mail
+		MailDriver:   getEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "Task Manager <no-reply@localhost>"),
+		MailDir:      getEnv("MAIL_DIR", "mail"),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
 	}
 
 	AppConfig = config
@@ -61,6 +189,17 @@ func Load() *Config {
 	return config
 }
 
+// Validate checks settings that must not be left at their defaults
+func (c *Config) Validate() error {
+	if c.JWTKeyDir != "" && c.JWTSigningKeyID == "" {
+		return errors.New("JWT_SIGNING_KEY_ID is required when JWT_KEY_DIR is set")
+	}
+	if c.IsProduction() && c.JWTKeyDir == "" && c.JWTSecret == DefaultJWTSecret {
+		return errors.New("JWT_SECRET must be changed from its default in production (or set JWT_KEY_DIR)")
+	}
+	return nil
+}
+
 func (c *Config) IsDevelopment() bool {
 	return c.AppEnv == "development"
 }
@@ -88,3 +227,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return intValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return boolValue
+}
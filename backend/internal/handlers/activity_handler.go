@@ -4,34 +4,28 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/ardani17/taskmanager/internal/policy"
 	"github.com/ardani17/taskmanager/internal/repository"
 	"github.com/ardani17/taskmanager/pkg/utils"
 )
 
 // ActivityHandler handles activity endpoints
 type ActivityHandler struct {
-	repo *repository.ActivityRepository
+	repo   repository.ActivityStore
+	policy *policy.Policy
 }
 
 // NewActivityHandler creates a new activity handler
-func NewActivityHandler(repo *repository.ActivityRepository) *ActivityHandler {
-	return &ActivityHandler{repo: repo}
+func NewActivityHandler(repo repository.ActivityStore, policy *policy.Policy) *ActivityHandler {
+	return &ActivityHandler{repo: repo, policy: policy}
 }
 
 // List handles GET /api/v1/activity
 func (h *ActivityHandler) List(w http.ResponseWriter, r *http.Request) {
 	// Parse pagination params
-	limit := 50
-	offset := 0
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if val, err := strconv.Atoi(l); err == nil && val > 0 {
-			limit = val
-		}
-	}
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if val, err := strconv.Atoi(o); err == nil && val >= 0 {
-			offset = val
-		}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
 
 	// Parse filters
@@ -48,15 +42,31 @@ func (h *ActivityHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	activities, total, err := h.repo.List(limit, offset, developerID, taskID)
+	// Only activities of projects and tasks the caller can see are listed
+	visibleTo := h.policy.VisibilityScope(policy.ActorFromRequest(r))
+	activities, result, err := h.repo.List(r.Context(), page, visibleTo, developerID, taskID)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch activities")
 		return
 	}
 
-	utils.JSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    activities,
-		"total":   total,
-	})
+	writePage(w, r, activities, result)
+}
+
+// Security handles GET /api/v1/activity/security
+// Lists security events such as login lockouts. Admin only.
+func (h *ActivityHandler) Security(w http.ResponseWriter, r *http.Request) {
+	// Parse pagination params
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	events, result, err := h.repo.ListSecurityEvents(r.Context(), page)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch security events")
+		return
+	}
+
+	writePage(w, r, events, result)
 }
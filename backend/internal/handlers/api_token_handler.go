@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ardani17/taskmanager/internal/middleware"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/internal/services"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// APITokenHandler handles personal API token endpoints
+type APITokenHandler struct {
+	apiTokenService *services.APITokenService
+	userRepo        repository.DeveloperStore
+}
+
+// NewAPITokenHandler creates a new API token handler
+func NewAPITokenHandler(apiTokenService *services.APITokenService, userRepo repository.DeveloperStore) *APITokenHandler {
+	return &APITokenHandler{
+		apiTokenService: apiTokenService,
+		userRepo:        userRepo,
+	}
+}
+
+// List handles GET /api/v1/auth/tokens
+func (h *APITokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.apiTokenService.List(r.Context(), middleware.GetUserID(r))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch API tokens")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    tokens,
+		"total":   len(tokens),
+	})
+}
+
+// Create handles POST /api/v1/auth/tokens
+// The token is returned only in this response
+func (h *APITokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate request
+	if errors := req.Validate(); len(errors) > 0 {
+		utils.ValidationErrorResponse(w, errors)
+		return
+	}
+
+	developer, err := h.userRepo.GetByID(r.Context(), middleware.GetUserID(r))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if developer == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	secret, token, err := h.apiTokenService.Create(r.Context(), developer, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrScopeNotAllowed) {
+			utils.ForbiddenResponse(w, "admin_required", "Only admins can create tokens with the admin scope")
+			return
+		}
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create API token")
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "API token created. Copy it now, it will not be shown again",
+		"data": map[string]interface{}{
+			"token":     secret,
+			"api_token": token,
+		},
+	})
+}
+
+// Revoke handles DELETE /api/v1/auth/tokens/{id}
+func (h *APITokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+
+	revoked, err := h.apiTokenService.Revoke(r.Context(), middleware.GetUserID(r), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to revoke API token")
+		return
+	}
+	if !revoked {
+		utils.ErrorResponse(w, http.StatusNotFound, "API token not found")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "API token revoked successfully",
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ardani17/taskmanager/internal/middleware"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/policy"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// logMutation stores the activity and audit entry describing a mutation in
+// the mutation's transaction, so a failure rolls the mutation back. Either
+// may be nil. The audit entry, built with models.NewAuditEntry, gets the
+// actor, request ID and IP of the request; updates that changed nothing are
+// not audited.
+func logMutation(tx *repository.Tx, r *http.Request, activity *models.Activity, entry *models.AuditEntry) error {
+	if activity != nil {
+		if err := tx.Activities().Create(r.Context(), activity); err != nil {
+			return err
+		}
+	}
+
+	if entry == nil || !fillAudit(r, entry) {
+		return nil
+	}
+	return tx.Audit().Create(r.Context(), entry)
+}
+
+// fillAudit adds the actor, request ID and IP of the request to an audit
+// entry and reports whether the entry is worth storing
+func fillAudit(r *http.Request, entry *models.AuditEntry) bool {
+	if entry.Action == models.AuditUpdate && len(entry.Changes) == 0 {
+		return false
+	}
+
+	if entry.ActorID == nil {
+		if userID := middleware.GetUserID(r); userID > 0 {
+			entry.ActorID = &userID
+		}
+	}
+	entry.RequestID = chiMiddleware.GetReqID(r.Context())
+	entry.IPAddress = utils.ClientIP(r)
+	return true
+}
+
+// writeHistory responds with a page of the audit history of an entity.
+// Where requests came from, and the actors' emails, are for admins only.
+func writeHistory(w http.ResponseWriter, r *http.Request, repo *repository.AuditRepository, entityType string, entityID int) {
+	// Parse pagination params
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	entries, result, err := repo.ListByEntity(r.Context(), entityType, entityID, page)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch history")
+		return
+	}
+
+	if !policy.ActorFromRequest(r).IsAdmin() {
+		for _, entry := range entries {
+			entry.RequestID = ""
+			entry.IPAddress = ""
+			if entry.Actor != nil {
+				entry.Actor.Email = ""
+			}
+		}
+	}
+
+	writePage(w, r, entries, result)
+}
@@ -2,32 +2,72 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strconv"
+	"time"
 
+	"github.com/ardani17/taskmanager/internal/lockout"
 	"github.com/ardani17/taskmanager/internal/middleware"
 	"github.com/ardani17/taskmanager/internal/models"
 	"github.com/ardani17/taskmanager/internal/repository"
 	"github.com/ardani17/taskmanager/internal/services"
 	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
-	jwtService *services.JWTService
-	userRepo   *repository.DeveloperRepository
+	sessionService   *services.SessionService
+	accountService   *services.AccountService
+	twoFactorService *services.TwoFactorService
+	loginGuard       *lockout.Guard
+	userRepo         repository.DeveloperStore
+	activityRepo     repository.ActivityStore
+	uow              *repository.UnitOfWork
+
+	// localLogin enables registration and login with email and password
+	localLogin bool
+	// registrationOpen lets anyone register; otherwise accounts come from invitations
+	registrationOpen bool
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(jwtService *services.JWTService, userRepo *repository.DeveloperRepository) *AuthHandler {
+func NewAuthHandler(sessionService *services.SessionService, accountService *services.AccountService, twoFactorService *services.TwoFactorService, loginGuard *lockout.Guard, userRepo repository.DeveloperStore, activityRepo repository.ActivityStore, uow *repository.UnitOfWork, localLogin, registrationOpen bool) *AuthHandler {
 	return &AuthHandler{
-		jwtService: jwtService,
-		userRepo:   userRepo,
+		sessionService:   sessionService,
+		accountService:   accountService,
+		twoFactorService: twoFactorService,
+		loginGuard:       loginGuard,
+		userRepo:         userRepo,
+		activityRepo:     activityRepo,
+		uow:              uow,
+		localLogin:       localLogin,
+		registrationOpen: registrationOpen,
 	}
 }
 
+// requireLocalLogin rejects password-based endpoints when local login is
+// disabled and reports whether the request may continue
+func (h *AuthHandler) requireLocalLogin(w http.ResponseWriter) bool {
+	if !h.localLogin {
+		utils.ForbiddenResponse(w, "local_login_disabled", "Password login is disabled, please use single sign-on")
+		return false
+	}
+	return true
+}
+
 // Register handles POST /api/v1/auth/register
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	if !h.requireLocalLogin(w) {
+		return
+	}
+	if !h.registrationOpen {
+		utils.ForbiddenResponse(w, "registration_closed", "Registration is by invitation only")
+		return
+	}
+
 	var req models.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
@@ -53,7 +93,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if email already exists
-	existingUser, err := h.userRepo.GetByEmail(req.Email)
+	existingUser, err := h.userRepo.GetByEmail(r.Context(), req.Email)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to check email")
 		return
@@ -74,20 +114,34 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	developer := req.ToDeveloper(passwordHash)
 
 	// Save to database
-	if err := h.userRepo.Create(developer); err != nil {
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Developers().Create(r.Context(), developer); err != nil {
+			return err
+		}
+
+		entry := models.NewAuditEntry(models.AuditEntityDeveloper, developer.ID, nil, developer)
+		entry.ActorID = &developer.ID
+		return logMutation(tx, r, nil, entry)
+	})
+	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create user")
 		return
 	}
 
-	// Generate JWT tokens (convert int ID to string)
-	tokenPair, err := h.jwtService.GenerateToken(strconv.Itoa(developer.ID), developer.Email, developer.Role)
+	// Ask the developer to confirm their email; the account works meanwhile
+	if err := h.accountService.SendVerification(r.Context(), developer); err != nil {
+		log.Error().Err(err).Int("developer_id", developer.ID).Msg("Failed to send verification email")
+	}
+
+	// Start a session and generate JWT tokens
+	tokenPair, err := h.sessionService.Start(r.Context(), developer, r.UserAgent(), utils.ClientIP(r))
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
 	// Update status to online
-	h.userRepo.UpdateStatus(developer.ID, "online")
+	h.userRepo.UpdateStatus(r.Context(), developer.ID, "online")
 
 	// Return response
 	utils.JSON(w, http.StatusCreated, map[string]interface{}{
@@ -96,9 +150,10 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		"data": map[string]interface{}{
 			"developer": developer,
 			"token": map[string]interface{}{
-				"access_token": tokenPair.AccessToken,
-				"token_type":   tokenPair.TokenType,
-				"expires_in":   tokenPair.ExpiresIn,
+				"access_token":  tokenPair.AccessToken,
+				"refresh_token": tokenPair.RefreshToken,
+				"token_type":    tokenPair.TokenType,
+				"expires_in":    tokenPair.ExpiresIn,
 			},
 		},
 	})
@@ -106,6 +161,10 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 // Login handles POST /api/v1/auth/login
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if !h.requireLocalLogin(w) {
+		return
+	}
+
 	var req models.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
@@ -118,40 +177,69 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Reject accounts and IPs that are locked out after too many failures
+	ip := utils.ClientIP(r)
+	wait, err := h.loginGuard.Check(r.Context(), req.Email, ip)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check login lockout")
+	}
+	if wait > 0 {
+		utils.TooManyRequestsResponse(w, wait, "Too many failed login attempts, please try again later")
+		return
+	}
+
 	// Fetch developer from database
-	developer, err := h.userRepo.GetByEmail(req.Email)
+	developer, err := h.userRepo.GetByEmail(r.Context(), req.Email)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
 		return
 	}
 
-	if developer == nil {
+	// Verify password. Accounts without one (e.g. provisioned through single
+	// sign-on) cannot log in with a password until they set one.
+	if developer == nil || developer.PasswordHash == "" || !utils.CheckPassword(req.Password, developer.PasswordHash) {
+		if wait := loginFailed(r, h.loginGuard, h.activityRepo, req.Email, ip, developer); wait > 0 {
+			utils.TooManyRequestsResponse(w, wait, "Too many failed login attempts, please try again later")
+			return
+		}
 		utils.ErrorResponse(w, http.StatusUnauthorized, "Invalid email or password")
 		return
 	}
 
-	// Verify password (check if password_hash column exists and has value)
-	// For existing users without password, allow any password for now
-	if developer.PasswordHash == "" {
-		// Legacy user - set password hash
-		passwordHash, _ := utils.HashPassword(req.Password)
-		developer.PasswordHash = passwordHash
+	if err := h.loginGuard.Succeed(r.Context(), req.Email); err != nil {
+		log.Error().Err(err).Msg("Failed to reset login failures")
 	}
 
-	if !utils.CheckPassword(req.Password, developer.PasswordHash) {
-		utils.ErrorResponse(w, http.StatusUnauthorized, "Invalid email or password")
+	// Developers using (or required to use) two-factor authentication get a
+	// challenge instead of tokens and continue at /auth/login/2fa
+	challenge, err := h.twoFactorService.BeginLogin(r.Context(), developer)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to check two-factor authentication")
+		return
+	}
+	if challenge != nil {
+		utils.JSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Two-factor authentication required",
+			"data": map[string]interface{}{
+				"two_factor_required": true,
+				"enrollment_required": challenge.EnrollmentRequired,
+				"challenge_token":     challenge.Token,
+				"expires_in":          challenge.ExpiresIn,
+			},
+		})
 		return
 	}
 
-	// Generate JWT tokens
-	tokenPair, err := h.jwtService.GenerateToken(strconv.Itoa(developer.ID), developer.Email, developer.Role)
+	// Start a session and generate JWT tokens
+	tokenPair, err := h.sessionService.Start(r.Context(), developer, r.UserAgent(), ip)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
 	// Update developer status
-	h.userRepo.UpdateStatus(developer.ID, "online")
+	h.userRepo.UpdateStatus(r.Context(), developer.ID, "online")
 	developer.Status = "online"
 
 	// Return response
@@ -161,14 +249,68 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		"data": map[string]interface{}{
 			"developer": developer,
 			"token": map[string]interface{}{
-				"access_token": tokenPair.AccessToken,
-				"token_type":   tokenPair.TokenType,
-				"expires_in":   tokenPair.ExpiresIn,
+				"access_token":  tokenPair.AccessToken,
+				"refresh_token": tokenPair.RefreshToken,
+				"token_type":    tokenPair.TokenType,
+				"expires_in":    tokenPair.ExpiresIn,
 			},
 		},
 	})
 }
 
+// loginFailed records a failed login to the account and logs a security
+// event for every lockout it starts. It returns how long the caller is now
+// locked out.
+func loginFailed(r *http.Request, guard *lockout.Guard, activityRepo repository.ActivityStore, account, ip string, developer *models.Developer) time.Duration {
+	lockouts, err := guard.Fail(r.Context(), account, ip)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to record login failure")
+	}
+
+	email := account
+	if developer != nil {
+		email = developer.Email
+	}
+
+	var wait time.Duration
+	for _, l := range lockouts {
+		if l.RetryAfter > wait {
+			wait = l.RetryAfter
+		}
+
+		activity := &models.Activity{
+			Metadata: models.JSONB{
+				"email":               email,
+				"ip":                  ip,
+				"failures":            l.Failures,
+				"retry_after_seconds": int(l.RetryAfter.Seconds()),
+			},
+			CreatedAt: now(),
+		}
+		if l.Scope == lockout.ScopeAccount {
+			activity.Action = models.ActionSecurityAccountLocked
+			activity.Description = "Account locked after failed logins: " + email
+			if developer != nil {
+				activity.DeveloperID = &developer.ID
+			}
+		} else {
+			activity.Action = models.ActionSecurityIPBlocked
+			activity.Description = "IP blocked after failed logins: " + ip
+		}
+		activityRepo.Create(r.Context(), activity)
+
+		log.Warn().
+			Str("scope", l.Scope).
+			Str("email", email).
+			Str("ip", ip).
+			Int("failures", l.Failures).
+			Dur("retry_after", l.RetryAfter).
+			Msg("Login locked out")
+	}
+
+	return wait
+}
+
 // Me handles GET /api/v1/auth/me
 // Returns the currently authenticated user
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
@@ -180,7 +322,7 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch full developer data from database
-	developer, err := h.userRepo.GetByID(userID)
+	developer, err := h.userRepo.GetByID(r.Context(), userID)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
 		return
@@ -206,8 +348,14 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Revoke the current session so its refresh tokens stop working
+	if err := h.sessionService.Revoke(r.Context(), middleware.GetSessionID(r), models.RevokeReasonLogout); err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
 	// Update developer status to offline
-	h.userRepo.UpdateStatus(userID, "inactive")
+	h.userRepo.UpdateStatus(r.Context(), userID, "inactive")
 
 	utils.JSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -215,6 +363,212 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// LogoutAll handles POST /api/v1/auth/logout-all
+// Revokes every session of the current user, including this one
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	revoked, err := h.sessionService.RevokeAll(r.Context(), userID, models.RevokeReasonLogoutAll)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	h.userRepo.UpdateStatus(r.Context(), userID, "inactive")
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Logged out of all sessions",
+		"data": map[string]interface{}{
+			"revoked": revoked,
+		},
+	})
+}
+
+// ListSessions handles GET /api/v1/auth/sessions
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	sessions, err := h.sessionService.List(r.Context(), userID, middleware.GetSessionID(r))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch sessions")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    sessions,
+		"total":   len(sessions),
+	})
+}
+
+// RevokeSession handles DELETE /api/v1/auth/sessions/{id}
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(sessionID); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	// Sessions of other users are reported as missing
+	revoked, err := h.sessionService.RevokeOwned(r.Context(), userID, sessionID, models.RevokeReasonUserRevoked)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+	if !revoked {
+		utils.ErrorResponse(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Session revoked successfully",
+	})
+}
+
+// ForgotPassword handles POST /api/v1/auth/forgot-password
+// The response is the same whether or not the email is registered
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if !h.requireLocalLogin(w) {
+		return
+	}
+
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Email == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	if err := h.accountService.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		log.Error().Err(err).Msg("Failed to send password reset email")
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "If the email is registered, a password reset link has been sent",
+	})
+}
+
+// ResetPassword handles POST /api/v1/auth/reset-password
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if !h.requireLocalLogin(w) {
+		return
+	}
+
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Token == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	// Validate password strength
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.accountService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		if errors.Is(err, services.ErrInvalidAccountToken) {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid or expired reset token")
+			return
+		}
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Password reset successfully, please log in again",
+	})
+}
+
+// VerifyEmail handles POST /api/v1/auth/verify-email
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Token == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(r.Context(), req.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidAccountToken) {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid or expired verification token")
+			return
+		}
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Email verified successfully",
+	})
+}
+
+// ResendVerification handles POST /api/v1/auth/verify-email/resend
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	developer, err := h.userRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if developer == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if developer.EmailVerified {
+		utils.ErrorResponse(w, http.StatusConflict, "Email already verified")
+		return
+	}
+
+	if err := h.accountService.SendVerification(r.Context(), developer); err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to send verification email")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Verification email sent",
+	})
+}
+
 // RefreshToken handles POST /api/v1/auth/refresh
 func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	var req models.RefreshRequest
@@ -228,10 +582,20 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Refresh the token
-	tokenPair, err := h.jwtService.RefreshToken(req.RefreshToken)
+	// Rotate the refresh token
+	tokenPair, err := h.sessionService.Refresh(r.Context(), req.RefreshToken, r.UserAgent(), utils.ClientIP(r))
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		switch {
+		case errors.Is(err, services.ErrTokenReused):
+			utils.ErrorResponse(w, http.StatusUnauthorized, "Refresh token has already been used; session revoked")
+		case errors.Is(err, services.ErrSessionRevoked):
+			utils.ErrorResponse(w, http.StatusUnauthorized, "Session has been revoked")
+		case errors.Is(err, services.ErrInvalidToken), errors.Is(err, services.ErrExpiredToken),
+			errors.Is(err, services.ErrInvalidClaims), errors.Is(err, services.ErrWrongTokenType):
+			utils.ErrorResponse(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to refresh token")
+		}
 		return
 	}
 
@@ -240,9 +604,10 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		"message": "Token refreshed successfully",
 		"data": map[string]interface{}{
 			"token": map[string]interface{}{
-				"access_token": tokenPair.AccessToken,
-				"token_type":   tokenPair.TokenType,
-				"expires_in":   tokenPair.ExpiresIn,
+				"access_token":  tokenPair.AccessToken,
+				"refresh_token": tokenPair.RefreshToken,
+				"token_type":    tokenPair.TokenType,
+				"expires_in":    tokenPair.ExpiresIn,
 			},
 		},
 	})
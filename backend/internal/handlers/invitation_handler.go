@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ardani17/taskmanager/internal/mailer"
+	"github.com/ardani17/taskmanager/internal/middleware"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/policy"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/internal/services"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// InvitationHandler handles invitation endpoints
+type InvitationHandler struct {
+	invitationService *services.InvitationService
+	sessionService    *services.SessionService
+	twoFactorService  *services.TwoFactorService
+	userRepo          repository.DeveloperStore
+	uow               *repository.UnitOfWork
+	policy            *policy.Policy
+
+	// localLogin enables accepting invitations with a password
+	localLogin bool
+}
+
+// NewInvitationHandler creates a new invitation handler
+func NewInvitationHandler(invitationService *services.InvitationService, sessionService *services.SessionService, twoFactorService *services.TwoFactorService, userRepo repository.DeveloperStore, uow *repository.UnitOfWork, policy *policy.Policy, localLogin bool) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+		sessionService:    sessionService,
+		twoFactorService:  twoFactorService,
+		userRepo:          userRepo,
+		uow:               uow,
+		policy:            policy,
+		localLogin:        localLogin,
+	}
+}
+
+// List handles GET /api/v1/invitations
+// Admins see every invitation, everyone else the invitations they sent
+func (h *InvitationHandler) List(w http.ResponseWriter, r *http.Request) {
+	// Parse pagination params
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	actor := policy.ActorFromRequest(r)
+	invitedBy := actor.ID
+	if actor.IsAdmin() {
+		invitedBy = 0
+	}
+
+	invitations, result, err := h.invitationService.List(r.Context(), page, invitedBy)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch invitations")
+		return
+	}
+
+	writePage(w, r, invitations, result)
+}
+
+// Create handles POST /api/v1/invitations
+// Admins may invite anyone; project owners may invite developers to their projects
+func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate request
+	if errors := req.Validate(); len(errors) > 0 {
+		utils.ValidationErrorResponse(w, errors)
+		return
+	}
+	if !utils.ValidateEmail(req.Email) {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid email format")
+		return
+	}
+
+	if !authorize(w, h.policy.CanInvite(r.Context(), policy.ActorFromRequest(r), &req)) {
+		return
+	}
+
+	inviter, err := h.userRepo.GetByID(r.Context(), middleware.GetUserID(r))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if inviter == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	var invitation *models.Invitation
+	var message *mailer.Message
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		invitation, message, err = h.invitationService.WithTx(tx).Create(r.Context(), inviter, &req)
+		if err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &inviter.ID,
+			ProjectID:   invitation.ProjectID,
+			Action:      models.ActionInvitationCreated,
+			Description: "Invitation sent to " + invitation.Email,
+			Metadata: models.JSONB{
+				"invitation_id": invitation.ID,
+				"email":         invitation.Email,
+				"role":          invitation.Role,
+				"project_id":    invitation.ProjectID,
+				"team_id":       invitation.TeamID,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, nil)
+	})
+
+	// The email goes out once the invitation is committed, without holding
+	// the transaction open. An invitation whose email failed is revoked.
+	if err == nil {
+		if err = h.invitationService.Send(message); err != nil {
+			h.revokeUnsent(r, invitation)
+		}
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmailRegistered):
+			utils.ErrorResponse(w, http.StatusConflict, "Email already registered")
+		case errors.Is(err, services.ErrInvitationProject):
+			utils.ErrorResponse(w, http.StatusNotFound, "Project not found")
+		case errors.Is(err, services.ErrInvitationTeam):
+			utils.ErrorResponse(w, http.StatusNotFound, "Team not found")
+		case errors.Is(err, services.ErrInvitationNotSent):
+			log.Error().Err(err).Str("email", req.Email).Msg("Failed to send invitation")
+			utils.ErrorResponse(w, http.StatusBadGateway, "Failed to send invitation email")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create invitation")
+		}
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "Invitation sent",
+		"data":    invitation,
+	})
+}
+
+// revokeUnsent revokes an invitation whose email could not be sent.
+// Failures are logged; the invitation then expires unused.
+func (h *InvitationHandler) revokeUnsent(r *http.Request, invitation *models.Invitation) {
+	err := h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		revoked, err := h.invitationService.WithTx(tx).Revoke(r.Context(), invitation.ID)
+		if err != nil || !revoked {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: invitation.InvitedBy,
+			ProjectID:   invitation.ProjectID,
+			Action:      models.ActionInvitationRevoked,
+			Description: "Invitation revoked for " + invitation.Email + ": the email could not be sent",
+			Metadata: models.JSONB{
+				"invitation_id": invitation.ID,
+				"email":         invitation.Email,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, nil)
+	})
+	if err != nil {
+		log.Error().Err(err).Int("invitation_id", invitation.ID).Msg("Failed to revoke unsent invitation")
+	}
+}
+
+// Revoke handles DELETE /api/v1/invitations/{id}
+func (h *InvitationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	invitation, err := h.invitationService.Get(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch invitation")
+		return
+	}
+	if invitation == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Invitation not found")
+		return
+	}
+
+	if !authorize(w, h.policy.CanRevokeInvitation(policy.ActorFromRequest(r), invitation)) {
+		return
+	}
+
+	userID := middleware.GetUserID(r)
+	revoked := false
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		revoked, err = h.invitationService.WithTx(tx).Revoke(r.Context(), id)
+		if err != nil || !revoked {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			ProjectID:   invitation.ProjectID,
+			Action:      models.ActionInvitationRevoked,
+			Description: "Invitation revoked for " + invitation.Email,
+			Metadata: models.JSONB{
+				"invitation_id": invitation.ID,
+				"email":         invitation.Email,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to revoke invitation")
+		return
+	}
+	if !revoked {
+		utils.ErrorResponse(w, http.StatusConflict, "Invitation is no longer pending")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Invitation revoked",
+	})
+}
+
+// Accept handles POST /api/v1/auth/invitations/accept
+// Creates the invitee's account and logs them in
+func (h *InvitationHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	if !h.localLogin {
+		utils.ForbiddenResponse(w, "local_login_disabled", "Password login is disabled, please use single sign-on")
+		return
+	}
+
+	var req models.AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate request
+	if errors := req.Validate(); len(errors) > 0 {
+		utils.ValidationErrorResponse(w, errors)
+		return
+	}
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var developer *models.Developer
+	var invitation *models.Invitation
+	err := h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		var err error
+		developer, invitation, err = h.invitationService.WithTx(tx).Accept(r.Context(), req.Token, req.Name, req.Password)
+		if err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &developer.ID,
+			ProjectID:   invitation.ProjectID,
+			Action:      models.ActionInvitationAccepted,
+			Description: developer.Name + " joined through an invitation",
+			Metadata: models.JSONB{
+				"invitation_id": invitation.ID,
+				"invited_by":    invitation.InvitedBy,
+				"role":          invitation.Role,
+				"project_id":    invitation.ProjectID,
+			},
+			CreatedAt: now(),
+		}
+		entry := models.NewAuditEntry(models.AuditEntityDeveloper, developer.ID, nil, developer)
+		entry.ActorID = &developer.ID
+		return logMutation(tx, r, activity, entry)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInvitation):
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invitation is invalid or has expired")
+		case errors.Is(err, services.ErrEmailRegistered):
+			utils.ErrorResponse(w, http.StatusConflict, "Email already registered")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to accept invitation")
+		}
+		return
+	}
+
+	// Roles that require two-factor authentication enroll before their first session
+	challenge, err := h.twoFactorService.BeginLogin(r.Context(), developer)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to check two-factor authentication")
+		return
+	}
+	if challenge != nil {
+		utils.JSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"message": "Invitation accepted, two-factor authentication required",
+			"data": map[string]interface{}{
+				"developer":           developer,
+				"two_factor_required": true,
+				"enrollment_required": challenge.EnrollmentRequired,
+				"challenge_token":     challenge.Token,
+				"expires_in":          challenge.ExpiresIn,
+			},
+		})
+		return
+	}
+
+	// Start a session and generate JWT tokens
+	tokenPair, err := h.sessionService.Start(r.Context(), developer, r.UserAgent(), utils.ClientIP(r))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	// Update status to online
+	h.userRepo.UpdateStatus(r.Context(), developer.ID, "online")
+	developer.Status = "online"
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "Invitation accepted",
+		"data": map[string]interface{}{
+			"developer": developer,
+			"token": map[string]interface{}{
+				"access_token":  tokenPair.AccessToken,
+				"refresh_token": tokenPair.RefreshToken,
+				"token_type":    tokenPair.TokenType,
+				"expires_in":    tokenPair.ExpiresIn,
+			},
+		},
+	})
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ardani17/taskmanager/internal/services"
+	"github.com/ardani17/taskmanager/pkg/utils"
+)
+
+// JWKSHandler publishes the public keys that verify access tokens
+type JWKSHandler struct {
+	jwtService *services.JWTService
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(jwtService *services.JWTService) *JWKSHandler {
+	return &JWKSHandler{jwtService: jwtService}
+}
+
+// JWKS handles GET /.well-known/jwks.json
+// Other services fetch this to verify tokens without sharing a secret. The key
+// set is empty while tokens are signed with JWT_SECRET.
+func (h *JWKSHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.JSON(w, http.StatusOK, h.jwtService.JWKS())
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/internal/services"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// OIDCHandler handles single sign-on endpoints
+type OIDCHandler struct {
+	oidcService      *services.OIDCService
+	sessionService   *services.SessionService
+	twoFactorService *services.TwoFactorService
+	userRepo         repository.DeveloperStore
+	uow              *repository.UnitOfWork
+}
+
+// NewOIDCHandler creates a new OIDC handler
+func NewOIDCHandler(oidcService *services.OIDCService, sessionService *services.SessionService, twoFactorService *services.TwoFactorService, userRepo repository.DeveloperStore, uow *repository.UnitOfWork) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService:      oidcService,
+		sessionService:   sessionService,
+		twoFactorService: twoFactorService,
+		userRepo:         userRepo,
+		uow:              uow,
+	}
+}
+
+// Login handles GET /api/v1/auth/oidc/login
+// Redirects to the identity provider, or returns its URL with ?redirect=false
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if !h.oidcService.Enabled() {
+		utils.ErrorResponse(w, http.StatusNotFound, "Single sign-on is not configured")
+		return
+	}
+
+	authURL, err := h.oidcService.BeginLogin(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to start OIDC login")
+		utils.ErrorResponse(w, http.StatusBadGateway, "Failed to reach identity provider")
+		return
+	}
+
+	if r.URL.Query().Get("redirect") == "false" {
+		utils.JSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"authorization_url": authURL,
+			},
+		})
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback handles GET /api/v1/auth/oidc/callback
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	if !h.oidcService.Enabled() {
+		utils.ErrorResponse(w, http.StatusNotFound, "Single sign-on is not configured")
+		return
+	}
+
+	q := r.URL.Query()
+	if providerError := q.Get("error"); providerError != "" {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Identity provider denied the login: "+providerError)
+		return
+	}
+	if q.Get("code") == "" || q.Get("state") == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Code and state are required")
+		return
+	}
+
+	// Provision the developer and record accounts created or changed by the
+	// identity provider together
+	var login *services.OIDCLogin
+	identity, err := h.oidcService.CompleteLogin(r.Context(), q.Get("code"), q.Get("state"))
+	if err == nil {
+		err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+			login, err = h.oidcService.WithTx(tx).Provision(r.Context(), identity)
+			if err != nil {
+				return err
+			}
+
+			entry := models.NewAuditEntry(models.AuditEntityDeveloper, login.Developer.ID, nil, login.Developer)
+			if login.Previous != nil {
+				entry = models.NewAuditEntry(models.AuditEntityDeveloper, login.Developer.ID, login.Previous, login.Developer)
+			}
+			entry.ActorID = &login.Developer.ID
+			return logMutation(tx, r, nil, entry)
+		})
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidOIDCState):
+			utils.ErrorResponse(w, http.StatusBadRequest, "Login has expired, please try again")
+		case errors.Is(err, services.ErrOIDCEmailMissing):
+			utils.ErrorResponse(w, http.StatusForbidden, "Identity provider did not share an email address")
+		case errors.Is(err, services.ErrOIDCEmailNotVerified):
+			utils.ErrorResponse(w, http.StatusConflict, "An account with this email exists, but the identity provider has not verified the address")
+		default:
+			log.Error().Err(err).Msg("OIDC login failed")
+			utils.ErrorResponse(w, http.StatusUnauthorized, "Single sign-on failed")
+		}
+		return
+	}
+	developer := login.Developer
+
+	// The identity provider's own checks do not replace a second factor:
+	// developers using (or required to use) two-factor authentication get a
+	// challenge instead of tokens and continue at /auth/login/2fa
+	challenge, err := h.twoFactorService.BeginLogin(r.Context(), developer)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to check two-factor authentication")
+		return
+	}
+	if challenge != nil {
+		utils.JSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Two-factor authentication required",
+			"data": map[string]interface{}{
+				"two_factor_required": true,
+				"enrollment_required": challenge.EnrollmentRequired,
+				"challenge_token":     challenge.Token,
+				"expires_in":          challenge.ExpiresIn,
+			},
+		})
+		return
+	}
+
+	// Start a session and generate JWT tokens
+	tokenPair, err := h.sessionService.Start(r.Context(), developer, r.UserAgent(), utils.ClientIP(r))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	// Update developer status
+	h.userRepo.UpdateStatus(r.Context(), developer.ID, "online")
+	developer.Status = "online"
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Login successful",
+		"data": map[string]interface{}{
+			"developer": developer,
+			"token": map[string]interface{}{
+				"access_token":  tokenPair.AccessToken,
+				"refresh_token": tokenPair.RefreshToken,
+				"token_type":    tokenPair.TokenType,
+				"expires_in":    tokenPair.ExpiresIn,
+			},
+		},
+	})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ardani17/taskmanager/internal/pagination"
+	"github.com/ardani17/taskmanager/pkg/utils"
+)
+
+// parsePage reads the pagination parameters of a list request. It writes
+// the response and returns false if the cursor is invalid.
+func parsePage(w http.ResponseWriter, r *http.Request) (pagination.Params, bool) {
+	page, err := pagination.Parse(r.URL.Query(), pagination.DefaultLimit)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return page, false
+	}
+	return page, true
+}
+
+// writePage responds with a page of a list. The cursors of the neighbouring
+// pages are included in the body and, as RFC 8288 links, in the Link header.
+func writePage(w http.ResponseWriter, r *http.Request, data interface{}, page *pagination.Page) {
+	var links []string
+	if page.Next != nil {
+		links = append(links, `<`+pageURL(r, page.Next)+`>; rel="next"`)
+	}
+	if page.Prev != nil {
+		links = append(links, `<`+pageURL(r, page.Prev)+`>; rel="prev"`)
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	response := map[string]interface{}{
+		"success":     true,
+		"data":        data,
+		"next_cursor": encodeCursor(page.Next),
+		"prev_cursor": encodeCursor(page.Prev),
+	}
+	if page.Total != nil {
+		response["total"] = *page.Total
+	}
+	utils.JSON(w, http.StatusOK, response)
+}
+
+// pageURL returns the URL of the request moved to the page at cursor
+func pageURL(r *http.Request, cursor *pagination.Cursor) string {
+	query := r.URL.Query()
+	query.Set("cursor", cursor.Encode())
+	query.Del("offset")
+
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+// encodeCursor returns the opaque form of a cursor, or nil at the end of a list
+func encodeCursor(cursor *pagination.Cursor) interface{} {
+	if cursor == nil {
+		return nil
+	}
+	return cursor.Encode()
+}
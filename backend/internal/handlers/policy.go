@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ardani17/taskmanager/internal/policy"
+	"github.com/ardani17/taskmanager/pkg/utils"
+)
+
+// authorize writes the response for a failed policy check and reports
+// whether the request may continue
+func authorize(w http.ResponseWriter, err error) bool {
+	if err == nil {
+		return true
+	}
+	if denied, ok := policy.IsDenied(err); ok {
+		utils.ForbiddenResponse(w, denied.Reason, denied.Message)
+		return false
+	}
+	utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to check permissions")
+	return false
+}
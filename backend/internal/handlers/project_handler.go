@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/ardani17/taskmanager/internal/middleware"
 	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/policy"
 	"github.com/ardani17/taskmanager/internal/repository"
 	"github.com/ardani17/taskmanager/pkg/utils"
 	"github.com/go-chi/chi/v5"
@@ -14,48 +16,57 @@ import (
 
 // ProjectHandler handles project endpoints
 type ProjectHandler struct {
-	repo         *repository.ProjectRepository
-	activityRepo *repository.ActivityRepository
+	repo       repository.ProjectStore
+	memberRepo *repository.ProjectMemberRepository
+	auditRepo  *repository.AuditRepository
+	uow        *repository.UnitOfWork
+	policy     *policy.Policy
 }
 
 // NewProjectHandler creates a new project handler
-func NewProjectHandler(repo *repository.ProjectRepository, activityRepo *repository.ActivityRepository) *ProjectHandler {
+func NewProjectHandler(
+	repo repository.ProjectStore,
+	memberRepo *repository.ProjectMemberRepository,
+	auditRepo *repository.AuditRepository,
+	uow *repository.UnitOfWork,
+	policy *policy.Policy,
+) *ProjectHandler {
 	return &ProjectHandler{
-		repo:         repo,
-		activityRepo: activityRepo,
+		repo:       repo,
+		memberRepo: memberRepo,
+		auditRepo:  auditRepo,
+		uow:        uow,
+		policy:     policy,
 	}
 }
 
 // List handles GET /api/v1/projects
 func (h *ProjectHandler) List(w http.ResponseWriter, r *http.Request) {
 	// Parse pagination params
-	limit := 50
-	offset := 0
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if val, err := strconv.Atoi(l); err == nil && val > 0 {
-			limit = val
-		}
-	}
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if val, err := strconv.Atoi(o); err == nil && val >= 0 {
-			offset = val
-		}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
 
 	// Parse filters
 	status := r.URL.Query().Get("status")
+	teamID := 0
+	if t := r.URL.Query().Get("team_id"); t != "" {
+		if val, err := strconv.Atoi(t); err == nil && val > 0 {
+			teamID = val
+		}
+	}
 
-	projects, total, err := h.repo.List(limit, offset, status)
+	// Non-admins only see projects they belong to
+	memberID := h.policy.VisibilityScope(policy.ActorFromRequest(r))
+
+	projects, result, err := h.repo.List(r.Context(), page, status, memberID, teamID)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch projects")
 		return
 	}
 
-	utils.JSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    projects,
-		"total":   total,
-	})
+	writePage(w, r, projects, result)
 }
 
 // Get handles GET /api/v1/projects/{id}
@@ -67,7 +78,11 @@ func (h *ProjectHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	project, err := h.repo.GetByID(id)
+	if !authorize(w, h.policy.CanViewProject(r.Context(), policy.ActorFromRequest(r), id)) {
+		return
+	}
+
+	project, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch project")
 		return
@@ -106,26 +121,36 @@ func (h *ProjectHandler) Create(w http.ResponseWriter, r *http.Request) {
 		TeamID:      req.TeamID,
 	}
 
-	// Save to database
-	if err := h.repo.Create(project); err != nil {
+	// Save the project, its owner and its activity together
+	userID := middleware.GetUserID(r)
+	err := h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Projects().Create(r.Context(), project); err != nil {
+			return err
+		}
+
+		// The creator owns the project
+		if err := tx.ProjectMembers().Add(r.Context(), project.ID, userID, models.ProjectRoleOwner); err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			ProjectID:   &project.ID,
+			Action:      models.ActionProjectCreated,
+			Description: "Project created: " + project.Name,
+			Metadata: models.JSONB{
+				"name":   project.Name,
+				"status": project.Status,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, models.NewAuditEntry(models.AuditEntityProject, project.ID, nil, project))
+	})
+	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create project")
 		return
 	}
 
-	// Log activity
-	userID := middleware.GetUserID(r)
-	activity := &models.Activity{
-		DeveloperID: &userID,
-		Action:      models.ActionProjectCreated,
-		Description: "Project created: " + project.Name,
-		Metadata: models.JSONB{
-			"name":   project.Name,
-			"status": project.Status,
-		},
-		CreatedAt: now(),
-	}
-	h.activityRepo.Create(activity)
-
 	utils.JSON(w, http.StatusCreated, map[string]interface{}{
 		"success": true,
 		"message": "Project created successfully",
@@ -142,35 +167,56 @@ func (h *ProjectHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorize(w, h.policy.CanManageProject(r.Context(), policy.ActorFromRequest(r), id)) {
+		return
+	}
+
 	var req models.UpdateProjectRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	project, err := h.repo.Update(id, &req)
+	existing, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update project")
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch project")
 		return
 	}
-
-	if project == nil {
+	if existing == nil {
 		utils.ErrorResponse(w, http.StatusNotFound, "Project not found")
 		return
 	}
 
-	// Log activity
+	var project *models.Project
 	userID := middleware.GetUserID(r)
-	activity := &models.Activity{
-		DeveloperID: &userID,
-		Action:      models.ActionProjectUpdated,
-		Description: "Project updated: " + project.Name,
-		Metadata: models.JSONB{
-			"name": project.Name,
-		},
-		CreatedAt: now(),
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		var err error
+		project, err = tx.Projects().Update(r.Context(), id, &req)
+		if err != nil || project == nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			ProjectID:   &project.ID,
+			Action:      models.ActionProjectUpdated,
+			Description: "Project updated: " + project.Name,
+			Metadata: models.JSONB{
+				"name": project.Name,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, models.NewAuditEntry(models.AuditEntityProject, project.ID, existing, project))
+	})
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update project")
+		return
+	}
+
+	if project == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Project not found")
+		return
 	}
-	h.activityRepo.Create(activity)
 
 	utils.JSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -188,19 +234,30 @@ func (h *ProjectHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get project before deleting (for activity log)
-	project, _ := h.repo.GetByID(id)
+	if !authorize(w, h.policy.CanManageProject(r.Context(), policy.ActorFromRequest(r), id)) {
+		return
+	}
 
-	if err := h.repo.Delete(id); err != nil {
-		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+	// Get project before deleting (for activity and audit log)
+	project, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+	if project == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Project not found")
 		return
 	}
 
-	// Log activity
-	if project != nil {
-		userID := middleware.GetUserID(r)
+	userID := middleware.GetUserID(r)
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Projects().Delete(r.Context(), id); err != nil {
+			return err
+		}
+
 		activity := &models.Activity{
 			DeveloperID: &userID,
+			ProjectID:   &project.ID,
 			Action:      models.ActionProjectDeleted,
 			Description: "Project deleted: " + project.Name,
 			Metadata: models.JSONB{
@@ -208,7 +265,15 @@ func (h *ProjectHandler) Delete(w http.ResponseWriter, r *http.Request) {
 			},
 			CreatedAt: now(),
 		}
-		h.activityRepo.Create(activity)
+		return logMutation(tx, r, activity, models.NewAuditEntry(models.AuditEntityProject, project.ID, project, nil))
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to delete project")
+		return
 	}
 
 	utils.JSON(w, http.StatusOK, map[string]interface{}{
@@ -216,3 +281,19 @@ func (h *ProjectHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		"message": "Project deleted successfully",
 	})
 }
+
+// History handles GET /api/v1/projects/{id}/history
+func (h *ProjectHandler) History(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if !authorize(w, h.policy.CanViewProject(r.Context(), policy.ActorFromRequest(r), id)) {
+		return
+	}
+
+	writeHistory(w, r, h.auditRepo, models.AuditEntityProject, id)
+}
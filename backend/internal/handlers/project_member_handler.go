@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ardani17/taskmanager/internal/middleware"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/policy"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// ProjectMemberHandler handles project membership endpoints
+type ProjectMemberHandler struct {
+	repo        *repository.ProjectMemberRepository
+	projectRepo repository.ProjectStore
+	userRepo    repository.DeveloperStore
+	uow         *repository.UnitOfWork
+	policy      *policy.Policy
+}
+
+// NewProjectMemberHandler creates a new project member handler
+func NewProjectMemberHandler(
+	repo *repository.ProjectMemberRepository,
+	projectRepo repository.ProjectStore,
+	userRepo repository.DeveloperStore,
+	uow *repository.UnitOfWork,
+	policy *policy.Policy,
+) *ProjectMemberHandler {
+	return &ProjectMemberHandler{
+		repo:        repo,
+		projectRepo: projectRepo,
+		userRepo:    userRepo,
+		uow:         uow,
+		policy:      policy,
+	}
+}
+
+// List handles GET /api/v1/projects/{id}/members
+func (h *ProjectMemberHandler) List(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if !authorize(w, h.policy.CanViewProject(r.Context(), policy.ActorFromRequest(r), projectID)) {
+		return
+	}
+
+	members, err := h.repo.List(r.Context(), projectID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch project members")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    members,
+		"total":   len(members),
+	})
+}
+
+// Add handles POST /api/v1/projects/{id}/members
+// Adds a developer to the project, or changes the role of an existing member
+func (h *ProjectMemberHandler) Add(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if !authorize(w, h.policy.CanManageProject(r.Context(), policy.ActorFromRequest(r), projectID)) {
+		return
+	}
+
+	var req models.AddProjectMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate request
+	if errors := req.Validate(); len(errors) > 0 {
+		utils.ValidationErrorResponse(w, errors)
+		return
+	}
+
+	project, err := h.projectRepo.GetByID(r.Context(), projectID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+	if project == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	developer, err := h.userRepo.GetByID(r.Context(), req.DeveloperID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if developer == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	userID := middleware.GetUserID(r)
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.ProjectMembers().Add(r.Context(), projectID, req.DeveloperID, req.Role); err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			ProjectID:   &projectID,
+			Action:      models.ActionProjectMemberAdded,
+			Description: developer.Name + " added to project " + project.Name + " as " + req.Role,
+			Metadata: models.JSONB{
+				"project_id":   projectID,
+				"developer_id": req.DeveloperID,
+				"role":         req.Role,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, nil)
+	})
+	// Demoting an owner must not leave the project without one
+	if errors.Is(err, repository.ErrLastOwner) {
+		utils.ErrorResponse(w, http.StatusConflict, "A project must keep at least one owner")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to add project member")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Project member saved successfully",
+		"data": &models.ProjectMember{
+			ProjectID:   projectID,
+			DeveloperID: req.DeveloperID,
+			Role:        req.Role,
+			Developer:   developer,
+		},
+	})
+}
+
+// Remove handles DELETE /api/v1/projects/{id}/members/{developerID}
+func (h *ProjectMemberHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+	developerID, err := strconv.Atoi(chi.URLParam(r, "developerID"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid developer ID")
+		return
+	}
+
+	if !authorize(w, h.policy.CanRemoveProjectMember(r.Context(), policy.ActorFromRequest(r), projectID, developerID)) {
+		return
+	}
+
+	userID := middleware.GetUserID(r)
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.ProjectMembers().Remove(r.Context(), projectID, developerID); err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			ProjectID:   &projectID,
+			Action:      models.ActionProjectMemberRemoved,
+			Description: "Project member removed",
+			Metadata: models.JSONB{
+				"project_id":   projectID,
+				"developer_id": developerID,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, nil)
+	})
+	if errors.Is(err, repository.ErrLastOwner) {
+		utils.ErrorResponse(w, http.StatusConflict, "A project must keep at least one owner")
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to remove project member")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Project member removed successfully",
+	})
+}
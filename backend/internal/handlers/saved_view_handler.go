@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/policy"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/internal/taskquery"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// SavedViewHandler handles saved view endpoints
+type SavedViewHandler struct {
+	repo        *repository.SavedViewRepository
+	taskRepo    repository.TaskStore
+	projectRepo repository.ProjectStore
+	policy      *policy.Policy
+}
+
+// NewSavedViewHandler creates a new saved view handler
+func NewSavedViewHandler(repo *repository.SavedViewRepository, taskRepo repository.TaskStore, projectRepo repository.ProjectStore, policy *policy.Policy) *SavedViewHandler {
+	return &SavedViewHandler{
+		repo:        repo,
+		taskRepo:    taskRepo,
+		projectRepo: projectRepo,
+		policy:      policy,
+	}
+}
+
+// List handles GET /api/v1/views
+// Lists the caller's personal views and the views shared with their projects
+func (h *SavedViewHandler) List(w http.ResponseWriter, r *http.Request) {
+	// Parse pagination params
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	projectID := 0
+	if projectIDStr := r.URL.Query().Get("project_id"); projectIDStr != "" {
+		id, err := strconv.Atoi(projectIDStr)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+			return
+		}
+		projectID = id
+	}
+
+	actor := policy.ActorFromRequest(r)
+	views, result, err := h.repo.List(r.Context(), page, actor.ID, h.policy.VisibilityScope(actor), projectID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch views")
+		return
+	}
+
+	writePage(w, r, views, result)
+}
+
+// Get handles GET /api/v1/views/{id}
+func (h *SavedViewHandler) Get(w http.ResponseWriter, r *http.Request) {
+	view, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorize(w, h.policy.CanViewSavedView(r.Context(), policy.ActorFromRequest(r), view)) {
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    view,
+	})
+}
+
+// Create handles POST /api/v1/views
+// Views with a project_id are shared with the project's members
+func (h *SavedViewHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.SavedViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	actor := policy.ActorFromRequest(r)
+	if !h.validate(w, r, actor, &req) {
+		return
+	}
+
+	view := &models.SavedView{
+		DeveloperID: actor.ID,
+		ProjectID:   req.ProjectID,
+		Name:        req.Name,
+		Query:       req.Query,
+		Columns:     req.Columns,
+	}
+	if err := h.repo.Create(r.Context(), view); err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create view")
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "View created successfully",
+		"data":    view,
+	})
+}
+
+// Update handles PUT /api/v1/views/{id}
+// Replaces the name, query, columns and project of a view
+func (h *SavedViewHandler) Update(w http.ResponseWriter, r *http.Request) {
+	view, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	actor := policy.ActorFromRequest(r)
+	if !authorize(w, h.policy.CanManageSavedView(r.Context(), actor, view)) {
+		return
+	}
+
+	var req models.SavedViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Sharing with another project needs the right to share there; a project
+	// owner tidying a view that is already shared with them does not
+	valid := false
+	if sameProject(view.ProjectID, req.ProjectID) {
+		valid = h.validateQuery(w, actor, &req)
+	} else {
+		valid = h.validate(w, r, actor, &req)
+	}
+	if !valid {
+		return
+	}
+
+	view.ProjectID = req.ProjectID
+	view.Name = req.Name
+	view.Query = req.Query
+	view.Columns = req.Columns
+
+	err := h.repo.Update(r.Context(), view)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, "View not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update view")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "View updated successfully",
+		"data":    view,
+	})
+}
+
+// Delete handles DELETE /api/v1/views/{id}
+func (h *SavedViewHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	view, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorize(w, h.policy.CanManageSavedView(r.Context(), policy.ActorFromRequest(r), view)) {
+		return
+	}
+
+	err := h.repo.Delete(r.Context(), view.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, "View not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to delete view")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "View deleted successfully",
+	})
+}
+
+// Tasks handles GET /api/v1/views/{id}/tasks
+// Runs the view's query as GET /tasks would. The q parameter narrows the
+// view down and the sort parameter overrides its order; the results are
+// limited to the tasks the caller can see, whoever saved the view.
+func (h *SavedViewHandler) Tasks(w http.ResponseWriter, r *http.Request) {
+	view, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorize(w, h.policy.CanViewSavedView(r.Context(), policy.ActorFromRequest(r), view)) {
+		return
+	}
+
+	writeTaskList(w, r, h.taskRepo, h.policy, viewQuery(view, r.URL.Query()))
+}
+
+// viewQuery returns the task list parameters running view with the
+// parameters of a request. The other filter parameters of the request are
+// kept and add to the view's filters.
+func viewQuery(view *models.SavedView, query url.Values) url.Values {
+	merged := url.Values{}
+	for key, values := range query {
+		merged[key] = values
+	}
+
+	// The q parameter is read after the others, so the sort is appended to
+	// it to win over a sort: term of the view
+	q := view.Query + " " + query.Get("q")
+	if sort := query.Get("sort"); sort != "" {
+		q += " sort:" + sort
+		merged.Del("sort")
+	}
+	merged.Set("q", strings.TrimSpace(q))
+	return merged
+}
+
+// load fetches the view named by the id URL parameter, writing the error
+// response if there is none
+func (h *SavedViewHandler) load(w http.ResponseWriter, r *http.Request) (*models.SavedView, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid view ID")
+		return nil, false
+	}
+
+	view, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch view")
+		return nil, false
+	}
+	if view == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "View not found")
+		return nil, false
+	}
+
+	return view, true
+}
+
+// validate checks a view request, its query and that the actor may share it
+// with its project, writing the error response if not
+func (h *SavedViewHandler) validate(w http.ResponseWriter, r *http.Request, actor policy.Actor, req *models.SavedViewRequest) bool {
+	if !h.validateQuery(w, actor, req) {
+		return false
+	}
+	if req.ProjectID == nil {
+		return true
+	}
+
+	project, err := h.projectRepo.GetByID(r.Context(), *req.ProjectID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch project")
+		return false
+	}
+	if project == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Project not found")
+		return false
+	}
+
+	return authorize(w, h.policy.CanShareSavedView(r.Context(), actor, *req.ProjectID))
+}
+
+// validateQuery checks the fields of a view request and parses its query, so
+// that views cannot be saved with a query that fails every time they run
+func (h *SavedViewHandler) validateQuery(w http.ResponseWriter, actor policy.Actor, req *models.SavedViewRequest) bool {
+	if errors := req.Validate(); len(errors) > 0 {
+		utils.ValidationErrorResponse(w, errors)
+		return false
+	}
+
+	if _, err := taskquery.Parse(url.Values{"q": {req.Query}}, actor.ID, time.Now()); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid filter: "+err.Error())
+		return false
+	}
+	return true
+}
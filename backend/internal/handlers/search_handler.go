@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+	"github.com/ardani17/taskmanager/internal/policy"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/pkg/utils"
+)
+
+// Search limits
+const (
+	defaultSearchLimit = 20
+	maxSearchLength    = 200
+)
+
+// SearchHandler handles the search endpoint
+type SearchHandler struct {
+	searcher repository.Searcher
+	policy   *policy.Policy
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(searcher repository.Searcher, policy *policy.Policy) *SearchHandler {
+	return &SearchHandler{
+		searcher: searcher,
+		policy:   policy,
+	}
+}
+
+// Search handles GET /api/v1/search
+// Searches the tasks, projects and activities the caller can see
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if text == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Search query is required")
+		return
+	}
+	if len(text) > maxSearchLength {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Search query is too long")
+		return
+	}
+
+	var types []string
+	if t := r.URL.Query().Get("type"); t != "" {
+		for _, resultType := range strings.Split(t, ",") {
+			if !slices.Contains(models.SearchTypes, resultType) {
+				utils.ErrorResponse(w, http.StatusBadRequest, "Invalid type. Must be one of: task, project, activity")
+				return
+			}
+			types = append(types, resultType)
+		}
+	}
+
+	limit := defaultSearchLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if val, err := strconv.Atoi(l); err == nil && val > 0 {
+			limit = min(val, pagination.MaxLimit)
+		}
+	}
+
+	results, err := h.searcher.Search(r.Context(), repository.SearchQuery{
+		Text:      text,
+		Types:     types,
+		Limit:     limit,
+		VisibleTo: h.policy.VisibilityScope(policy.ActorFromRequest(r)),
+	})
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to search")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    results,
+	})
+}
@@ -2,61 +2,78 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/ardani17/taskmanager/internal/middleware"
 	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+	"github.com/ardani17/taskmanager/internal/policy"
 	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/internal/taskquery"
 	"github.com/ardani17/taskmanager/pkg/utils"
 	"github.com/go-chi/chi/v5"
 )
 
 // TaskHandler handles task endpoints
 type TaskHandler struct {
-	repo         *repository.TaskRepository
-	activityRepo *repository.ActivityRepository
+	repo      repository.TaskStore
+	linkRepo  *repository.TaskLinkRepository
+	auditRepo *repository.AuditRepository
+	uow       *repository.UnitOfWork
+	policy    *policy.Policy
 }
 
 // NewTaskHandler creates a new task handler
-func NewTaskHandler(repo *repository.TaskRepository, activityRepo *repository.ActivityRepository) *TaskHandler {
+func NewTaskHandler(repo repository.TaskStore, linkRepo *repository.TaskLinkRepository, auditRepo *repository.AuditRepository, uow *repository.UnitOfWork, policy *policy.Policy) *TaskHandler {
 	return &TaskHandler{
-		repo:         repo,
-		activityRepo: activityRepo,
+		repo:      repo,
+		linkRepo:  linkRepo,
+		auditRepo: auditRepo,
+		uow:       uow,
+		policy:    policy,
 	}
 }
 
 // List handles GET /api/v1/tasks
 func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
+	writeTaskList(w, r, h.repo, h.policy, r.URL.Query())
+}
+
+// writeTaskList responds with a page of the tasks selected by the filter
+// parameters of query that the actor can see
+func writeTaskList(w http.ResponseWriter, r *http.Request, repo repository.TaskStore, pol *policy.Policy, query url.Values) {
 	// Parse pagination params
-	limit := 50
-	offset := 0
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if val, err := strconv.Atoi(l); err == nil && val > 0 {
-			limit = val
-		}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if val, err := strconv.Atoi(o); err == nil && val >= 0 {
-			offset = val
-		}
+
+	// Parse filters and sort order
+	actor := policy.ActorFromRequest(r)
+	filter, err := taskquery.Parse(query, actor.ID, time.Now())
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid filter: "+err.Error())
+		return
 	}
 
-	// Parse filters
-	status := r.URL.Query().Get("status")
-	priority := r.URL.Query().Get("priority")
+	// Non-admins only see tasks from projects they belong to
+	visibleTo := pol.VisibilityScope(actor)
 
-	tasks, total, err := h.repo.List(limit, offset, status, priority)
+	tasks, result, err := repo.List(r.Context(), page, filter, visibleTo)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch tasks")
 		return
 	}
 
-	utils.JSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    tasks,
-		"total":   total,
-	})
+	writePage(w, r, tasks, result)
 }
 
 // Get handles GET /api/v1/tasks/{id}
@@ -68,7 +85,7 @@ func (h *TaskHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.repo.GetByID(id)
+	task, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch task")
 		return
@@ -79,6 +96,20 @@ func (h *TaskHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorize(w, h.policy.CanViewTask(r.Context(), policy.ActorFromRequest(r), task)) {
+		return
+	}
+
+	// Tasks with subtasks carry the sums of their subtree
+	rollup, err := h.repo.Rollup(r.Context(), task.ID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch task")
+		return
+	}
+	if rollup.Subtasks > 0 {
+		task.Rollup = rollup
+	}
+
 	utils.JSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"data":    task,
@@ -99,40 +130,81 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	actor := policy.ActorFromRequest(r)
+
+	// Subtasks are created in their parent's project
+	if req.ParentID != nil {
+		parent, err := h.repo.GetByID(r.Context(), *req.ParentID)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch parent task")
+			return
+		}
+		if parent == nil {
+			utils.ErrorResponse(w, http.StatusNotFound, "Parent task not found")
+			return
+		}
+		if !authorize(w, h.policy.CanEditTask(r.Context(), actor, parent)) {
+			return
+		}
+
+		if req.ProjectID == nil {
+			req.ProjectID = parent.ProjectID
+		} else if !sameProject(req.ProjectID, parent.ProjectID) {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Subtasks must be in their parent's project")
+			return
+		}
+	}
+
+	if !authorize(w, h.policy.CanCreateTask(r.Context(), actor, req.ProjectID)) {
+		return
+	}
+
 	// Create task model
 	task := &models.Task{
 		Title:          req.Title,
 		Description:    req.Description,
 		AssigneeID:     req.AssigneeID,
 		ProjectID:      req.ProjectID,
+		ParentID:       req.ParentID,
 		Status:         req.Status,
 		Priority:       req.Priority,
 		DueDate:        req.DueDate,
 		EstimatedHours: req.EstimatedHours,
+		AutoComplete:   req.AutoComplete,
+		CreatedBy:      &actor.ID,
 	}
 
-	// Save to database
-	if err := h.repo.Create(task); err != nil {
+	// Save the task and its activity together
+	userID := actor.ID
+	err := h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Tasks().Create(r.Context(), task); err != nil {
+			return err
+		}
+		if task.Status == "done" {
+			if err := completeParents(tx, r, task.ParentID); err != nil {
+				return err
+			}
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			TaskID:      &task.ID,
+			Action:      models.ActionTaskCreated,
+			Description: "Task created: " + task.Title,
+			Metadata: models.JSONB{
+				"title":    task.Title,
+				"status":   task.Status,
+				"priority": task.Priority,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, models.NewAuditEntry(models.AuditEntityTask, task.ID, nil, task))
+	})
+	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create task")
 		return
 	}
 
-	// Log activity
-	userID := middleware.GetUserID(r)
-	activity := &models.Activity{
-		DeveloperID: &userID,
-		TaskID:      &task.ID,
-		Action:      models.ActionTaskCreated,
-		Description: "Task created: " + task.Title,
-		Metadata: models.JSONB{
-			"title":    task.Title,
-			"status":   task.Status,
-			"priority": task.Priority,
-		},
-		CreatedAt: now(),
-	}
-	h.activityRepo.Create(activity)
-
 	utils.JSON(w, http.StatusCreated, map[string]interface{}{
 		"success": true,
 		"message": "Task created successfully",
@@ -155,7 +227,72 @@ func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.repo.Update(id, &req)
+	existing, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch task")
+		return
+	}
+	if existing == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	actor := policy.ActorFromRequest(r)
+	if !authorize(w, h.policy.CanEditTask(r.Context(), actor, existing)) {
+		return
+	}
+
+	// Moving a task to another project needs access to the target project
+	if req.ProjectID != nil && (existing.ProjectID == nil || *req.ProjectID != *existing.ProjectID) {
+		if !authorize(w, h.policy.CanCreateTask(r.Context(), actor, req.ProjectID)) {
+			return
+		}
+
+		// Subtasks stay in the project of their parent
+		rollup, err := h.repo.Rollup(r.Context(), id)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch task")
+			return
+		}
+		if existing.ParentID != nil || rollup.Subtasks > 0 {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Tasks with a parent or subtasks change project with POST /tasks/{id}/move")
+			return
+		}
+	}
+
+	blockers, ok := h.checkBlockers(w, r, existing, req.Status, req.Force)
+	if !ok {
+		return
+	}
+
+	var task *models.Task
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		var err error
+		task, err = tx.Tasks().Update(r.Context(), id, &req)
+		if err != nil || task == nil {
+			return err
+		}
+		if task.Status == "done" && existing.Status != "done" {
+			if err := completeParents(tx, r, task.ParentID); err != nil {
+				return err
+			}
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &actor.ID,
+			TaskID:      &task.ID,
+			Action:      models.ActionTaskUpdated,
+			Description: "Task updated: " + task.Title,
+			Metadata: models.JSONB{
+				"title": task.Title,
+			},
+			CreatedAt: now(),
+		}
+		if len(blockers) > 0 {
+			activity.Metadata["open_blockers"] = blockers
+		}
+		return logMutation(tx, r, activity, models.NewAuditEntry(models.AuditEntityTask, task.ID, existing, task))
+	})
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update task")
 		return
@@ -166,20 +303,6 @@ func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Log activity
-	userID := middleware.GetUserID(r)
-	activity := &models.Activity{
-		DeveloperID: &userID,
-		TaskID:      &task.ID,
-		Action:      models.ActionTaskUpdated,
-		Description: "Task updated: " + task.Title,
-		Metadata: models.JSONB{
-			"title": task.Title,
-		},
-		CreatedAt: now(),
-	}
-	h.activityRepo.Create(activity)
-
 	utils.JSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": "Task updated successfully",
@@ -196,17 +319,28 @@ func (h *TaskHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get task before deleting (for activity log)
-	task, _ := h.repo.GetByID(id)
+	// Get task before deleting (for permission check and activity log)
+	task, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch task")
+		return
+	}
+	if task == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Task not found")
+		return
+	}
 
-	if err := h.repo.Delete(id); err != nil {
-		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+	if !authorize(w, h.policy.CanDeleteTask(r.Context(), policy.ActorFromRequest(r), task)) {
 		return
 	}
 
-	// Log activity
-	if task != nil {
-		userID := middleware.GetUserID(r)
+	// The task is only gone if its activity was logged too
+	userID := middleware.GetUserID(r)
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Tasks().Delete(r.Context(), id); err != nil {
+			return err
+		}
+
 		activity := &models.Activity{
 			DeveloperID: &userID,
 			TaskID:      &task.ID,
@@ -217,7 +351,15 @@ func (h *TaskHandler) Delete(w http.ResponseWriter, r *http.Request) {
 			},
 			CreatedAt: now(),
 		}
-		h.activityRepo.Create(activity)
+		return logMutation(tx, r, activity, models.NewAuditEntry(models.AuditEntityTask, task.ID, task, nil))
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to delete task")
+		return
 	}
 
 	utils.JSON(w, http.StatusOK, map[string]interface{}{
@@ -237,6 +379,8 @@ func (h *TaskHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		Status string `json:"status"`
+		// Force starts or completes the task while open tasks block it
+		Force bool `json:"force"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
@@ -255,31 +399,312 @@ func (h *TaskHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.UpdateStatus(id, req.Status); err != nil {
-		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update status")
+	task, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch task")
+		return
+	}
+	if task == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	if !authorize(w, h.policy.CanEditTask(r.Context(), policy.ActorFromRequest(r), task)) {
+		return
+	}
+
+	blockers, ok := h.checkBlockers(w, r, task, req.Status, req.Force)
+	if !ok {
 		return
 	}
 
-	// Log activity
 	userID := middleware.GetUserID(r)
-	action := models.ActionTaskUpdated
-	if req.Status == "done" {
-		action = models.ActionTaskCompleted
-	}
-	activity := &models.Activity{
-		DeveloperID: &userID,
-		TaskID:      &id,
-		Action:      action,
-		Description: "Task status changed to: " + req.Status,
-		Metadata: models.JSONB{
-			"new_status": req.Status,
-		},
-		CreatedAt: now(),
-	}
-	h.activityRepo.Create(activity)
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Tasks().UpdateStatus(r.Context(), id, req.Status); err != nil {
+			return err
+		}
+		if req.Status == "done" && task.Status != "done" {
+			if err := completeParents(tx, r, task.ParentID); err != nil {
+				return err
+			}
+		}
+
+		action := models.ActionTaskUpdated
+		if req.Status == "done" {
+			action = models.ActionTaskCompleted
+		}
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			TaskID:      &id,
+			Action:      action,
+			Description: "Task status changed to: " + req.Status,
+			Metadata: models.JSONB{
+				"new_status": req.Status,
+			},
+			CreatedAt: now(),
+		}
+		if len(blockers) > 0 {
+			activity.Metadata["open_blockers"] = blockers
+		}
+		updated := *task
+		updated.Status = req.Status
+		return logMutation(tx, r, activity, models.NewAuditEntry(models.AuditEntityTask, task.ID, task, &updated))
+	})
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update status")
+		return
+	}
 
 	utils.JSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": "Status updated successfully",
 	})
 }
+
+// History handles GET /api/v1/tasks/{id}/history
+// Admins can still read the history of a deleted task.
+func (h *TaskHandler) History(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	task, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch task")
+		return
+	}
+
+	actor := policy.ActorFromRequest(r)
+	if task == nil && !actor.IsAdmin() {
+		utils.ErrorResponse(w, http.StatusNotFound, "Task not found")
+		return
+	}
+	if task != nil && !authorize(w, h.policy.CanViewTask(r.Context(), actor, task)) {
+		return
+	}
+
+	writeHistory(w, r, h.auditRepo, models.AuditEntityTask, id)
+}
+
+// Children handles GET /api/v1/tasks/{id}/children
+// Lists the direct subtasks of a task; the filters of GET /tasks apply
+func (h *TaskHandler) Children(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	task, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch task")
+		return
+	}
+	if task == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	if !authorize(w, h.policy.CanViewTask(r.Context(), policy.ActorFromRequest(r), task)) {
+		return
+	}
+
+	query := r.URL.Query()
+	query.Del("parent_id")
+	query.Set("parent", idStr)
+	writeTaskList(w, r, h.repo, h.policy, query)
+}
+
+// Move handles POST /api/v1/tasks/{id}/move
+// Moves a task and its subtasks under another parent or to the top level
+func (h *TaskHandler) Move(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	var req models.MoveTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	task, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch task")
+		return
+	}
+	if task == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	actor := policy.ActorFromRequest(r)
+	if !authorize(w, h.policy.CanEditTask(r.Context(), actor, task)) {
+		return
+	}
+
+	// The subtree ends up in the parent's project, or in the requested one
+	projectID := task.ProjectID
+	if req.ParentID != nil {
+		parent, err := h.repo.GetByID(r.Context(), *req.ParentID)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch parent task")
+			return
+		}
+		if parent == nil {
+			utils.ErrorResponse(w, http.StatusNotFound, "Parent task not found")
+			return
+		}
+		if !authorize(w, h.policy.CanEditTask(r.Context(), actor, parent)) {
+			return
+		}
+		if req.ProjectID != nil && !sameProject(req.ProjectID, parent.ProjectID) {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Subtasks must be in their parent's project")
+			return
+		}
+		projectID = parent.ProjectID
+	} else if req.ProjectID != nil {
+		projectID = req.ProjectID
+	}
+
+	if projectID != nil && !sameProject(projectID, task.ProjectID) {
+		if !authorize(w, h.policy.CanCreateTask(r.Context(), actor, projectID)) {
+			return
+		}
+	}
+
+	var moved *models.Task
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Tasks().Move(r.Context(), id, req.ParentID, projectID); err != nil {
+			return err
+		}
+
+		var err error
+		moved, err = tx.Tasks().GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &actor.ID,
+			TaskID:      &id,
+			Action:      models.ActionTaskMoved,
+			Description: "Task moved: " + task.Title,
+			Metadata: models.JSONB{
+				"parent_id":  moved.ParentID,
+				"project_id": moved.ProjectID,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, models.NewAuditEntry(models.AuditEntityTask, id, task, moved))
+	})
+	switch {
+	case errors.Is(err, repository.ErrTaskCycle):
+		utils.ErrorResponse(w, http.StatusBadRequest, "A task cannot be moved under itself or its subtasks")
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, "Task not found")
+		return
+	case err != nil:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to move task")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Task moved successfully",
+		"data":    moved,
+	})
+}
+
+// checkBlockers refuses to start or complete a task while open tasks block
+// it, unless force is set, writing the error response. It returns the open
+// blockers that were overridden.
+func (h *TaskHandler) checkBlockers(w http.ResponseWriter, r *http.Request, task *models.Task, status string, force bool) ([]int, bool) {
+	if status == task.Status || (status != "in_progress" && status != "done") {
+		return nil, true
+	}
+
+	blockers, err := h.linkRepo.OpenBlockers(r.Context(), task.ID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch blockers")
+		return nil, false
+	}
+	if len(blockers) > 0 && !force {
+		utils.BlockedResponse(w, blockers, "Task is blocked by open tasks")
+		return nil, false
+	}
+
+	return blockers, true
+}
+
+// completeParents marks done the ancestors of a task, starting at parentID,
+// that have auto_complete set and no other open subtasks, and logs them.
+// A parent with open blockers stays open, like a manual change would.
+// It runs when a task becomes done, in the same transaction.
+func completeParents(tx *repository.Tx, r *http.Request, parentID *int) error {
+	for parentID != nil {
+		parent, err := tx.Tasks().GetByID(r.Context(), *parentID)
+		if err != nil || parent == nil {
+			return err
+		}
+		if !parent.AutoComplete || parent.Status == "done" {
+			return nil
+		}
+
+		open, err := tx.Tasks().CountOpenSubtasks(r.Context(), parent.ID)
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return nil
+		}
+		blockers, err := tx.TaskLinks().OpenBlockers(r.Context(), parent.ID)
+		if err != nil {
+			return err
+		}
+		if len(blockers) > 0 {
+			return nil
+		}
+
+		if err := tx.Tasks().UpdateStatus(r.Context(), parent.ID, "done"); err != nil {
+			return err
+		}
+
+		userID := middleware.GetUserID(r)
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			TaskID:      &parent.ID,
+			Action:      models.ActionTaskCompleted,
+			Description: "Task completed with its subtasks: " + parent.Title,
+			Metadata: models.JSONB{
+				"new_status":    "done",
+				"auto_complete": true,
+			},
+			CreatedAt: now(),
+		}
+		updated := *parent
+		updated.Status = "done"
+		if err := logMutation(tx, r, activity, models.NewAuditEntry(models.AuditEntityTask, parent.ID, parent, &updated)); err != nil {
+			return err
+		}
+
+		parentID = parent.ParentID
+	}
+	return nil
+}
+
+// sameProject reports whether two optional project IDs are the same
+func sameProject(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/policy"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/internal/taskgraph"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// TaskLinkHandler handles the links between tasks and the dependency graph
+type TaskLinkHandler struct {
+	repo        *repository.TaskLinkRepository
+	taskRepo    repository.TaskStore
+	projectRepo repository.ProjectStore
+	uow         *repository.UnitOfWork
+	policy      *policy.Policy
+}
+
+// NewTaskLinkHandler creates a new task link handler
+func NewTaskLinkHandler(
+	repo *repository.TaskLinkRepository,
+	taskRepo repository.TaskStore,
+	projectRepo repository.ProjectStore,
+	uow *repository.UnitOfWork,
+	policy *policy.Policy,
+) *TaskLinkHandler {
+	return &TaskLinkHandler{
+		repo:        repo,
+		taskRepo:    taskRepo,
+		projectRepo: projectRepo,
+		uow:         uow,
+		policy:      policy,
+	}
+}
+
+// List handles GET /api/v1/tasks/{id}/links
+// Lists the links from and to a task
+func (h *TaskLinkHandler) List(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.loadTask(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorize(w, h.policy.CanViewTask(r.Context(), policy.ActorFromRequest(r), task)) {
+		return
+	}
+
+	links, err := h.repo.ListByTask(r.Context(), task.ID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch links")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    links,
+	})
+}
+
+// Create handles POST /api/v1/tasks/{id}/links
+// Links the task to another one it can see, or can edit if the other task
+// becomes blocked; blocks and duplicates links must not form a cycle
+func (h *TaskLinkHandler) Create(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.loadTask(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateTaskLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errors := req.Validate(); len(errors) > 0 {
+		utils.ValidationErrorResponse(w, errors)
+		return
+	}
+	if req.TaskID == task.ID {
+		utils.ErrorResponse(w, http.StatusBadRequest, "A task cannot be linked to itself")
+		return
+	}
+
+	actor := policy.ActorFromRequest(r)
+	if !authorize(w, h.policy.CanEditTask(r.Context(), actor, task)) {
+		return
+	}
+
+	other, err := h.taskRepo.GetByID(r.Context(), req.TaskID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch linked task")
+		return
+	}
+	if other == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Linked task not found")
+		return
+	}
+
+	// Blocking a task holds it back, so it needs the right to edit it
+	link := req.Link(task.ID)
+	check := h.policy.CanViewTask
+	if link.Type == models.LinkBlocks && link.TargetID == other.ID {
+		check = h.policy.CanEditTask
+	}
+	if !authorize(w, check(r.Context(), actor, other)) {
+		return
+	}
+
+	link.CreatedBy = &actor.ID
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.TaskLinks().Create(r.Context(), link); err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &actor.ID,
+			TaskID:      &task.ID,
+			Action:      models.ActionTaskLinked,
+			Description: "Task linked: " + task.Title + " " + req.Type + " " + other.Title,
+			Metadata: models.JSONB{
+				"link_id":   link.ID,
+				"source_id": link.SourceID,
+				"target_id": link.TargetID,
+				"type":      link.Type,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, nil)
+	})
+	var cycle *repository.LinkCycleError
+	switch {
+	case errors.Is(err, repository.ErrLinkExists):
+		utils.ErrorResponse(w, http.StatusConflict, "Tasks are already linked")
+		return
+	case errors.As(err, &cycle):
+		utils.CycleResponse(w, cycle.TaskIDs, "Link would create a cycle")
+		return
+	case err != nil:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create link")
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "Link created successfully",
+		"data":    link,
+	})
+}
+
+// Delete handles DELETE /api/v1/tasks/{id}/links/{linkID}
+func (h *TaskLinkHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.loadTask(w, r)
+	if !ok {
+		return
+	}
+
+	linkID, err := strconv.Atoi(chi.URLParam(r, "linkID"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid link ID")
+		return
+	}
+
+	actor := policy.ActorFromRequest(r)
+	if !authorize(w, h.policy.CanEditTask(r.Context(), actor, task)) {
+		return
+	}
+
+	link, err := h.repo.GetByID(r.Context(), linkID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch link")
+		return
+	}
+	if link == nil || (link.SourceID != task.ID && link.TargetID != task.ID) {
+		utils.ErrorResponse(w, http.StatusNotFound, "Link not found")
+		return
+	}
+
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.TaskLinks().Delete(r.Context(), link.ID); err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &actor.ID,
+			TaskID:      &task.ID,
+			Action:      models.ActionTaskUnlinked,
+			Description: "Task unlinked: " + task.Title,
+			Metadata: models.JSONB{
+				"link_id":   link.ID,
+				"source_id": link.SourceID,
+				"target_id": link.TargetID,
+				"type":      link.Type,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, nil)
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, "Link not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to delete link")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Link deleted successfully",
+	})
+}
+
+// Graph handles GET /api/v1/projects/{id}/dependencies
+// Returns the tasks of a project, the links between them, and their schedule
+// and critical path from their estimated hours and due dates
+func (h *TaskLinkHandler) Graph(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if !authorize(w, h.policy.CanViewProject(r.Context(), policy.ActorFromRequest(r), id)) {
+		return
+	}
+
+	project, err := h.projectRepo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+	if project == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	tasks, err := h.taskRepo.ListByProject(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch tasks")
+		return
+	}
+	links, err := h.repo.ListByProject(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch links")
+		return
+	}
+
+	graph, err := taskgraph.Analyze(tasks, links, time.Now())
+	var cycle *taskgraph.CycleError
+	switch {
+	case errors.As(err, &cycle):
+		utils.CycleResponse(w, cycle.TaskIDs, "Blocking links form a cycle")
+		return
+	case err != nil:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to compute dependencies")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    graph,
+	})
+}
+
+// loadTask fetches the task named by the id URL parameter, writing the error
+// response if there is none
+func (h *TaskLinkHandler) loadTask(w http.ResponseWriter, r *http.Request) (*models.Task, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid task ID")
+		return nil, false
+	}
+
+	task, err := h.taskRepo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch task")
+		return nil, false
+	}
+	if task == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Task not found")
+		return nil, false
+	}
+
+	return task, true
+}
@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ardani17/taskmanager/internal/middleware"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+	"github.com/ardani17/taskmanager/internal/policy"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// TeamHandler handles team endpoints
+type TeamHandler struct {
+	repo     *repository.TeamRepository
+	userRepo repository.DeveloperStore
+	uow      *repository.UnitOfWork
+	policy   *policy.Policy
+}
+
+// NewTeamHandler creates a new team handler
+func NewTeamHandler(repo *repository.TeamRepository, userRepo repository.DeveloperStore, uow *repository.UnitOfWork, policy *policy.Policy) *TeamHandler {
+	return &TeamHandler{
+		repo:     repo,
+		userRepo: userRepo,
+		uow:      uow,
+		policy:   policy,
+	}
+}
+
+// List handles GET /api/v1/teams
+// Teams are listed by name
+func (h *TeamHandler) List(w http.ResponseWriter, r *http.Request) {
+	// Parse pagination params
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	teams, result, err := h.repo.List(r.Context(), page)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch teams")
+		return
+	}
+
+	writePage(w, r, teams, result)
+}
+
+// Get handles GET /api/v1/teams/{id}
+func (h *TeamHandler) Get(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid team ID")
+		return
+	}
+
+	team, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch team")
+		return
+	}
+
+	if team == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Team not found")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    team,
+	})
+}
+
+// Create handles POST /api/v1/teams
+func (h *TeamHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate request
+	if errors := req.Validate(); len(errors) > 0 {
+		utils.ValidationErrorResponse(w, errors)
+		return
+	}
+
+	// Check if name already exists
+	existing, err := h.repo.GetByName(r.Context(), req.Name)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to check team name")
+		return
+	}
+	if existing != nil {
+		utils.ErrorResponse(w, http.StatusConflict, "Team name already exists")
+		return
+	}
+
+	team := &models.Team{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	// Save the team and its activity together
+	userID := middleware.GetUserID(r)
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Teams().Create(r.Context(), team); err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			Action:      models.ActionTeamCreated,
+			Description: "Team created: " + team.Name,
+			Metadata: models.JSONB{
+				"team_id": team.ID,
+				"name":    team.Name,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create team")
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "Team created successfully",
+		"data":    team,
+	})
+}
+
+// Update handles PUT /api/v1/teams/{id}
+func (h *TeamHandler) Update(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid team ID")
+		return
+	}
+
+	var req models.UpdateTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name != "" {
+		existing, err := h.repo.GetByName(r.Context(), req.Name)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to check team name")
+			return
+		}
+		if existing != nil && existing.ID != id {
+			utils.ErrorResponse(w, http.StatusConflict, "Team name already exists")
+			return
+		}
+	}
+
+	var team *models.Team
+	userID := middleware.GetUserID(r)
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		var err error
+		team, err = tx.Teams().Update(r.Context(), id, &req)
+		if err != nil || team == nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			Action:      models.ActionTeamUpdated,
+			Description: "Team updated: " + team.Name,
+			Metadata: models.JSONB{
+				"team_id": team.ID,
+				"name":    team.Name,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update team")
+		return
+	}
+
+	if team == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Team not found")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Team updated successfully",
+		"data":    team,
+	})
+}
+
+// Delete handles DELETE /api/v1/teams/{id}
+func (h *TeamHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid team ID")
+		return
+	}
+
+	// Get team before deleting (for activity log)
+	team, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch team")
+		return
+	}
+	if team == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Team not found")
+		return
+	}
+
+	userID := middleware.GetUserID(r)
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Teams().Delete(r.Context(), id); err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &userID,
+			Action:      models.ActionTeamDeleted,
+			Description: "Team deleted: " + team.Name,
+			Metadata: models.JSONB{
+				"team_id": team.ID,
+				"name":    team.Name,
+			},
+			CreatedAt: now(),
+		}
+		return logMutation(tx, r, activity, nil)
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to delete team")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Team deleted successfully",
+	})
+}
+
+// ListMembers handles GET /api/v1/teams/{id}/members
+func (h *TeamHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid team ID")
+		return
+	}
+
+	// Parse pagination params
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	members, result, err := h.userRepo.List(r.Context(), page, id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch team members")
+		return
+	}
+
+	writePage(w, r, members, result)
+}
+
+// AddMember handles POST /api/v1/teams/{id}/members
+// A developer belongs to at most one team, so this moves them from any previous team
+func (h *TeamHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid team ID")
+		return
+	}
+
+	var req models.AddTeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.DeveloperID <= 0 {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Developer ID is required")
+		return
+	}
+
+	team, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch team")
+		return
+	}
+	if team == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Team not found")
+		return
+	}
+
+	developer, err := h.userRepo.GetByID(r.Context(), req.DeveloperID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if developer == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Developers().SetTeam(r.Context(), req.DeveloperID, &team.ID); err != nil {
+			return err
+		}
+
+		updated := *developer
+		updated.TeamID = &team.ID
+		return logMutation(tx, r, nil, models.NewAuditEntry(models.AuditEntityDeveloper, developer.ID, developer, &updated))
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to add team member")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Team member added successfully",
+	})
+}
+
+// RemoveMember handles DELETE /api/v1/teams/{id}/members/{developerID}
+func (h *TeamHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid team ID")
+		return
+	}
+	developerID, err := strconv.Atoi(chi.URLParam(r, "developerID"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid developer ID")
+		return
+	}
+
+	developer, err := h.userRepo.GetByID(r.Context(), developerID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if developer == nil || developer.TeamID == nil || *developer.TeamID != id {
+		utils.ErrorResponse(w, http.StatusNotFound, "Team member not found")
+		return
+	}
+
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Developers().SetTeam(r.Context(), developerID, nil); err != nil {
+			return err
+		}
+
+		updated := *developer
+		updated.TeamID = nil
+		return logMutation(tx, r, nil, models.NewAuditEntry(models.AuditEntityDeveloper, developer.ID, developer, &updated))
+	})
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to remove team member")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Team member removed successfully",
+	})
+}
+
+// Dashboard handles GET /api/v1/teams/dashboard
+// Returns task counts by status for every team, counting only the projects
+// the caller can see
+func (h *TeamHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.repo.TaskStats(r.Context(), 0, h.policy.VisibilityScope(policy.ActorFromRequest(r)))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch team statistics")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    stats,
+	})
+}
+
+// TeamDashboard handles GET /api/v1/teams/{id}/dashboard
+// Like Dashboard, for a single team
+func (h *TeamHandler) TeamDashboard(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid team ID")
+		return
+	}
+
+	stats, err := h.repo.TaskStats(r.Context(), id, h.policy.VisibilityScope(policy.ActorFromRequest(r)))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch team statistics")
+		return
+	}
+
+	if len(stats) == 0 {
+		utils.ErrorResponse(w, http.StatusNotFound, "Team not found")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    stats[0],
+	})
+}
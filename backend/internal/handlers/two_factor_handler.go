@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ardani17/taskmanager/internal/lockout"
+	"github.com/ardani17/taskmanager/internal/middleware"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/internal/services"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// TwoFactorHandler handles two-factor authentication endpoints
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+	sessionService   *services.SessionService
+	loginGuard       *lockout.Guard
+	userRepo         repository.DeveloperStore
+	activityRepo     repository.ActivityStore
+}
+
+// NewTwoFactorHandler creates a new two-factor handler
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService, sessionService *services.SessionService, loginGuard *lockout.Guard, userRepo repository.DeveloperStore, activityRepo repository.ActivityStore) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		sessionService:   sessionService,
+		loginGuard:       loginGuard,
+		userRepo:         userRepo,
+		activityRepo:     activityRepo,
+	}
+}
+
+// Status handles GET /api/v1/auth/2fa
+func (h *TwoFactorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	developer, ok := h.currentDeveloper(w, r)
+	if !ok {
+		return
+	}
+
+	required, err := h.twoFactorService.IsRequired(r.Context(), developer.Role)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to check two-factor requirements")
+		return
+	}
+
+	remaining := 0
+	if developer.TwoFactorEnabled {
+		remaining, err = h.twoFactorService.RemainingRecoveryCodes(r.Context(), developer.ID)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to count recovery codes")
+			return
+		}
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"enabled":                  developer.TwoFactorEnabled,
+			"required":                 required,
+			"recovery_codes_remaining": remaining,
+		},
+	})
+}
+
+// Setup handles POST /api/v1/auth/2fa/setup
+func (h *TwoFactorHandler) Setup(w http.ResponseWriter, r *http.Request) {
+	developer, ok := h.currentDeveloper(w, r)
+	if !ok {
+		return
+	}
+
+	setup, err := h.twoFactorService.Setup(r.Context(), developer)
+	if err != nil {
+		twoFactorError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Scan the URI with an authenticator app, then confirm with a code",
+		"data":    setup,
+	})
+}
+
+// Enable handles POST /api/v1/auth/2fa/enable
+func (h *TwoFactorHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	recoveryCodes, err := h.twoFactorService.Enable(r.Context(), middleware.GetUserID(r), req.Code)
+	if err != nil {
+		twoFactorError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Two-factor authentication enabled",
+		"data": map[string]interface{}{
+			"recovery_codes": recoveryCodes,
+		},
+	})
+}
+
+// Disable handles POST /api/v1/auth/2fa/disable
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	developer, ok := h.currentDeveloper(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactorService.Disable(r.Context(), developer, req.Code); err != nil {
+		twoFactorError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes handles POST /api/v1/auth/2fa/recovery-codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	recoveryCodes, err := h.twoFactorService.RegenerateRecoveryCodes(r.Context(), middleware.GetUserID(r), req.Code)
+	if err != nil {
+		twoFactorError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Recovery codes regenerated",
+		"data": map[string]interface{}{
+			"recovery_codes": recoveryCodes,
+		},
+	})
+}
+
+// LoginSetup handles POST /api/v1/auth/login/2fa/setup
+// Used by developers whose role requires two-factor authentication to enroll during login
+func (h *TwoFactorHandler) LoginSetup(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	setup, err := h.twoFactorService.SetupFromChallenge(r.Context(), req.ChallengeToken)
+	if err != nil {
+		twoFactorError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Scan the URI with an authenticator app, then log in with a code",
+		"data":    setup,
+	})
+}
+
+// Login handles POST /api/v1/auth/login/2fa
+// Exchanges the challenge token from /auth/login and a TOTP or recovery code for tokens.
+// Wrong codes count towards the lockout of the developer and the client IP.
+func (h *TwoFactorHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.ChallengeToken == "" || req.Code == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Challenge token and code are required")
+		return
+	}
+
+	challenged, err := h.twoFactorService.ChallengedDeveloper(r.Context(), req.ChallengeToken)
+	if err != nil {
+		twoFactorError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	// Reject developers and IPs that are locked out after too many failures
+	account := lockout.TwoFactorAccount(challenged.ID)
+	ip := utils.ClientIP(r)
+	wait, err := h.loginGuard.Check(r.Context(), account, ip)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check login lockout")
+	}
+	if wait > 0 {
+		utils.TooManyRequestsResponse(w, wait, "Too many failed login attempts, please try again later")
+		return
+	}
+
+	developer, recoveryCodes, err := h.twoFactorService.CompleteLogin(r.Context(), req.ChallengeToken, req.Code)
+	if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+		if wait := loginFailed(r, h.loginGuard, h.activityRepo, account, ip, challenged); wait > 0 {
+			utils.TooManyRequestsResponse(w, wait, "Too many failed login attempts, please try again later")
+			return
+		}
+	}
+	if err != nil {
+		twoFactorError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.loginGuard.Succeed(r.Context(), account); err != nil {
+		log.Error().Err(err).Msg("Failed to reset login failures")
+	}
+
+	// Start a session and generate JWT tokens
+	tokenPair, err := h.sessionService.Start(r.Context(), developer, r.UserAgent(), ip)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	// Update developer status
+	h.userRepo.UpdateStatus(r.Context(), developer.ID, "online")
+	developer.Status = "online"
+
+	data := map[string]interface{}{
+		"developer": developer,
+		"token": map[string]interface{}{
+			"access_token":  tokenPair.AccessToken,
+			"refresh_token": tokenPair.RefreshToken,
+			"token_type":    tokenPair.TokenType,
+			"expires_in":    tokenPair.ExpiresIn,
+		},
+	}
+	if recoveryCodes != nil {
+		data["recovery_codes"] = recoveryCodes
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Login successful",
+		"data":    data,
+	})
+}
+
+// GetSettings handles GET /api/v1/settings/two-factor
+func (h *TwoFactorHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.twoFactorService.Settings(r.Context())
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch two-factor settings")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    settings,
+	})
+}
+
+// UpdateSettings handles PUT /api/v1/settings/two-factor
+func (h *TwoFactorHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate request
+	if errors := req.Validate(); len(errors) > 0 {
+		utils.ValidationErrorResponse(w, errors)
+		return
+	}
+
+	if err := h.twoFactorService.UpdateSettings(r.Context(), &req); err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update two-factor settings")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Two-factor settings updated successfully",
+		"data":    req,
+	})
+}
+
+// currentDeveloper loads the authenticated developer, writing an error response on failure
+func (h *TwoFactorHandler) currentDeveloper(w http.ResponseWriter, r *http.Request) (*models.Developer, bool) {
+	developer, err := h.userRepo.GetByID(r.Context(), middleware.GetUserID(r))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return nil, false
+	}
+	if developer == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return nil, false
+	}
+	return developer, true
+}
+
+// twoFactorError maps two-factor and challenge token errors to responses.
+// invalidCodeStatus is used for wrong codes: 401 during login, 400 otherwise.
+func twoFactorError(w http.ResponseWriter, err error, invalidCodeStatus int) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		utils.ErrorResponse(w, invalidCodeStatus, "Invalid two-factor code")
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		utils.ForbiddenResponse(w, "two_factor_required", "Two-factor authentication is required for your role")
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		utils.ErrorResponse(w, http.StatusConflict, "Two-factor authentication is already enabled")
+	case errors.Is(err, services.ErrTwoFactorNotSetUp):
+		utils.ErrorResponse(w, http.StatusBadRequest, "Two-factor authentication has not been set up")
+	case errors.Is(err, services.ErrTwoFactorNotEnabled):
+		utils.ErrorResponse(w, http.StatusBadRequest, "Two-factor authentication is not enabled")
+	case errors.Is(err, services.ErrInvalidToken), errors.Is(err, services.ErrExpiredToken),
+		errors.Is(err, services.ErrInvalidClaims), errors.Is(err, services.ErrWrongTokenType):
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Invalid or expired challenge token")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Two-factor authentication failed")
+	}
+}
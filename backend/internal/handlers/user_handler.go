@@ -2,54 +2,66 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/ardani17/taskmanager/internal/middleware"
 	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/policy"
 	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/internal/services"
 	"github.com/ardani17/taskmanager/pkg/utils"
 	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
 )
 
 // UserHandler handles user endpoints
 type UserHandler struct {
-	repo *repository.DeveloperRepository
+	repo            repository.DeveloperStore
+	auditRepo       *repository.AuditRepository
+	uow             *repository.UnitOfWork
+	sessionService  *services.SessionService
+	apiTokenService *services.APITokenService
+	policy          *policy.Policy
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(repo *repository.DeveloperRepository) *UserHandler {
-	return &UserHandler{repo: repo}
+func NewUserHandler(repo repository.DeveloperStore, auditRepo *repository.AuditRepository, uow *repository.UnitOfWork, sessionService *services.SessionService, apiTokenService *services.APITokenService, policy *policy.Policy) *UserHandler {
+	return &UserHandler{
+		repo:            repo,
+		auditRepo:       auditRepo,
+		uow:             uow,
+		sessionService:  sessionService,
+		apiTokenService: apiTokenService,
+		policy:          policy,
+	}
 }
 
 // List handles GET /api/v1/users
 func (h *UserHandler) List(w http.ResponseWriter, r *http.Request) {
 	// Parse pagination params
-	limit := 50
-	offset := 0
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if val, err := strconv.Atoi(l); err == nil && val > 0 {
-			limit = val
-		}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if val, err := strconv.Atoi(o); err == nil && val >= 0 {
-			offset = val
+
+	// Parse filters
+	teamID := 0
+	if t := r.URL.Query().Get("team_id"); t != "" {
+		if val, err := strconv.Atoi(t); err == nil && val > 0 {
+			teamID = val
 		}
 	}
 
-	users, total, err := h.repo.List(limit, offset)
+	users, result, err := h.repo.List(r.Context(), page, teamID)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch users")
 		return
 	}
 
-	utils.JSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    users,
-		"total":   total,
-	})
+	writePage(w, r, users, result)
 }
 
 // Get handles GET /api/v1/users/{id}
@@ -61,7 +73,7 @@ func (h *UserHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.repo.GetByID(id)
+	user, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
 		return
@@ -87,13 +99,8 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get current user ID from context
-	currentUserID := middleware.GetUserID(r)
-	currentUserRole := middleware.GetUserRole(r)
-
 	// Only allow users to update their own profile, or admins to update any
-	if id != currentUserID && currentUserRole != "admin" {
-		utils.ErrorResponse(w, http.StatusForbidden, "You can only update your own profile")
+	if !authorize(w, h.policy.CanManageUser(policy.ActorFromRequest(r), id)) {
 		return
 	}
 
@@ -103,7 +110,25 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.repo.Update(id, &req)
+	existing, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if existing == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	var user *models.Developer
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		var err error
+		user, err = tx.Developers().Update(r.Context(), id, &req)
+		if err != nil || user == nil {
+			return err
+		}
+		return logMutation(tx, r, nil, models.NewAuditEntry(models.AuditEntityDeveloper, user.ID, existing, user))
+	})
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update user")
 		return
@@ -123,24 +148,43 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 // Delete handles DELETE /api/v1/users/{id}
 func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
 	// Only admins can delete users
-	currentUserRole := middleware.GetUserRole(r)
-	if currentUserRole != "admin" {
-		utils.ErrorResponse(w, http.StatusForbidden, "Only admins can delete users")
+	if !authorize(w, h.policy.CanDeleteUser(policy.ActorFromRequest(r), id)) {
 		return
 	}
 
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.Atoi(idStr)
+	// Get user before deleting (for audit log)
+	user, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if user == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Developers().Delete(r.Context(), id); err != nil {
+			return err
+		}
+		return logMutation(tx, r, nil, models.NewAuditEntry(models.AuditEntityDeveloper, user.ID, user, nil))
+	})
+	if errors.Is(err, repository.ErrNotFound) {
 		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
 		return
 	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
 
 	utils.JSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -157,6 +201,11 @@ func (h *UserHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only allow users to change their own status, or admins to change any
+	if !authorize(w, h.policy.CanManageUser(policy.ActorFromRequest(r), id)) {
+		return
+	}
+
 	var req struct {
 		Status string `json:"status"`
 	}
@@ -170,7 +219,26 @@ func (h *UserHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.UpdateStatus(id, req.Status); err != nil {
+	user, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if user == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Developers().UpdateStatus(r.Context(), id, req.Status); err != nil {
+			return err
+		}
+
+		updated := *user
+		updated.Status = req.Status
+		return logMutation(tx, r, nil, models.NewAuditEntry(models.AuditEntityDeveloper, user.ID, user, &updated))
+	})
+	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update status")
 		return
 	}
@@ -181,6 +249,124 @@ func (h *UserHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ListSessions handles GET /api/v1/users/{id}/sessions
+func (h *UserHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if user == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	sessions, err := h.sessionService.List(r.Context(), id, middleware.GetSessionID(r))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch sessions")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    sessions,
+		"total":   len(sessions),
+	})
+}
+
+// ForceLogout handles POST /api/v1/users/{id}/force-logout
+// Revokes every session and API token of a user, e.g. when the account is compromised
+func (h *UserHandler) ForceLogout(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if user == nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	revoked, err := h.sessionService.RevokeAll(r.Context(), id, models.RevokeReasonAdminForced)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	revokedTokens, err := h.apiTokenService.RevokeAll(r.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to revoke API tokens")
+		return
+	}
+
+	adminID := middleware.GetUserID(r)
+	err = h.uow.Do(r.Context(), func(tx *repository.Tx) error {
+		if err := tx.Developers().UpdateStatus(r.Context(), id, "inactive"); err != nil {
+			return err
+		}
+
+		activity := &models.Activity{
+			DeveloperID: &adminID,
+			Action:      models.ActionUserForcedLogout,
+			Description: "Sessions revoked for " + user.Email,
+			Metadata: models.JSONB{
+				"user_id":            user.ID,
+				"revoked":            revoked,
+				"revoked_api_tokens": revokedTokens,
+			},
+			CreatedAt: now(),
+		}
+		updated := *user
+		updated.Status = "inactive"
+		return logMutation(tx, r, activity, models.NewAuditEntry(models.AuditEntityDeveloper, user.ID, user, &updated))
+	})
+	if err != nil {
+		// The sessions and tokens are revoked either way
+		log.Error().Err(err).Int("developer_id", user.ID).Msg("Failed to deactivate user after forced logout")
+	}
+
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "User logged out of all sessions",
+		"data": map[string]interface{}{
+			"revoked":            revoked,
+			"revoked_api_tokens": revokedTokens,
+		},
+	})
+}
+
+// History handles GET /api/v1/users/{id}/history
+// Users can read their own history; admins can read anyone's, including
+// deleted users.
+func (h *UserHandler) History(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if !authorize(w, h.policy.CanManageUser(policy.ActorFromRequest(r), id)) {
+		return
+	}
+
+	writeHistory(w, r, h.auditRepo, models.AuditEntityDeveloper, id)
+}
+
 // Helper to get current time
 func now() time.Time {
 	return time.Now()
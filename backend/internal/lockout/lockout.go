@@ -0,0 +1,152 @@
+// Package lockout slows down password guessing by counting failed logins per
+// account and per client IP and locking a key out for an exponentially
+// growing time once it has failed too often.
+//
+// Password logins are counted by the email they were tried for, and the
+// second factor of two-factor logins by TwoFactorAccount.
+package lockout
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scopes of a lockout
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+)
+
+// Policy decides when and for how long a key is locked out
+type Policy struct {
+	// MaxFailures is the number of failures allowed before the first lockout
+	MaxFailures int
+	// BaseDelay is the length of the first lockout; every further failure doubles it
+	BaseDelay time.Duration
+	// MaxDelay caps the length of a lockout
+	MaxDelay time.Duration
+	// Window is how long failures are remembered after the most recent one
+	Window time.Duration
+}
+
+// Delay returns how long a key is locked out after its nth consecutive failure
+func (p Policy) Delay(failures int) time.Duration {
+	if p.MaxFailures <= 0 || failures < p.MaxFailures {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.MaxFailures; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Lockout describes a lockout that was just started
+type Lockout struct {
+	Scope      string
+	Key        string
+	Failures   int
+	RetryAfter time.Duration
+}
+
+// Guard tracks failed logins and enforces the account and IP policies
+type Guard struct {
+	store   Store
+	account Policy
+	ip      Policy
+}
+
+// NewGuard creates a new guard
+func NewGuard(store Store, account, ip Policy) *Guard {
+	return &Guard{
+		store:   store,
+		account: account,
+		ip:      ip,
+	}
+}
+
+// TwoFactorAccount is the account under which the failed second factors of
+// a developer are counted
+func TwoFactorAccount(developerID int) string {
+	return "2fa:" + strconv.Itoa(developerID)
+}
+
+// Check returns how long the caller has to wait before trying to log in to
+// the account from the IP again, or 0 if it may try now
+func (g *Guard) Check(ctx context.Context, account, ip string) (time.Duration, error) {
+	accountWait, err := g.store.LockedFor(ctx, accountKey(account))
+	if err != nil {
+		return 0, err
+	}
+	ipWait, err := g.store.LockedFor(ctx, ipKey(ip))
+	if err != nil {
+		return 0, err
+	}
+
+	if ipWait > accountWait {
+		return ipWait, nil
+	}
+	return accountWait, nil
+}
+
+// Fail records a failed login and returns the lockouts it started
+func (g *Guard) Fail(ctx context.Context, account, ip string) ([]Lockout, error) {
+	var lockouts []Lockout
+
+	targets := []struct {
+		scope  string
+		value  string
+		key    string
+		policy Policy
+	}{
+		{ScopeAccount, normalizeAccount(account), accountKey(account), g.account},
+		{ScopeIP, ip, ipKey(ip), g.ip},
+	}
+	for _, t := range targets {
+		failures, err := g.store.AddFailure(ctx, t.key, t.policy.Window)
+		if err != nil {
+			return lockouts, err
+		}
+
+		delay := t.policy.Delay(failures)
+		if delay == 0 {
+			continue
+		}
+		if err := g.store.Lock(ctx, t.key, delay); err != nil {
+			return lockouts, err
+		}
+		lockouts = append(lockouts, Lockout{
+			Scope:      t.scope,
+			Key:        t.value,
+			Failures:   failures,
+			RetryAfter: delay,
+		})
+	}
+
+	return lockouts, nil
+}
+
+// Succeed clears the failures of an account after a successful login. The
+// IP's failures are kept, so one valid account cannot be used to keep
+// guessing the passwords of others.
+func (g *Guard) Succeed(ctx context.Context, account string) error {
+	return g.store.Reset(ctx, accountKey(account))
+}
+
+func accountKey(account string) string {
+	return "login:" + ScopeAccount + ":" + normalizeAccount(account)
+}
+
+func ipKey(ip string) string {
+	return "login:" + ScopeIP + ":" + ip
+}
+
+func normalizeAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
@@ -0,0 +1,116 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{MaxFailures: 3, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: 2, want: 0},
+		{failures: 3, want: time.Minute},
+		{failures: 4, want: 2 * time.Minute},
+		{failures: 6, want: 8 * time.Minute},
+		{failures: 7, want: 10 * time.Minute},
+		{failures: 100, want: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Delay(tt.failures); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+
+	if got := (Policy{}).Delay(100); got != 0 {
+		t.Errorf("disabled policy delays by %v", got)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	account := Policy{MaxFailures: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour}
+	ip := Policy{MaxFailures: 3, BaseDelay: time.Hour, MaxDelay: time.Hour, Window: time.Hour}
+	guard := NewGuard(NewMemoryStore(), account, ip)
+
+	fail := func(account, ip string) []Lockout {
+		t.Helper()
+		lockouts, err := guard.Fail(ctx, account, ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lockouts
+	}
+	wait := func(account, ip string) time.Duration {
+		t.Helper()
+		wait, err := guard.Check(ctx, account, ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wait
+	}
+
+	if lockouts := fail("Ann@Example.com", "192.0.2.1"); len(lockouts) != 0 {
+		t.Fatalf("first failure locked out %+v", lockouts)
+	}
+
+	// Accounts are counted case-insensitively
+	lockouts := fail(" ann@example.com", "192.0.2.1")
+	if len(lockouts) != 1 || lockouts[0].Scope != ScopeAccount || lockouts[0].Key != "ann@example.com" ||
+		lockouts[0].Failures != 2 || lockouts[0].RetryAfter != time.Minute {
+		t.Fatalf("second failure: %+v", lockouts)
+	}
+	if w := wait("ANN@example.com", "198.51.100.7"); w <= 59*time.Second || w > time.Minute {
+		t.Fatalf("locked account waits %v", w)
+	}
+	if w := wait("bob@example.com", "198.51.100.7"); w != 0 {
+		t.Fatalf("other account waits %v", w)
+	}
+
+	// The IP is locked out after failures across accounts, and stays so
+	// after a successful login
+	lockouts = fail("bob@example.com", "192.0.2.1")
+	if len(lockouts) != 1 || lockouts[0].Scope != ScopeIP || lockouts[0].Key != "192.0.2.1" || lockouts[0].RetryAfter != time.Hour {
+		t.Fatalf("third failure from the IP: %+v", lockouts)
+	}
+	if err := guard.Succeed(ctx, "ann@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if w := wait("ann@example.com", "198.51.100.7"); w != 0 {
+		t.Fatalf("account waits %v after a successful login", w)
+	}
+	if w := wait("carol@example.com", "192.0.2.1"); w <= 59*time.Minute {
+		t.Fatalf("locked IP waits %v", w)
+	}
+}
+
+func TestTwoFactorAccount(t *testing.T) {
+	if got := TwoFactorAccount(7); got != "2fa:7" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestMemoryStoreForgetsFailures(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	for want := 1; want <= 2; want++ {
+		got, err := store.AddFailure(ctx, "key", 20*time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("failure count %d, want %d", got, want)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if got, _ := store.AddFailure(ctx, "key", time.Minute); got != 1 {
+		t.Fatalf("failure count %d after the window, want 1", got)
+	}
+}
@@ -0,0 +1,67 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a Store shared by every server using the same Redis
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a new Redis store. Keys are prefixed with "lockout:".
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client, prefix: "lockout:"}
+}
+
+// AddFailure implements Store
+func (s *RedisStore) AddFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	failuresKey := s.prefix + key + ":failures"
+
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, failuresKey)
+		pipe.PExpire(ctx, failuresKey, window)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	return int(incr.Val()), nil
+}
+
+// Lock implements Store
+func (s *RedisStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	if err := s.client.Set(ctx, s.prefix+key+":lock", 1, d).Err(); err != nil {
+		return fmt.Errorf("failed to lock out %s: %w", key, err)
+	}
+	return nil
+}
+
+// LockedFor implements Store
+func (s *RedisStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, s.prefix+key+":lock").Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check lockout: %w", err)
+	}
+
+	// Negative values mean the key does not exist or has no expiry
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Reset implements Store
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.prefix+key+":failures", s.prefix+key+":lock").Err(); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
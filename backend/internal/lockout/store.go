@@ -0,0 +1,117 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps failure counters and lockouts. MemoryStore suits a single
+// server; RedisStore shares state between several.
+type Store interface {
+	// AddFailure increments the failure count of key and returns the new
+	// count. The count is forgotten after window without further failures.
+	AddFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	// Lock locks key out for d
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor returns how long key stays locked out, or 0
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Reset clears the failures and lockout of key
+	Reset(ctx context.Context, key string) error
+}
+
+// sweepInterval is how often the memory store drops forgotten entries
+const sweepInterval = time.Minute
+
+// MemoryStore is an in-process Store
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	failures    int
+	expiresAt   time.Time
+	lockedUntil time.Time
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+// AddFailure implements Store
+func (s *MemoryStore) AddFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	e := s.entries[key]
+	if e == nil {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	} else if now.After(e.expiresAt) {
+		e.failures = 0
+	}
+	e.failures++
+	e.expiresAt = now.Add(window)
+
+	return e.failures, nil
+}
+
+// Lock implements Store
+func (s *MemoryStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entries[key]
+	if e == nil {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+	e.lockedUntil = time.Now().Add(d)
+
+	return nil
+}
+
+// LockedFor implements Store
+func (s *MemoryStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entries[key]
+	if e == nil {
+		return 0, nil
+	}
+	if wait := time.Until(e.lockedUntil); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// Reset implements Store
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep drops entries whose failures and lockout have both expired.
+// The caller must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if now.After(e.expiresAt) && now.After(e.lockedUntil) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/ardani17/taskmanager/internal/models"
 	"github.com/ardani17/taskmanager/internal/services"
 	"github.com/ardani17/taskmanager/pkg/utils"
 )
@@ -16,10 +19,14 @@ const (
 	UserIDKey    contextKey = "userID"
 	UserEmailKey contextKey = "userEmail"
 	UserRoleKey  contextKey = "userRole"
+	SessionIDKey contextKey = "sessionID"
+	ScopesKey    contextKey = "scopes"
+	APITokenKey  contextKey = "apiTokenID"
 )
 
-// AuthMiddleware validates JWT tokens and protects routes
-func AuthMiddleware(jwtService *services.JWTService) func(http.Handler) http.Handler {
+// AuthMiddleware validates JWT access tokens and personal API tokens and
+// protects routes. JWTs belonging to a revoked or expired session are rejected.
+func AuthMiddleware(jwtService *services.JWTService, sessionService *services.SessionService, apiTokenService *services.APITokenService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get Authorization header
@@ -36,6 +43,11 @@ func AuthMiddleware(jwtService *services.JWTService) func(http.Handler) http.Han
 				return
 			}
 
+			if services.IsAPIToken(tokenString) {
+				authenticateAPIToken(w, r, next, apiTokenService, tokenString)
+				return
+			}
+
 			// Validate token
 			claims, err := jwtService.ValidateToken(tokenString)
 			if err != nil {
@@ -47,11 +59,23 @@ func AuthMiddleware(jwtService *services.JWTService) func(http.Handler) http.Han
 				return
 			}
 
+			// Check that the session has not been revoked
+			active, err := sessionService.Use(r.Context(), claims.SessionID, utils.ClientIP(r))
+			if err != nil {
+				utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to verify session")
+				return
+			}
+			if !active {
+				utils.ErrorResponse(w, http.StatusUnauthorized, "Session has been revoked")
+				return
+			}
+
 			// Add user info to context
 			ctx := r.Context()
 			ctx = context.WithValue(ctx, UserIDKey, claims.DeveloperID)
 			ctx = context.WithValue(ctx, UserEmailKey, claims.Email)
 			ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+			ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
 
 			// Continue to next handler
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -59,6 +83,97 @@ func AuthMiddleware(jwtService *services.JWTService) func(http.Handler) http.Han
 	}
 }
 
+// authenticateAPIToken authenticates a request made with a personal API token
+// and checks that the token's scopes cover the request
+func authenticateAPIToken(w http.ResponseWriter, r *http.Request, next http.Handler, apiTokenService *services.APITokenService, secret string) {
+	token, err := apiTokenService.Authenticate(r.Context(), secret, utils.ClientIP(r))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIToken) {
+			utils.ErrorResponse(w, http.StatusUnauthorized, "Invalid, revoked or expired API token")
+			return
+		}
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to verify API token")
+		return
+	}
+
+	scope, allowed := requiredScope(r)
+	if !allowed {
+		utils.ForbiddenResponse(w, "session_required", "This endpoint is not available to API tokens")
+		return
+	}
+	if !models.ScopesAllow(token.Scopes, scope) {
+		utils.ForbiddenResponse(w, "insufficient_scope", "API token lacks the "+scope+" scope")
+		return
+	}
+
+	// Admin privileges need the admin scope, not just an admin owner
+	role := token.DeveloperRole
+	if role == models.RoleAdmin && !models.ScopesAllow(token.Scopes, models.ScopeAdmin) {
+		role = models.RoleDeveloper
+	}
+
+	ctx := r.Context()
+	ctx = context.WithValue(ctx, UserIDKey, strconv.Itoa(token.DeveloperID))
+	ctx = context.WithValue(ctx, UserEmailKey, token.DeveloperEmail)
+	ctx = context.WithValue(ctx, UserRoleKey, role)
+	ctx = context.WithValue(ctx, ScopesKey, token.Scopes)
+	ctx = context.WithValue(ctx, APITokenKey, token.ID)
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// requiredScope returns the API token scope a request needs. Reads need
+// read:<resource> and everything else write:<resource>. allowed is false for
+// endpoints that only accept logins, such as session and token management.
+func requiredScope(r *http.Request) (scope string, allowed bool) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/")
+	resource, _, _ := strings.Cut(path, "/")
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	switch resource {
+	case "auth":
+		// Tokens may identify their owner, but not manage the account
+		if read && strings.TrimSuffix(path, "/") == "auth/me" {
+			return models.ScopeReadUsers, true
+		}
+		return "", false
+	case "tasks", "projects", "users", "teams", "activity":
+		if read {
+			return "read:" + resource, true
+		}
+		return "write:" + resource, true
+	case "search", "views":
+		// Search and saved views work on tasks
+		if read {
+			return models.ScopeReadTasks, true
+		}
+		return models.ScopeWriteTasks, true
+	case "invitations":
+		// Invitations grant project access, and their list includes emails
+		return models.ScopeWriteProjects, true
+	default:
+		return models.ScopeAdmin, true
+	}
+}
+
+// GetScopes returns the scopes of the API token used for the request, or nil
+// for requests authenticated with a login session
+func GetScopes(r *http.Request) []string {
+	if scopes, ok := r.Context().Value(ScopesKey).([]string); ok {
+		return scopes
+	}
+	return nil
+}
+
+// GetAPITokenID returns the ID of the API token used for the request, or 0
+// for requests authenticated with a login session
+func GetAPITokenID(r *http.Request) int {
+	if id, ok := r.Context().Value(APITokenKey).(int); ok {
+		return id
+	}
+	return 0
+}
+
 // GetUserID extracts user ID from request context (returns int)
 func GetUserID(r *http.Request) int {
 	if userIDStr, ok := r.Context().Value(UserIDKey).(string); ok {
@@ -95,13 +210,21 @@ func GetUserRole(r *http.Request) string {
 	return ""
 }
 
+// GetSessionID extracts the session ID from request context
+func GetSessionID(r *http.Request) string {
+	if sessionID, ok := r.Context().Value(SessionIDKey).(string); ok {
+		return sessionID
+	}
+	return ""
+}
+
 // RequireRole middleware checks if user has required role
 func RequireRole(requiredRole string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			role := GetUserRole(r)
 			if role != requiredRole && role != "admin" {
-				utils.ErrorResponse(w, http.StatusForbidden, "Insufficient permissions")
+				utils.ForbiddenResponse(w, "insufficient_role", "Insufficient permissions")
 				return
 			}
 			next.ServeHTTP(w, r)
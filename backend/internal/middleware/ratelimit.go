@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/ratelimit"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// RateLimit limits requests with a token bucket per caller. Callers are told
+// about their budget with RateLimit-Limit, RateLimit-Remaining,
+// RateLimit-Reset and RateLimit-Policy headers and get a 429 with Retry-After
+// once it is used up. If the store fails, requests are let through.
+//
+// group separates the buckets of differently limited routes. A disabled
+// limit lets every request through.
+func RateLimit(store ratelimit.Store, group string, limit ratelimit.Limit) func(http.Handler) http.Handler {
+	return RateLimitByMethod(store, group, limit, limit)
+}
+
+// RateLimitByMethod is RateLimit with separate limits, and buckets, for
+// reads (GET, HEAD and OPTIONS) and writes
+func RateLimitByMethod(store ratelimit.Store, group string, read, write ratelimit.Limit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name, limit := group, read
+			if read != write {
+				name += ":read"
+				if !isRead(r) {
+					name, limit = group+":write", write
+				}
+			}
+			if !limit.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := store.Take(r.Context(), name+":"+rateLimitKey(r), limit)
+			if err != nil {
+				log.Error().Err(err).Msg("Rate limiter failed, allowing request")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			h.Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(ceilSeconds(limit.Per)))
+
+			if !result.Allowed {
+				utils.TooManyRequestsResponse(w, result.RetryAfter, "Rate limit exceeded, please slow down")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKey identifies the caller: by API token, then developer, then
+// client IP for unauthenticated requests
+func rateLimitKey(r *http.Request) string {
+	if id := GetAPITokenID(r); id != 0 {
+		return "token:" + strconv.Itoa(id)
+	}
+	if id := GetUserIDString(r); id != "" {
+		return "developer:" + id
+	}
+	return "ip:" + utils.ClientIP(r)
+}
+
+func isRead(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
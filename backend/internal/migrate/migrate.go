@@ -0,0 +1,334 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// advisoryLockID serializes migrations across server instances sharing a database
+const advisoryLockID = 72060321
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+// Migrator applies embedded SQL migrations and records the current version
+// in a schema_migrations table compatible with golang-migrate
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []*Migration
+}
+
+// Migration errors
+var (
+	ErrDirty          = errors.New("database schema is dirty, fix it manually and force the version")
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrNoDownScript   = errors.New("migration has no down script")
+)
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// New creates a migrator from the *.up.sql and *.down.sql files in fsys for
+// a database of the given driver, "postgres" or "sqlite"
+func New(db *sql.DB, driver string, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %q: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(".", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("conflicting names for migration %d: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// Latest returns the highest known migration version
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the currently applied version and whether it is dirty
+func (m *Migrator) Version() (int64, bool, error) {
+	if err := m.ensureTable(m.db); err != nil {
+		return 0, false, err
+	}
+	return m.version(m.db)
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	current, _, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		statuses = append(statuses, MigrationStatus{
+			Version: mig.Version,
+			Name:    mig.Name,
+			Applied: mig.Version <= current,
+		})
+	}
+	return statuses, nil
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up() error {
+	return m.Goto(m.Latest())
+}
+
+// Down rolls back the last n applied migrations
+func (m *Migrator) Down(n int) error {
+	current, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	target := int64(0)
+	applied := m.appliedUpTo(current)
+	if n < len(applied) {
+		target = applied[len(applied)-n-1].Version
+	}
+	return m.Goto(target)
+}
+
+// Goto migrates up or down until the given version is the current one.
+// Version 0 rolls back every migration.
+func (m *Migrator) Goto(target int64) error {
+	if target != 0 && m.find(target) == nil {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := m.ensureTable(conn); err != nil {
+		return err
+	}
+
+	current, dirty, err := m.version(conn)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w (version %d)", ErrDirty, current)
+	}
+
+	// Migrate up
+	for _, mig := range m.migrations {
+		if mig.Version <= current || mig.Version > target {
+			continue
+		}
+		if err := m.apply(ctx, conn, mig.Up, mig.Version); err != nil {
+			return fmt.Errorf("migration %d_%s up failed: %w", mig.Version, mig.Name, err)
+		}
+		log.Info().Int64("version", mig.Version).Str("name", mig.Name).Msg("Migration applied")
+	}
+
+	// Migrate down
+	applied := m.appliedUpTo(current)
+	for i := len(applied) - 1; i >= 0; i-- {
+		mig := applied[i]
+		if mig.Version <= target {
+			break
+		}
+		if mig.Down == "" {
+			return fmt.Errorf("%w: %d_%s", ErrNoDownScript, mig.Version, mig.Name)
+		}
+
+		previous := int64(0)
+		if i > 0 {
+			previous = applied[i-1].Version
+		}
+		if err := m.apply(ctx, conn, mig.Down, previous); err != nil {
+			return fmt.Errorf("migration %d_%s down failed: %w", mig.Version, mig.Name, err)
+		}
+		log.Info().Int64("version", mig.Version).Str("name", mig.Name).Msg("Migration rolled back")
+	}
+
+	return nil
+}
+
+// lock keeps other servers sharing a Postgres database from migrating it at
+// the same time. A SQLite database belongs to a single server and every
+// migration takes its write lock anyway, so it needs no lock.
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	if m.driver == "sqlite" {
+		return func() {}, nil
+	}
+
+	// Migrations, and waiting for another server's migrations, may take
+	// longer than the statement timeout meant for API queries
+	if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+		return nil, fmt.Errorf("failed to disable statement timeout: %w", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockID); err != nil {
+		conn.ExecContext(ctx, "RESET statement_timeout")
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	return func() {
+		conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", advisoryLockID)
+		conn.ExecContext(ctx, "RESET statement_timeout")
+	}, nil
+}
+
+// apply runs a migration script and records the resulting version in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, script string, version int64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("failed to clear schema version: %w", err)
+	}
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", version); err != nil {
+			return fmt.Errorf("failed to record schema version: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// appliedUpTo returns the known migrations with a version at or below current
+func (m *Migrator) appliedUpTo(current int64) []*Migration {
+	var applied []*Migration
+	for _, mig := range m.migrations {
+		if mig.Version <= current {
+			applied = append(applied, mig)
+		}
+	}
+	return applied
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig
+		}
+	}
+	return nil
+}
+
+type execQueryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (m *Migrator) ensureTable(db execQueryer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) version(db execQueryer) (int64, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, dirty, nil
+}
@@ -0,0 +1,201 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rs/zerolog"
+	_ "modernc.org/sqlite"
+)
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+// testMigrations creates two tables; the third migration cannot be rolled back
+var testMigrations = fstest.MapFS{
+	"001_teams.up.sql":     {Data: []byte("CREATE TABLE teams (id INTEGER PRIMARY KEY)")},
+	"001_teams.down.sql":   {Data: []byte("DROP TABLE teams")},
+	"002_members.up.sql":   {Data: []byte("CREATE TABLE members (id INTEGER PRIMARY KEY)")},
+	"002_members.down.sql": {Data: []byte("DROP TABLE members")},
+	"010_seed.up.sql":      {Data: []byte("INSERT INTO teams (id) VALUES (1)")},
+	"README.md":            {Data: []byte("not a migration")},
+}
+
+func newTestMigrator(t *testing.T, fsys fstest.MapFS) (*Migrator, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := New(db, "sqlite", fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, db
+}
+
+func tables(t *testing.T, db *sql.DB) string {
+	t.Helper()
+
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name <> 'schema_migrations' ORDER BY name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ",")
+}
+
+func expectVersion(t *testing.T, m *Migrator, want int64) {
+	t.Helper()
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != want || dirty {
+		t.Fatalf("version %d (dirty %v), want %d", version, dirty, want)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	m, db := newTestMigrator(t, testMigrations)
+	if m.Latest() != 10 {
+		t.Fatalf("latest = %d", m.Latest())
+	}
+	expectVersion(t, m, 0)
+
+	if err := m.Goto(2); err != nil {
+		t.Fatal(err)
+	}
+	expectVersion(t, m, 2)
+	if got := tables(t, db); got != "members,teams" {
+		t.Fatalf("tables = %s", got)
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MigrationStatus{{1, "teams", true}, {2, "members", true}, {10, "seed", false}}
+	if len(statuses) != len(want) {
+		t.Fatalf("status = %+v", statuses)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("status = %+v, want %+v", statuses, want)
+		}
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	expectVersion(t, m, 10)
+
+	// The seed has no down script
+	if err := m.Down(1); !errors.Is(err, ErrNoDownScript) {
+		t.Fatalf("down over a migration without a down script: %v", err)
+	}
+	expectVersion(t, m, 10)
+
+	if _, err := db.Exec("DELETE FROM schema_migrations; INSERT INTO schema_migrations (version, dirty) VALUES (2, false)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	expectVersion(t, m, 1)
+	if err := m.Down(5); err != nil {
+		t.Fatal(err)
+	}
+	expectVersion(t, m, 0)
+	if got := tables(t, db); got != "" {
+		t.Fatalf("tables after rolling back everything = %s", got)
+	}
+
+	if err := m.Goto(3); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("goto an unknown version: %v", err)
+	}
+}
+
+func TestMigratorFailedMigration(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_teams.up.sql":  testMigrations["001_teams.up.sql"],
+		"002_broken.up.sql": {Data: []byte("CREATE TABLE members (id INTEGER PRIMARY KEY); CREATE TABLE nonsense (")},
+	}
+	m, db := newTestMigrator(t, fsys)
+
+	if err := m.Up(); err == nil || !strings.Contains(err.Error(), "2_broken up failed") {
+		t.Fatalf("up = %v", err)
+	}
+
+	// The failed migration is rolled back as a whole
+	expectVersion(t, m, 1)
+	if got := tables(t, db); got != "teams" {
+		t.Fatalf("tables = %s", got)
+	}
+}
+
+func TestMigratorDirty(t *testing.T) {
+	m, db := newTestMigrator(t, testMigrations)
+	if err := m.Goto(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("UPDATE schema_migrations SET dirty = true"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(); !errors.Is(err, ErrDirty) {
+		t.Fatalf("up on a dirty schema: %v", err)
+	}
+}
+
+func TestNewRejectsInvalidMigrations(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		err  string
+	}{
+		{
+			name: "conflicting names",
+			fsys: fstest.MapFS{
+				"001_teams.up.sql":    {Data: []byte("SELECT 1")},
+				"001_groups.up.sql":   {Data: []byte("SELECT 1")},
+				"001_groups.down.sql": {Data: []byte("SELECT 1")},
+			},
+			err: "conflicting names for migration 1",
+		},
+		{
+			name: "no up script",
+			fsys: fstest.MapFS{"001_teams.down.sql": {Data: []byte("SELECT 1")}},
+			err:  "migration 1_teams has no up script",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(nil, "sqlite", tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
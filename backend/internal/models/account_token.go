@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// AccountToken is a single-use token sent by email, e.g. to verify an
+// address or reset a password. Only the hash of the token is stored.
+type AccountToken struct {
+	ID          int        `json:"id"`
+	DeveloperID int        `json:"developer_id"`
+	Purpose     string     `json:"purpose"`
+	TokenHash   string     `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	UsedAt      *time.Time `json:"used_at,omitempty"`
+}
+
+// Account token purposes
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposePasswordReset = "password_reset"
+)
@@ -11,6 +11,7 @@ type Activity struct {
 	ID          int        `json:"id"`
 	DeveloperID *int       `json:"developer_id,omitempty"`
 	TaskID      *int       `json:"task_id,omitempty"`
+	ProjectID   *int       `json:"project_id,omitempty"` // of activities without a task
 	Developer   *Developer `json:"developer,omitempty"`
 	Action      string     `json:"action"`
 	Description string     `json:"description,omitempty"`
@@ -64,11 +65,34 @@ const (
 	ActionTaskUpdated   = "task_updated"
 	ActionTaskDeleted   = "task_deleted"
 	ActionTaskCompleted = "task_completed"
+	ActionTaskMoved     = "task_moved"
+	ActionTaskLinked    = "task_linked"
+	ActionTaskUnlinked  = "task_unlinked"
 
 	ActionProjectCreated = "project_created"
 	ActionProjectUpdated = "project_updated"
 	ActionProjectDeleted = "project_deleted"
 
+	ActionProjectMemberAdded   = "project_member_added"
+	ActionProjectMemberRemoved = "project_member_removed"
+
+	ActionTeamCreated = "team_created"
+	ActionTeamUpdated = "team_updated"
+	ActionTeamDeleted = "team_deleted"
+
 	ActionUserLoggedIn  = "user_logged_in"
 	ActionUserLoggedOut = "user_logged_out"
+
+	ActionUserForcedLogout = "user_forced_logout"
+
+	ActionInvitationCreated  = "invitation_created"
+	ActionInvitationAccepted = "invitation_accepted"
+	ActionInvitationRevoked  = "invitation_revoked"
+
+	// Security events are only shown to admins
+	ActionSecurityAccountLocked = "security_account_locked"
+	ActionSecurityIPBlocked     = "security_ip_blocked"
 )
+
+// SecurityActionPrefix starts the action of every security event
+const SecurityActionPrefix = "security_"
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// APIToken is a personal access token for scripts and CI. Only the hash of
+// the token is stored; the token itself is shown once, when it is created.
+type APIToken struct {
+	ID          int        `json:"id"`
+	DeveloperID int        `json:"developer_id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	TokenHash   string     `json:"-"`
+	Scopes      []string   `json:"scopes"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP  string     `json:"last_used_ip,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+
+	// Owner details, loaded when a token is authenticated
+	DeveloperEmail string `json:"-"`
+	DeveloperRole  string `json:"-"`
+}
+
+// API token scopes. write:X implies read:X, and admin implies every scope.
+const (
+	ScopeReadTasks     = "read:tasks"
+	ScopeWriteTasks    = "write:tasks"
+	ScopeReadProjects  = "read:projects"
+	ScopeWriteProjects = "write:projects"
+	ScopeReadUsers     = "read:users"
+	ScopeWriteUsers    = "write:users"
+	ScopeReadTeams     = "read:teams"
+	ScopeWriteTeams    = "write:teams"
+	ScopeReadActivity  = "read:activity"
+	ScopeAdmin         = "admin"
+)
+
+// APITokenScopes lists every valid scope
+var APITokenScopes = []string{
+	ScopeReadTasks, ScopeWriteTasks,
+	ScopeReadProjects, ScopeWriteProjects,
+	ScopeReadUsers, ScopeWriteUsers,
+	ScopeReadTeams, ScopeWriteTeams,
+	ScopeReadActivity,
+	ScopeAdmin,
+}
+
+// IsActive reports whether the token is neither revoked nor expired
+func (t *APIToken) IsActive() bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || time.Now().Before(*t.ExpiresAt)
+}
+
+// ScopesAllow reports whether a set of granted scopes covers a required scope
+func ScopesAllow(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scope == required || scope == ScopeAdmin {
+			return true
+		}
+		// write:X implies read:X
+		if strings.HasPrefix(required, "read:") && scope == "write:"+strings.TrimPrefix(required, "read:") {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPITokenRequest represents an API token creation request
+type CreateAPITokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+
+	// ExpiresInDays is optional; tokens without it never expire
+	ExpiresInDays int `json:"expires_in_days,omitempty"`
+}
+
+// Validate validates the create API token request
+func (r *CreateAPITokenRequest) Validate() []string {
+	var errors []string
+
+	if r.Name == "" {
+		errors = append(errors, "Name is required")
+	}
+	if len(r.Name) > 100 {
+		errors = append(errors, "Name must be at most 100 characters")
+	}
+	if len(r.Scopes) == 0 {
+		errors = append(errors, "At least one scope is required")
+	}
+	for _, scope := range r.Scopes {
+		if !isAPITokenScope(scope) {
+			errors = append(errors, "Unknown scope: "+scope)
+		}
+	}
+	if r.ExpiresInDays < 0 {
+		errors = append(errors, "Expiry must not be negative")
+	}
+
+	return errors
+}
+
+func isAPITokenScope(scope string) bool {
+	for _, s := range APITokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
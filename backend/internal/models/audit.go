@@ -0,0 +1,177 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry records one mutation of an entity with the fields it changed
+type AuditEntry struct {
+	ID         int64        `json:"id"`
+	EntityType string       `json:"entity_type"`
+	EntityID   int          `json:"entity_id"`
+	Action     string       `json:"action"`
+	ActorID    *int         `json:"actor_id,omitempty"`
+	Actor      *Developer   `json:"actor,omitempty"`
+	RequestID  string       `json:"request_id,omitempty"`
+	IPAddress  string       `json:"ip_address,omitempty"`
+	Changes    AuditChanges `json:"changes"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// Audited entity types
+const (
+	AuditEntityTask      = "task"
+	AuditEntityProject   = "project"
+	AuditEntityDeveloper = "developer"
+)
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// FieldChange is the value of a field before and after a mutation. Before is
+// null for created entities and After is null for deleted ones.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges maps field names to their change
+type AuditChanges map[string]FieldChange
+
+// Value implements driver.Valuer interface
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements sql.Scanner interface
+func (c *AuditChanges) Scan(value interface{}) error {
+	if value == nil {
+		*c = AuditChanges{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// Auditable is implemented by entities whose changes are audited.
+// AuditFields returns the audited fields by their JSON name; values must be
+// comparable (strings, numbers, booleans or nil).
+type Auditable interface {
+	AuditFields() map[string]interface{}
+}
+
+// AuditFields implements Auditable
+func (t *Task) AuditFields() map[string]interface{} {
+	return map[string]interface{}{
+		"title":           t.Title,
+		"description":     t.Description,
+		"status":          t.Status,
+		"priority":        t.Priority,
+		"project_id":      auditInt(t.ProjectID),
+		"parent_id":       auditInt(t.ParentID),
+		"assignee_id":     auditInt(t.AssigneeID),
+		"due_date":        auditTime(t.DueDate),
+		"estimated_hours": t.EstimatedHours,
+		"actual_hours":    t.ActualHours,
+		"auto_complete":   t.AutoComplete,
+	}
+}
+
+// AuditFields implements Auditable
+func (p *Project) AuditFields() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        p.Name,
+		"description": p.Description,
+		"status":      p.Status,
+		"start_date":  auditString(p.StartDate),
+		"end_date":    auditString(p.EndDate),
+		"team_id":     auditInt(p.TeamID),
+	}
+}
+
+// AuditFields implements Auditable. The password hash is never audited.
+func (d *Developer) AuditFields() map[string]interface{} {
+	return map[string]interface{}{
+		"name":               d.Name,
+		"email":              d.Email,
+		"role":               d.Role,
+		"team_id":            auditInt(d.TeamID),
+		"avatar_url":         d.AvatarURL,
+		"status":             d.Status,
+		"email_verified":     d.EmailVerified,
+		"two_factor_enabled": d.TwoFactorEnabled,
+	}
+}
+
+// NewAuditEntry builds the audit entry of a mutation from the entity before
+// and after it. Pass a nil before for creations and a nil after for deletions.
+func NewAuditEntry(entityType string, entityID int, before, after Auditable) *AuditEntry {
+	action := AuditUpdate
+	var beforeFields, afterFields map[string]interface{}
+	if before == nil {
+		action = AuditCreate
+	} else {
+		beforeFields = before.AuditFields()
+	}
+	if after == nil {
+		action = AuditDelete
+	} else {
+		afterFields = after.AuditFields()
+	}
+
+	changes := AuditChanges{}
+	for field, value := range afterFields {
+		if old, ok := beforeFields[field]; ok && old == value {
+			continue
+		}
+		if action == AuditCreate && value == nil {
+			continue
+		}
+		changes[field] = FieldChange{Before: beforeFields[field], After: value}
+	}
+	for field, value := range beforeFields {
+		if _, ok := afterFields[field]; !ok && value != nil {
+			changes[field] = FieldChange{Before: value}
+		}
+	}
+
+	return &AuditEntry{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    changes,
+	}
+}
+
+func auditInt(v *int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func auditString(v *string) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func auditTime(v *time.Time) interface{} {
+	if v == nil {
+		return nil
+	}
+	return v.UTC().Format(time.RFC3339)
+}
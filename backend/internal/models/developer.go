@@ -6,24 +6,31 @@ import (
 
 // Developer represents a developer in the system
 type Developer struct {
-	ID           int       `json:"id"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`              // Never expose password hash
-	Role         string    `json:"role"`
-	TeamID       *int      `json:"team_id,omitempty"`
-	AvatarURL    string    `json:"avatar_url,omitempty"`
-	Status       string    `json:"status"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID               int       `json:"id"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	PasswordHash     string    `json:"-"` // Never expose password hash
+	Role             string    `json:"role"`
+	TeamID           *int      `json:"team_id,omitempty"`
+	AvatarURL        string    `json:"avatar_url,omitempty"`
+	Status           string    `json:"status"`
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
+// Global developer roles
+const (
+	RoleAdmin     = "admin"
+	RoleDeveloper = "developer"
+)
+
 // RegisterRequest represents the registration request body
 type RegisterRequest struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
-	Role     string `json:"role,omitempty"` // Optional, defaults to "developer"
 }
 
 // LoginRequest represents the login request body
@@ -47,9 +54,10 @@ type LoginResponseData struct {
 
 // TokenData contains token information
 type TokenData struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int64  `json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
 }
 
 // RefreshRequest represents a token refresh request
@@ -57,6 +65,22 @@ type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// ForgotPasswordRequest represents a password reset request
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest sets a new password using a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// VerifyEmailRequest confirms an email address using a verification token
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
 // MeResponse represents the current user response
 type MeResponse struct {
 	Success bool       `json:"success"`
@@ -107,18 +131,14 @@ func (r *LoginRequest) Validate() []string {
 	return errors
 }
 
-// ToDeveloper creates a Developer from RegisterRequest
+// ToDeveloper creates a Developer from RegisterRequest. Self-registered
+// developers always get the developer role; other roles come from invitations.
 func (r *RegisterRequest) ToDeveloper(passwordHash string) *Developer {
-	role := r.Role
-	if role == "" {
-		role = "developer"
-	}
-
 	return &Developer{
 		Name:         r.Name,
 		Email:        r.Email,
 		PasswordHash: passwordHash,
-		Role:         role,
+		Role:         RoleDeveloper,
 		Status:       "active",
 	}
 }
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Invitation invites someone to create an account with a given role and,
+// optionally, a project membership and team. Only the hash of the emailed
+// token is stored.
+type Invitation struct {
+	ID          int        `json:"id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	ProjectID   *int       `json:"project_id,omitempty"`
+	ProjectRole string     `json:"project_role,omitempty"`
+	TeamID      *int       `json:"team_id,omitempty"`
+	InvitedBy   *int       `json:"invited_by,omitempty"`
+	TokenHash   string     `json:"-"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	AcceptedBy  *int       `json:"accepted_by,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Invitation statuses
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// CurrentStatus derives the invitation's status from its timestamps
+func (i *Invitation) CurrentStatus() string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case time.Now().After(i.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
+
+// CreateInvitationRequest represents an invitation creation request
+type CreateInvitationRequest struct {
+	Email       string `json:"email"`
+	Role        string `json:"role,omitempty"`
+	ProjectID   *int   `json:"project_id,omitempty"`
+	ProjectRole string `json:"project_role,omitempty"`
+	TeamID      *int   `json:"team_id,omitempty"`
+}
+
+// Validate validates the create invitation request and fills in defaults
+func (r *CreateInvitationRequest) Validate() []string {
+	var errors []string
+
+	r.Email = strings.TrimSpace(r.Email)
+	if r.Email == "" {
+		errors = append(errors, "Email is required")
+	}
+
+	if r.Role == "" {
+		r.Role = RoleDeveloper
+	}
+	if r.Role != RoleAdmin && r.Role != RoleDeveloper {
+		errors = append(errors, "Invalid role. Must be one of: admin, developer")
+	}
+
+	if r.ProjectID == nil {
+		if r.ProjectRole != "" {
+			errors = append(errors, "Project role requires a project")
+		}
+	} else {
+		if r.ProjectRole == "" {
+			r.ProjectRole = ProjectRoleMember
+		}
+		validRoles := map[string]bool{ProjectRoleOwner: true, ProjectRoleMember: true, ProjectRoleViewer: true}
+		if !validRoles[r.ProjectRole] {
+			errors = append(errors, "Invalid project role. Must be one of: owner, member, viewer")
+		}
+	}
+
+	return errors
+}
+
+// AcceptInvitationRequest represents a request to accept an invitation and
+// create the account
+type AcceptInvitationRequest struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// Validate validates the accept invitation request
+func (r *AcceptInvitationRequest) Validate() []string {
+	var errors []string
+
+	if r.Token == "" {
+		errors = append(errors, "Token is required")
+	}
+	if len(r.Name) < 2 {
+		errors = append(errors, "Name must be at least 2 characters")
+	}
+	if r.Password == "" {
+		errors = append(errors, "Password is required")
+	}
+
+	return errors
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// OIDCLoginState is a login that was sent to the identity provider and has
+// not returned yet. It holds the secrets needed to complete the login.
+type OIDCLoginState struct {
+	StateHash    string
+	CodeVerifier string
+	Nonce        string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
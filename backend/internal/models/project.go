@@ -18,6 +18,13 @@ type Project struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// Project member roles, stored in project_members.role
+const (
+	ProjectRoleOwner  = "owner"
+	ProjectRoleMember = "member"
+	ProjectRoleViewer = "viewer"
+)
+
 // CreateProjectRequest represents a project creation request
 type CreateProjectRequest struct {
 	Name        string `json:"name"`
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// ProjectMember represents a developer's membership in a project
+type ProjectMember struct {
+	ProjectID   int        `json:"project_id"`
+	DeveloperID int        `json:"developer_id"`
+	Role        string     `json:"role"`
+	Developer   *Developer `json:"developer,omitempty"`
+	JoinedAt    time.Time  `json:"joined_at"`
+}
+
+// AddProjectMemberRequest represents a request to add or update a project member
+type AddProjectMemberRequest struct {
+	DeveloperID int    `json:"developer_id"`
+	Role        string `json:"role,omitempty"`
+}
+
+// Validate validates the add project member request
+func (r *AddProjectMemberRequest) Validate() []string {
+	var errors []string
+
+	if r.DeveloperID <= 0 {
+		errors = append(errors, "Developer ID is required")
+	}
+
+	// Set default role
+	if r.Role == "" {
+		r.Role = ProjectRoleMember
+	}
+
+	// Validate role
+	validRoles := map[string]bool{ProjectRoleOwner: true, ProjectRoleMember: true, ProjectRoleViewer: true}
+	if !validRoles[r.Role] {
+		errors = append(errors, "Invalid role. Must be one of: owner, member, viewer")
+	}
+
+	return errors
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// SavedView is a named task list: a query in the syntax of GET /tasks and
+// the columns to show. A view belongs to the developer who saved it and,
+// when it has a project, is shared with that project's members.
+type SavedView struct {
+	ID          int         `json:"id"`
+	DeveloperID int         `json:"developer_id"`
+	ProjectID   *int        `json:"project_id,omitempty"`
+	Name        string      `json:"name"`
+	Query       string      `json:"query"`
+	Columns     ViewColumns `json:"columns"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// TaskColumns lists the task list columns a view can show
+var TaskColumns = []string{
+	"title", "status", "priority", "assignee", "project", "due_date",
+	"estimated_hours", "actual_hours", "created_at", "updated_at",
+}
+
+// ViewColumns are the columns shown by a view, in order
+type ViewColumns []string
+
+// Value implements driver.Valuer interface
+func (c ViewColumns) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements sql.Scanner interface
+func (c *ViewColumns) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		*c = ViewColumns{}
+		return nil
+	}
+}
+
+// SavedViewRequest creates a view or replaces one. Views without a project
+// are personal.
+type SavedViewRequest struct {
+	Name      string   `json:"name"`
+	Query     string   `json:"query"`
+	Columns   []string `json:"columns"`
+	ProjectID *int     `json:"project_id,omitempty"`
+}
+
+// Validate validates the saved view request
+func (r *SavedViewRequest) Validate() []string {
+	var errors []string
+
+	if r.Name == "" {
+		errors = append(errors, "Name is required")
+	}
+	if len(r.Name) > 100 {
+		errors = append(errors, "Name must be at most 100 characters")
+	}
+	if len(r.Query) > 1000 {
+		errors = append(errors, "Query must be at most 1000 characters")
+	}
+
+	validColumns := map[string]bool{}
+	for _, column := range TaskColumns {
+		validColumns[column] = true
+	}
+	seen := map[string]bool{}
+	for _, column := range r.Columns {
+		if !validColumns[column] {
+			errors = append(errors, "Invalid column: "+column)
+		} else if seen[column] {
+			errors = append(errors, "Duplicate column: "+column)
+		}
+		seen[column] = true
+	}
+
+	return errors
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Search result types
+const (
+	SearchTypeTask     = "task"
+	SearchTypeProject  = "project"
+	SearchTypeActivity = "activity"
+)
+
+// SearchTypes lists the types of search results
+var SearchTypes = []string{SearchTypeTask, SearchTypeProject, SearchTypeActivity}
+
+// SearchResult is a task, project or activity matching a search
+type SearchResult struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+	// Title is the task title, project name or activity action
+	Title string `json:"title"`
+	// Snippet is an HTML excerpt of the matching text: the text is escaped
+	// and the matched words are wrapped in <mark> elements
+	Snippet string `json:"snippet"`
+	// Rank orders the results, best first; it only compares results of the
+	// same search
+	Rank      float64   `json:"rank"`
+	ProjectID *int      `json:"project_id,omitempty"`
+	TaskID    *int      `json:"task_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
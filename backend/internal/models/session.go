@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// Session represents a login session. Every refresh token issued for the
+// login belongs to the session, so revoking it invalidates the whole family.
+type Session struct {
+	ID            string     `json:"id"`
+	DeveloperID   int        `json:"developer_id"`
+	UserAgent     string     `json:"user_agent"`
+	IPAddress     string     `json:"ip_address"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
+	Device        string     `json:"device"`
+	Current       bool       `json:"current"`
+}
+
+// IsActive reports whether the session is neither revoked nor expired
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// RefreshToken represents a stored (hashed) refresh token
+type RefreshToken struct {
+	ID        int        `json:"id"`
+	SessionID string     `json:"session_id"`
+	TokenHash string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+}
+
+// Session revocation reasons
+const (
+	RevokeReasonLogout        = "logout"
+	RevokeReasonLogoutAll     = "logout_all"
+	RevokeReasonUserRevoked   = "revoked_by_user"
+	RevokeReasonAdminForced   = "admin_force_logout"
+	RevokeReasonTokenReuse    = "refresh_token_reuse"
+	RevokeReasonPasswordReset = "password_reset"
+)
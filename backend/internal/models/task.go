@@ -6,19 +6,35 @@ import (
 
 // Task represents a task in the system
 type Task struct {
-	ID             int        `json:"id"`
-	Title          string     `json:"title"`
-	Description    string     `json:"description,omitempty"`
-	Status         string     `json:"status"`
-	Priority       string     `json:"priority"`
-	ProjectID      *int       `json:"project_id,omitempty"`
-	AssigneeID     *int       `json:"assignee_id,omitempty"`
-	Assignee       *Developer `json:"assignee,omitempty"`
-	DueDate        *time.Time `json:"due_date,omitempty"`
-	EstimatedHours float64    `json:"estimated_hours,omitempty"`
-	ActualHours    float64    `json:"actual_hours,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID             int         `json:"id"`
+	Title          string      `json:"title"`
+	Description    string      `json:"description,omitempty"`
+	Status         string      `json:"status"`
+	Priority       string      `json:"priority"`
+	ProjectID      *int        `json:"project_id,omitempty"`
+	ParentID       *int        `json:"parent_id,omitempty"`
+	AssigneeID     *int        `json:"assignee_id,omitempty"`
+	Assignee       *Developer  `json:"assignee,omitempty"`
+	DueDate        *time.Time  `json:"due_date,omitempty"`
+	EstimatedHours float64     `json:"estimated_hours,omitempty"`
+	ActualHours    float64     `json:"actual_hours,omitempty"`
+	AutoComplete   bool        `json:"auto_complete,omitempty"`
+	CreatedBy      *int        `json:"created_by,omitempty"`
+	Rollup         *TaskRollup `json:"rollup,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// TaskRollup sums up the subtasks of a task, at every depth
+type TaskRollup struct {
+	Subtasks int `json:"subtasks"`
+	Done     int `json:"done"`
+	// Progress is the percentage of subtasks done
+	Progress int `json:"progress"`
+	// EstimatedHours and ActualHours add the hours of the subtasks to those
+	// of the task itself
+	EstimatedHours float64 `json:"estimated_hours"`
+	ActualHours    float64 `json:"actual_hours"`
 }
 
 // CreateTaskRequest represents a task creation request
@@ -28,9 +44,11 @@ type CreateTaskRequest struct {
 	Status         string     `json:"status,omitempty"`
 	Priority       string     `json:"priority,omitempty"`
 	ProjectID      *int       `json:"project_id,omitempty"`
+	ParentID       *int       `json:"parent_id,omitempty"`
 	AssigneeID     *int       `json:"assignee_id,omitempty"`
 	DueDate        *time.Time `json:"due_date,omitempty"`
 	EstimatedHours float64    `json:"estimated_hours,omitempty"`
+	AutoComplete   bool       `json:"auto_complete,omitempty"`
 }
 
 // UpdateTaskRequest represents a task update request
@@ -44,6 +62,17 @@ type UpdateTaskRequest struct {
 	DueDate        *time.Time `json:"due_date,omitempty"`
 	EstimatedHours float64    `json:"estimated_hours,omitempty"`
 	ActualHours    float64    `json:"actual_hours,omitempty"`
+	AutoComplete   *bool      `json:"auto_complete,omitempty"`
+	// Force starts or completes a task that open tasks still block
+	Force bool `json:"force,omitempty"`
+}
+
+// MoveTaskRequest moves a task and its subtasks under another parent, or to
+// the top level when ParentID is nil. Subtasks are in their parent's
+// project; top-level tasks move to ProjectID when it is set.
+type MoveTaskRequest struct {
+	ParentID  *int `json:"parent_id"`
+	ProjectID *int `json:"project_id,omitempty"`
 }
 
 // TaskListResponse represents a list of tasks with pagination
@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// Task link types. A link reads "source <type> target"; blocked_by is only
+// accepted in requests and stored as the inverse blocks link.
+const (
+	LinkBlocks     = "blocks"
+	LinkBlockedBy  = "blocked_by"
+	LinkRelatesTo  = "relates_to"
+	LinkDuplicates = "duplicates"
+)
+
+// TaskLink relates two tasks
+type TaskLink struct {
+	ID        int       `json:"id"`
+	SourceID  int       `json:"source_id"`
+	TargetID  int       `json:"target_id"`
+	Type      string    `json:"type"`
+	CreatedBy *int      `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateTaskLinkRequest links the task of the URL to TaskID, e.g. type
+// blocked_by with task_id 7 for "this task is blocked by task 7"
+type CreateTaskLinkRequest struct {
+	Type   string `json:"type"`
+	TaskID int    `json:"task_id"`
+}
+
+// Validate validates the task link request
+func (r *CreateTaskLinkRequest) Validate() []string {
+	var errors []string
+
+	validTypes := map[string]bool{LinkBlocks: true, LinkBlockedBy: true, LinkRelatesTo: true, LinkDuplicates: true}
+	if !validTypes[r.Type] {
+		errors = append(errors, "Invalid type. Must be one of: blocks, blocked_by, relates_to, duplicates")
+	}
+	if r.TaskID <= 0 {
+		errors = append(errors, "Task ID is required")
+	}
+
+	return errors
+}
+
+// Link returns the link the request asks for from task id, in its stored
+// direction
+func (r *CreateTaskLinkRequest) Link(id int) *TaskLink {
+	link := &TaskLink{SourceID: id, TargetID: r.TaskID, Type: r.Type}
+	switch {
+	case r.Type == LinkBlockedBy:
+		link.SourceID, link.TargetID, link.Type = r.TaskID, id, LinkBlocks
+	case r.Type == LinkRelatesTo && r.TaskID < id:
+		link.SourceID, link.TargetID = r.TaskID, id
+	}
+	return link
+}
+
+// DependencyGraph is the graph of a project's tasks and the links between
+// them, scheduled along the blocks links
+type DependencyGraph struct {
+	Nodes []*DependencyNode `json:"nodes"`
+	Edges []*TaskLink       `json:"edges"`
+	// CriticalPath lists the open tasks, in order, of the chain of blockers
+	// with the least slack
+	CriticalPath []int `json:"critical_path"`
+	// DurationHours is the estimated work along the critical path
+	DurationHours float64 `json:"duration_hours"`
+}
+
+// DependencyNode is a task of a dependency graph and its schedule. Hours
+// count from the time the graph was computed, with the estimates of open
+// tasks laid end to end; done tasks take no time and have no slack.
+type DependencyNode struct {
+	TaskID         int        `json:"task_id"`
+	Title          string     `json:"title"`
+	Status         string     `json:"status"`
+	DueDate        *time.Time `json:"due_date,omitempty"`
+	EstimatedHours float64    `json:"estimated_hours"`
+	EarliestStart  float64    `json:"earliest_start"`
+	EarliestFinish float64    `json:"earliest_finish"`
+	// Slack is how many hours the task can slip without delaying a due date
+	// or the end of the project; negative when a due date cannot be met
+	Slack    *float64 `json:"slack,omitempty"`
+	Critical bool     `json:"critical"`
+}
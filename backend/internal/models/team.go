@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// Team represents a team of developers
+type Team struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateTeamRequest represents a team creation request
+type CreateTeamRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// UpdateTeamRequest represents a team update request
+type UpdateTeamRequest struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// AddTeamMemberRequest represents a request to add a developer to a team
+type AddTeamMemberRequest struct {
+	DeveloperID int `json:"developer_id"`
+}
+
+// TeamTaskStats holds task counts by status for a team's projects
+type TeamTaskStats struct {
+	TeamID       int            `json:"team_id"`
+	TeamName     string         `json:"team_name"`
+	StatusCounts map[string]int `json:"status_counts"`
+	Total        int            `json:"total"`
+}
+
+// Validate validates the create team request
+func (r *CreateTeamRequest) Validate() []string {
+	var errors []string
+
+	if r.Name == "" {
+		errors = append(errors, "Name is required")
+	}
+	if len(r.Name) < 2 {
+		errors = append(errors, "Name must be at least 2 characters")
+	}
+
+	return errors
+}
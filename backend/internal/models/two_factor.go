@@ -0,0 +1,41 @@
+package models
+
+// TwoFactorSetup is returned when enrolling an authenticator app
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest carries a TOTP or recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TwoFactorChallengeRequest carries the challenge token returned by login
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+// TwoFactorLoginRequest completes a login with a second factor
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+// TwoFactorSettings lists the roles whose members must use two-factor authentication
+type TwoFactorSettings struct {
+	RequiredRoles []string `json:"required_roles"`
+}
+
+// Validate validates the two-factor settings
+func (s *TwoFactorSettings) Validate() []string {
+	var errors []string
+
+	for _, role := range s.RequiredRoles {
+		if role != RoleAdmin && role != RoleDeveloper {
+			errors = append(errors, "Unknown role: "+role)
+		}
+	}
+
+	return errors
+}
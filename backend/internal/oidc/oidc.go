@@ -0,0 +1,230 @@
+// Package oidc implements the OpenID Connect authorization code flow with
+// PKCE against a company identity provider.
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// ErrNotConfigured is returned when no identity provider is configured
+var ErrNotConfigured = errors.New("OIDC is not configured")
+
+// Config configures the identity provider client
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Enabled reports whether an identity provider is configured
+func (c Config) Enabled() bool {
+	return c.IssuerURL != "" && c.ClientID != ""
+}
+
+// Identity is the verified identity of a developer returned by the provider
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+
+	// Claims holds every claim of the ID token, for role mapping
+	Claims map[string]interface{}
+}
+
+// Provider talks to an OpenID Connect identity provider. Discovery happens
+// on first use, so the server can start while the provider is unreachable.
+type Provider struct {
+	cfg Config
+
+	mu       sync.Mutex
+	provider *gooidc.Provider
+	oauth    *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+// NewProvider creates a new provider client
+func NewProvider(cfg Config) *Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{gooidc.ScopeOpenID, "email", "profile"}
+	}
+	return &Provider{cfg: cfg}
+}
+
+// Enabled reports whether an identity provider is configured
+func (p *Provider) Enabled() bool {
+	return p.cfg.Enabled()
+}
+
+// AuthCodeURL returns the provider's login URL. The PKCE verifier and the
+// nonce must be kept to complete the login.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	oauth, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return oauth.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange redeems an authorization code and verifies the returned ID token
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	oauth, idVerifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response did not include an ID token")
+	}
+
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify ID token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse ID token claims: %w", err)
+	}
+
+	identity := &Identity{
+		Issuer:  idToken.Issuer,
+		Subject: idToken.Subject,
+		Claims:  claims,
+	}
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	return identity, nil
+}
+
+// discover fetches the provider metadata once and caches it
+func (p *Provider) discover(ctx context.Context) (*oauth2.Config, *gooidc.IDTokenVerifier, error) {
+	if !p.cfg.Enabled() {
+		return nil, nil, ErrNotConfigured
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider == nil {
+		provider, err := gooidc.NewProvider(ctx, p.cfg.IssuerURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+		}
+
+		p.provider = provider
+		p.oauth = &oauth2.Config{
+			ClientID:     p.cfg.ClientID,
+			ClientSecret: p.cfg.ClientSecret,
+			RedirectURL:  p.cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       p.cfg.Scopes,
+		}
+		p.verifier = provider.Verifier(&gooidc.Config{ClientID: p.cfg.ClientID})
+	}
+
+	return p.oauth, p.verifier, nil
+}
+
+// RoleMapping maps values of an ID token claim, such as group names, to roles
+type RoleMapping struct {
+	Claim       string
+	Roles       map[string]string
+	DefaultRole string
+}
+
+// ParseRoleMapping parses a mapping such as "platform-admins=admin,engineering=developer".
+// Every role, including the default role, must be admin or developer.
+func ParseRoleMapping(claim, mapping, defaultRole string) (*RoleMapping, error) {
+	m := &RoleMapping{
+		Claim:       claim,
+		Roles:       make(map[string]string),
+		DefaultRole: defaultRole,
+	}
+	if defaultRole != models.RoleAdmin && defaultRole != models.RoleDeveloper {
+		return nil, fmt.Errorf("invalid default role %q, expected %s or %s", defaultRole, models.RoleAdmin, models.RoleDeveloper)
+	}
+
+	for _, pair := range strings.Split(mapping, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		value, role, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(value) == "" || strings.TrimSpace(role) == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected value=role", pair)
+		}
+		role = strings.TrimSpace(role)
+		if role != models.RoleAdmin && role != models.RoleDeveloper {
+			return nil, fmt.Errorf("invalid role %q in role mapping %q, expected %s or %s", role, pair, models.RoleAdmin, models.RoleDeveloper)
+		}
+		m.Roles[strings.TrimSpace(value)] = role
+	}
+
+	return m, nil
+}
+
+// Role returns the role for a set of claims. When several claim values
+// match, admin wins. Without a match the default role is returned.
+func (m *RoleMapping) Role(claims map[string]interface{}) string {
+	role := m.DefaultRole
+
+	for _, value := range claimValues(claims[m.Claim]) {
+		mapped, ok := m.Roles[value]
+		if !ok {
+			continue
+		}
+		if mapped == models.RoleAdmin {
+			return mapped
+		}
+		role = mapped
+	}
+
+	return role
+}
+
+// claimValues returns a claim that is either a string or a list of strings
+func claimValues(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package oidc
+
+import "testing"
+
+func TestParseRoleMapping(t *testing.T) {
+	tests := []struct {
+		name        string
+		mapping     string
+		defaultRole string
+		want        map[string]string
+		wantErr     bool
+	}{
+		{name: "empty", defaultRole: "developer", want: map[string]string{}},
+		{
+			name:        "pairs",
+			mapping:     " platform-admins = admin ,engineering=developer,",
+			defaultRole: "developer",
+			want:        map[string]string{"platform-admins": "admin", "engineering": "developer"},
+		},
+		{name: "missing role", mapping: "engineering", defaultRole: "developer", wantErr: true},
+		{name: "missing value", mapping: "=admin", defaultRole: "developer", wantErr: true},
+		{name: "unknown role", mapping: "engineering=admins", defaultRole: "developer", wantErr: true},
+		{name: "role is case sensitive", mapping: "engineering=Admin", defaultRole: "developer", wantErr: true},
+		{name: "unknown default role", defaultRole: "viewer", wantErr: true},
+		{name: "no default role", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseRoleMapping("groups", tt.mapping, tt.defaultRole)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.Roles) != len(tt.want) {
+				t.Fatalf("got roles %v, want %v", m.Roles, tt.want)
+			}
+			for value, role := range tt.want {
+				if m.Roles[value] != role {
+					t.Fatalf("got roles %v, want %v", m.Roles, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRoleMappingRole(t *testing.T) {
+	m, err := ParseRoleMapping("groups", "platform-admins=admin,engineering=developer", "developer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		want   string
+	}{
+		{name: "no claim", claims: map[string]interface{}{}, want: "developer"},
+		{name: "string claim", claims: map[string]interface{}{"groups": "platform-admins"}, want: "admin"},
+		{name: "admin wins", claims: map[string]interface{}{"groups": []interface{}{"engineering", "platform-admins"}}, want: "admin"},
+		{name: "unmapped value", claims: map[string]interface{}{"groups": []interface{}{"sales"}}, want: "developer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Role(tt.claims); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Package pagination implements cursor (keyset) pagination of lists ordered
+// newest first by creation time and ID. Unlike offsets, cursors neither skip
+// nor repeat rows when rows are added or removed between two pages, and a
+// page costs the same however deep it is.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Page sizes
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued by the API
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in a list: the creation time and ID of a row. A page
+// after a cursor holds the rows that follow it, older ones unless the list
+// is sorted otherwise; a backward page holds the rows that precede it.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+	// Sort and Values are set in lists that can be sorted by other keys:
+	// the sort order and the row's sort keys. Values come back from Decode
+	// as JSON values, e.g. times as strings.
+	Sort     string
+	Values   []interface{}
+	Backward bool
+}
+
+// cursorJSON is the encoded form of a cursor
+type cursorJSON struct {
+	CreatedAt time.Time     `json:"t"`
+	ID        int64         `json:"i"`
+	Sort      string        `json:"s,omitempty"`
+	Values    []interface{} `json:"v,omitempty"`
+	Backward  bool          `json:"b,omitempty"`
+}
+
+// Encode returns the opaque form of the cursor used in URLs
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(cursorJSON{
+		CreatedAt: c.CreatedAt.UTC(),
+		ID:        c.ID,
+		Sort:      c.Sort,
+		Values:    c.Values,
+		Backward:  c.Backward,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a cursor returned by Encode
+func Decode(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursorJSON
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 || c.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{CreatedAt: c.CreatedAt, ID: c.ID, Sort: c.Sort, Values: c.Values, Backward: c.Backward}, nil
+}
+
+// Params selects a page of a list
+type Params struct {
+	// Limit is the page size, at most MaxLimit
+	Limit int
+	// Cursor is where the page starts; nil for the first page
+	Cursor *Cursor
+	// WithTotal asks for the number of rows in the whole list, which costs
+	// a count query
+	WithTotal bool
+}
+
+// Parse reads the limit, cursor and include_total query parameters. A
+// missing or invalid limit falls back to defaultLimit and limits above
+// MaxLimit are lowered to it.
+func Parse(query url.Values, defaultLimit int) (Params, error) {
+	p := Params{Limit: defaultLimit}
+	if l := query.Get("limit"); l != "" {
+		if val, err := strconv.Atoi(l); err == nil && val > 0 {
+			p.Limit = val
+		}
+	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+
+	if c := query.Get("cursor"); c != "" {
+		cursor, err := Decode(c)
+		if err != nil {
+			return p, err
+		}
+		p.Cursor = cursor
+	}
+
+	p.WithTotal, _ = strconv.ParseBool(query.Get("include_total"))
+	return p, nil
+}
+
+// Backward reports whether the page precedes its cursor
+func (p Params) Backward() bool {
+	return p.Cursor != nil && p.Cursor.Backward
+}
+
+// Page describes where a page sits in its list
+type Page struct {
+	// Next and Prev point at the neighbouring pages; nil at either end
+	Next *Cursor
+	Prev *Cursor
+	// Total is the number of rows in the list, when asked for
+	Total *int
+}
+
+// Build turns the rows fetched for p into a page. The query must fetch up to
+// p.Limit+1 rows nearest to the cursor first: in list order, or reversed for
+// a backward page. key returns the cursor pointing at a row.
+func Build[T any](p Params, rows []T, key func(T) Cursor) ([]T, *Page) {
+	more := len(rows) > p.Limit
+	if more {
+		rows = rows[:p.Limit]
+	}
+
+	backward := p.Backward()
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	at := func(row T, backward bool) *Cursor {
+		c := key(row)
+		c.Backward = backward
+		return &c
+	}
+	// An empty page past a cursor still links back to where it came from
+	turn := func() *Cursor {
+		c := *p.Cursor
+		c.Backward = !c.Backward
+		return &c
+	}
+
+	page := &Page{}
+	switch {
+	case backward:
+		if more {
+			page.Prev = at(rows[0], true)
+		}
+		if len(rows) > 0 {
+			page.Next = at(rows[len(rows)-1], false)
+		} else {
+			page.Next = turn()
+		}
+	default:
+		if more {
+			page.Next = at(rows[len(rows)-1], false)
+		}
+		if p.Cursor != nil {
+			if len(rows) > 0 {
+				page.Prev = at(rows[0], true)
+			} else {
+				page.Prev = turn()
+			}
+		}
+	}
+
+	return rows, page
+}
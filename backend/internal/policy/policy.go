@@ -0,0 +1,314 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ardani17/taskmanager/internal/middleware"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/repository"
+)
+
+// Machine-readable reasons returned with 403 responses
+const (
+	ReasonAdminRequired         = "admin_required"
+	ReasonNotSelf               = "not_self"
+	ReasonCannotDeleteSelf      = "cannot_delete_self"
+	ReasonProjectOwnerRequired  = "project_owner_required"
+	ReasonProjectMemberRequired = "project_member_required"
+	ReasonReadOnlyMember        = "read_only_member"
+	ReasonViewOwnerRequired     = "view_owner_required"
+)
+
+// DeniedError is returned when the actor is not allowed to perform an action
+type DeniedError struct {
+	Reason  string
+	Message string
+}
+
+func (e *DeniedError) Error() string {
+	return e.Message
+}
+
+// IsDenied reports whether err is a policy denial and returns it
+func IsDenied(err error) (*DeniedError, bool) {
+	var denied *DeniedError
+	if errors.As(err, &denied) {
+		return denied, true
+	}
+	return nil, false
+}
+
+func deny(reason, message string) error {
+	return &DeniedError{Reason: reason, Message: message}
+}
+
+// Actor is the authenticated developer performing a request
+type Actor struct {
+	ID   int
+	Role string
+}
+
+// IsAdmin reports whether the actor holds the global admin role
+func (a Actor) IsAdmin() bool {
+	return a.Role == models.RoleAdmin
+}
+
+// ActorFromRequest builds the actor from the auth middleware context
+func ActorFromRequest(r *http.Request) Actor {
+	return Actor{
+		ID:   middleware.GetUserID(r),
+		Role: middleware.GetUserRole(r),
+	}
+}
+
+// Policy decides what an actor may do with users, projects and tasks.
+//
+// Global admins may do everything. Within a project, owners manage the
+// project and its tasks, members work on tasks, and viewers are read-only.
+type Policy struct {
+	members *repository.ProjectMemberRepository
+}
+
+// NewPolicy creates a new policy
+func NewPolicy(members *repository.ProjectMemberRepository) *Policy {
+	return &Policy{members: members}
+}
+
+// ProjectRole returns the actor's role in a project, or "" if not a member
+func (p *Policy) ProjectRole(ctx context.Context, actor Actor, projectID int) (string, error) {
+	role, err := p.members.GetRole(ctx, projectID, actor.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project role: %w", err)
+	}
+	return role, nil
+}
+
+// CanManageUser checks whether the actor may update a user's profile or status
+func (p *Policy) CanManageUser(actor Actor, userID int) error {
+	if actor.IsAdmin() || actor.ID == userID {
+		return nil
+	}
+	return deny(ReasonNotSelf, "You can only modify your own account")
+}
+
+// CanDeleteUser checks whether the actor may delete a user
+func (p *Policy) CanDeleteUser(actor Actor, userID int) error {
+	if !actor.IsAdmin() {
+		return deny(ReasonAdminRequired, "Only admins can delete users")
+	}
+	if actor.ID == userID {
+		return deny(ReasonCannotDeleteSelf, "Admins cannot delete their own account")
+	}
+	return nil
+}
+
+// VisibilityScope returns the developer ID that project and task listings
+// are restricted to, or 0 when the actor may see everything
+func (p *Policy) VisibilityScope(actor Actor) int {
+	if actor.IsAdmin() {
+		return 0
+	}
+	return actor.ID
+}
+
+// CanViewProject checks whether the actor may see a project and its members
+func (p *Policy) CanViewProject(ctx context.Context, actor Actor, projectID int) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+
+	role, err := p.ProjectRole(ctx, actor, projectID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return deny(ReasonProjectMemberRequired, "You are not a member of this project")
+	}
+	return nil
+}
+
+// CanViewTask checks whether the actor may see a task
+func (p *Policy) CanViewTask(ctx context.Context, actor Actor, task *models.Task) error {
+	if actor.IsAdmin() || isAssignee(actor, task) || task.ProjectID == nil {
+		return nil
+	}
+	return p.CanViewProject(ctx, actor, *task.ProjectID)
+}
+
+// CanRemoveProjectMember checks whether the actor may remove a member.
+// Members may always leave a project themselves.
+func (p *Policy) CanRemoveProjectMember(ctx context.Context, actor Actor, projectID, developerID int) error {
+	if actor.ID == developerID {
+		return nil
+	}
+	return p.CanManageProject(ctx, actor, projectID)
+}
+
+// CanManageProject checks whether the actor may update or delete a project
+func (p *Policy) CanManageProject(ctx context.Context, actor Actor, projectID int) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+
+	role, err := p.ProjectRole(ctx, actor, projectID)
+	if err != nil {
+		return err
+	}
+	if role != models.ProjectRoleOwner {
+		return deny(ReasonProjectOwnerRequired, "Only project owners can manage this project")
+	}
+	return nil
+}
+
+// CanInvite checks whether the actor may send an invitation. Admins may invite
+// anyone; project owners may invite developers to the projects they own.
+func (p *Policy) CanInvite(ctx context.Context, actor Actor, req *models.CreateInvitationRequest) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+	if req.Role != models.RoleDeveloper {
+		return deny(ReasonAdminRequired, "Only admins can invite admins")
+	}
+	if req.TeamID != nil {
+		return deny(ReasonAdminRequired, "Only admins can invite people into a team")
+	}
+	if req.ProjectID == nil {
+		return deny(ReasonProjectOwnerRequired, "Only admins can invite people without a project")
+	}
+	return p.CanManageProject(ctx, actor, *req.ProjectID)
+}
+
+// CanRevokeInvitation checks whether the actor may revoke an invitation
+func (p *Policy) CanRevokeInvitation(actor Actor, invitation *models.Invitation) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+	if invitation.InvitedBy != nil && *invitation.InvitedBy == actor.ID {
+		return nil
+	}
+	return deny(ReasonAdminRequired, "Only the inviter or an admin can revoke this invitation")
+}
+
+// CanCreateTask checks whether the actor may create a task in a project.
+// Tasks without a project can be created by anyone.
+func (p *Policy) CanCreateTask(ctx context.Context, actor Actor, projectID *int) error {
+	if actor.IsAdmin() || projectID == nil {
+		return nil
+	}
+	return p.requireContributor(ctx, actor, *projectID)
+}
+
+// CanEditTask checks whether the actor may update a task or change its
+// status. Project tasks need an owner or member of the project, even when
+// assigned to the actor; tasks without a project need their assignee or
+// creator.
+func (p *Policy) CanEditTask(ctx context.Context, actor Actor, task *models.Task) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+	if task.ProjectID == nil {
+		if isAssignee(actor, task) || (task.CreatedBy != nil && *task.CreatedBy == actor.ID) {
+			return nil
+		}
+		return deny(ReasonAdminRequired, "Only the assignee, the creator or an admin can edit this task")
+	}
+	return p.requireContributor(ctx, actor, *task.ProjectID)
+}
+
+// CanDeleteTask checks whether the actor may delete a task. Project tasks
+// need the project owner; tasks without a project need their assignee.
+func (p *Policy) CanDeleteTask(ctx context.Context, actor Actor, task *models.Task) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+	if task.ProjectID == nil {
+		if isAssignee(actor, task) {
+			return nil
+		}
+		return deny(ReasonAdminRequired, "Only the assignee or an admin can delete this task")
+	}
+
+	role, err := p.ProjectRole(ctx, actor, *task.ProjectID)
+	if err != nil {
+		return err
+	}
+	if role != models.ProjectRoleOwner {
+		return deny(ReasonProjectOwnerRequired, "Only project owners can delete project tasks")
+	}
+	return nil
+}
+
+// CanViewSavedView checks whether the actor may see and run a saved view.
+// Personal views are private; shared views are visible to project members.
+func (p *Policy) CanViewSavedView(ctx context.Context, actor Actor, view *models.SavedView) error {
+	if actor.IsAdmin() || view.DeveloperID == actor.ID {
+		return nil
+	}
+	if view.ProjectID == nil {
+		return deny(ReasonViewOwnerRequired, "This view is private")
+	}
+	return p.CanViewProject(ctx, actor, *view.ProjectID)
+}
+
+// CanShareSavedView checks whether the actor may share a view with a project
+func (p *Policy) CanShareSavedView(ctx context.Context, actor Actor, projectID int) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+
+	role, err := p.ProjectRole(ctx, actor, projectID)
+	if err != nil {
+		return err
+	}
+
+	switch role {
+	case models.ProjectRoleOwner, models.ProjectRoleMember:
+		return nil
+	case models.ProjectRoleViewer:
+		return deny(ReasonReadOnlyMember, "Project viewers cannot share views")
+	default:
+		return deny(ReasonProjectMemberRequired, "You are not a member of this project")
+	}
+}
+
+// CanManageSavedView checks whether the actor may update or delete a saved
+// view. Project owners may also manage the views shared with their project.
+func (p *Policy) CanManageSavedView(ctx context.Context, actor Actor, view *models.SavedView) error {
+	if actor.IsAdmin() || view.DeveloperID == actor.ID {
+		return nil
+	}
+	if view.ProjectID != nil {
+		role, err := p.ProjectRole(ctx, actor, *view.ProjectID)
+		if err != nil {
+			return err
+		}
+		if role == models.ProjectRoleOwner {
+			return nil
+		}
+	}
+	return deny(ReasonViewOwnerRequired, "Only the view's owner can modify this view")
+}
+
+// requireContributor allows project owners and members, but not viewers
+func (p *Policy) requireContributor(ctx context.Context, actor Actor, projectID int) error {
+	role, err := p.ProjectRole(ctx, actor, projectID)
+	if err != nil {
+		return err
+	}
+
+	switch role {
+	case models.ProjectRoleOwner, models.ProjectRoleMember:
+		return nil
+	case models.ProjectRoleViewer:
+		return deny(ReasonReadOnlyMember, "Project viewers cannot modify tasks")
+	default:
+		return deny(ReasonProjectMemberRequired, "You are not a member of this project")
+	}
+}
+
+func isAssignee(actor Actor, task *models.Task) bool {
+	return task.AssigneeID != nil && *task.AssigneeID == actor.ID
+}
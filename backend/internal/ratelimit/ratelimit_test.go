@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "", want: Limit{}},
+		{in: "0", want: Limit{}},
+		{in: "10/m", want: Limit{Requests: 10, Per: time.Minute}},
+		{in: " 300/h ", want: Limit{Requests: 300, Per: time.Hour}},
+		{in: "5/s", want: Limit{Requests: 5, Per: time.Second}},
+		{in: "50/10s", want: Limit{Requests: 50, Per: 10 * time.Second}},
+		{in: "10", wantErr: true},
+		{in: "ten/m", wantErr: true},
+		{in: "-1/m", wantErr: true},
+		{in: "10/fortnight", wantErr: true},
+		{in: "10/-5s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitString(t *testing.T) {
+	if got := (Limit{Requests: 10, Per: time.Minute}).String(); got != "10/1m0s" {
+		t.Fatalf("got %q", got)
+	}
+	if got := (Limit{}).String(); got != "0" {
+		t.Fatalf("got %q for no limit", got)
+	}
+	if (Limit{Requests: 10}).Enabled() {
+		t.Fatal("a limit without a period is enabled")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Requests: 3, Per: time.Hour}
+
+	// A burst of up to Requests is allowed
+	for i := 2; i >= 0; i-- {
+		res, err := store.Take(ctx, "alice", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Remaining != i || res.RetryAfter != 0 {
+			t.Fatalf("request %d: %+v", 3-i, res)
+		}
+	}
+
+	// Then requests wait for the bucket to refill, a token every 20 minutes
+	res, err := store.Take(ctx, "alice", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("request over the limit: %+v", res)
+	}
+	if res.RetryAfter <= 19*time.Minute || res.RetryAfter > 20*time.Minute {
+		t.Fatalf("retry after %v, want about 20m", res.RetryAfter)
+	}
+	if res.Reset <= 59*time.Minute || res.Reset > time.Hour {
+		t.Fatalf("reset after %v, want about 1h", res.Reset)
+	}
+
+	// Buckets are per key
+	res, err = store.Take(ctx, "bob", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("other key: %+v", res)
+	}
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Requests: 1, Per: 50 * time.Millisecond}
+
+	if res, _ := store.Take(ctx, "alice", limit); !res.Allowed {
+		t.Fatalf("first request: %+v", res)
+	}
+	if res, _ := store.Take(ctx, "alice", limit); res.Allowed {
+		t.Fatalf("second request: %+v", res)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if res, _ := store.Take(ctx, "alice", limit); !res.Allowed {
+		t.Fatalf("request after refilling: %+v", res)
+	}
+}
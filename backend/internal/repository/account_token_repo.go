@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+// AccountTokenRepository handles database operations for account tokens
+type AccountTokenRepository struct {
+	db *DB
+}
+
+// NewAccountTokenRepository creates a new account token repository
+func NewAccountTokenRepository(db *DB) *AccountTokenRepository {
+	return &AccountTokenRepository{db: db}
+}
+
+// Create stores a new account token hash
+func (r *AccountTokenRepository) Create(ctx context.Context, token *models.AccountToken) error {
+	query := `
+		INSERT INTO account_tokens (developer_id, purpose, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, token.DeveloperID, token.Purpose, token.TokenHash, time.Now(), token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create account token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash retrieves an account token by its hash and purpose
+func (r *AccountTokenRepository) GetByHash(ctx context.Context, hash, purpose string) (*models.AccountToken, error) {
+	query := `
+		SELECT id, developer_id, purpose, token_hash, created_at, expires_at, used_at
+		FROM account_tokens
+		WHERE token_hash = $1 AND purpose = $2
+	`
+
+	token := &models.AccountToken{}
+	var usedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, hash, purpose).Scan(
+		&token.ID,
+		&token.DeveloperID,
+		&token.Purpose,
+		&token.TokenHash,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&usedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account token: %w", err)
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return token, nil
+}
+
+// Consume marks a token as used. It returns false if the token was already
+// used or has expired, so a token can be redeemed only once.
+func (r *AccountTokenRepository) Consume(ctx context.Context, id int) (bool, error) {
+	now := time.Now()
+
+	query := `
+		UPDATE account_tokens
+		SET used_at = $2
+		WHERE id = $1 AND used_at IS NULL AND expires_at > $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume account token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// InvalidateForDeveloper marks every unused token of a developer for a purpose
+// as used, so only the most recently issued token works
+func (r *AccountTokenRepository) InvalidateForDeveloper(ctx context.Context, developerID int, purpose string) error {
+	query := `
+		UPDATE account_tokens
+		SET used_at = $3
+		WHERE developer_id = $1 AND purpose = $2 AND used_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, developerID, purpose, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to invalidate account tokens: %w", err)
+	}
+
+	return nil
+}
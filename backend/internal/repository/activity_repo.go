@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
 )
 
 // ActivityRepository handles database operations for activity logs
@@ -19,19 +21,20 @@ func NewActivityRepository(db *DB) *ActivityRepository {
 }
 
 // Create creates a new activity log entry
-func (r *ActivityRepository) Create(activity *models.Activity) error {
+func (r *ActivityRepository) Create(ctx context.Context, activity *models.Activity) error {
 	now := time.Now()
 
 	query := `
-		INSERT INTO activities (developer_id, task_id, action, description, metadata, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO activities (developer_id, task_id, project_id, action, description, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at
 	`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		query,
 		activity.DeveloperID,
 		activity.TaskID,
+		activity.ProjectID,
 		activity.Action,
 		activity.Description,
 		activity.Metadata,
@@ -45,12 +48,14 @@ func (r *ActivityRepository) Create(activity *models.Activity) error {
 	return nil
 }
 
-// List retrieves activity logs with pagination and filters
-func (r *ActivityRepository) List(limit, offset int, developerID, taskID int) ([]*models.Activity, int, error) {
+// List retrieves activity logs with pagination and filters. Security events
+// are left out; see ListSecurityEvents. A non-zero visibleTo limits them to
+// the activities that developer can see.
+func (r *ActivityRepository) List(ctx context.Context, page pagination.Params, visibleTo, developerID, taskID int) ([]*models.Activity, *pagination.Page, error) {
 	// Build query with filters
-	whereClause := "WHERE 1=1"
-	args := []interface{}{}
-	argIndex := 1
+	whereClause := "WHERE a.action NOT LIKE $1"
+	args := []interface{}{models.SecurityActionPrefix + "%"}
+	argIndex := 2
 
 	if developerID > 0 {
 		whereClause += fmt.Sprintf(" AND a.developer_id = $%d", argIndex)
@@ -62,68 +67,95 @@ func (r *ActivityRepository) List(limit, offset int, developerID, taskID int) ([
 		args = append(args, taskID)
 		argIndex++
 	}
+	if visibleTo > 0 {
+		whereClause += " AND " + activityVisibleClause(fmt.Sprintf("$%d", argIndex))
+		args = append(args, visibleTo)
+		argIndex++
+	}
+
+	return r.list(ctx, whereClause, args, page)
+}
 
+// ListSecurityEvents retrieves security events, such as lockouts, with pagination
+func (r *ActivityRepository) ListSecurityEvents(ctx context.Context, page pagination.Params) ([]*models.Activity, *pagination.Page, error) {
+	return r.list(ctx, "WHERE a.action LIKE $1", []interface{}{models.SecurityActionPrefix + "%"}, page)
+}
+
+// list retrieves the activity logs matching whereClause, newest first
+func (r *ActivityRepository) list(ctx context.Context, whereClause string, args []interface{}, page pagination.Params) ([]*models.Activity, *pagination.Page, error) {
 	// Get total count
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM activities a %s", whereClause)
-	var total int
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	total, err := r.db.countTotal(ctx, page, "SELECT COUNT(*) FROM activities a "+whereClause, args)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count activities: %w", err)
+		return nil, nil, fmt.Errorf("failed to count activities: %w", err)
 	}
 
 	// Get activities
-	args = append(args, limit, offset)
+	cond, tail, args := pageClause("a.", page, args)
 	query := fmt.Sprintf(`
-		SELECT a.id, a.developer_id, a.task_id, a.action, a.description, a.metadata, a.created_at,
-		       d.id, d.name, d.email, d.status
+		SELECT a.id, a.developer_id, a.task_id, a.project_id, a.action, a.description, a.metadata, a.created_at,
+		       d.name, d.email, d.status
 		FROM activities a
 		LEFT JOIN developers d ON a.developer_id = d.id
+		%s%s
 		%s
-		ORDER BY a.created_at DESC
-		LIMIT $%d OFFSET $%d
-	`, whereClause, argIndex, argIndex+1)
+	`, whereClause, cond, tail)
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list activities: %w", err)
+		return nil, nil, fmt.Errorf("failed to list activities: %w", err)
 	}
 	defer rows.Close()
 
 	var activities []*models.Activity
 	for rows.Next() {
 		a := &models.Activity{}
-		var developerID, taskID sql.NullInt64
-		var developer models.Developer
+		var developerID, taskID, projectID sql.NullInt64
+		var name, email, status sql.NullString
 
 		err := rows.Scan(
 			&a.ID,
 			&developerID,
 			&taskID,
+			&projectID,
 			&a.Action,
 			&a.Description,
 			&a.Metadata,
 			&a.CreatedAt,
-			&developer.ID,
-			&developer.Name,
-			&developer.Email,
-			&developer.Status,
+			&name,
+			&email,
+			&status,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan activity: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan activity: %w", err)
 		}
 
+		// Activities without a developer (or whose developer was deleted)
+		// have NULL developer columns
 		if developerID.Valid {
 			did := int(developerID.Int64)
 			a.DeveloperID = &did
-			a.Developer = &developer
+			if name.Valid {
+				a.Developer = &models.Developer{
+					ID:     did,
+					Name:   name.String,
+					Email:  email.String,
+					Status: status.String,
+				}
+			}
 		}
 		if taskID.Valid {
 			tid := int(taskID.Int64)
 			a.TaskID = &tid
 		}
+		a.ProjectID = nullIntPtr(projectID)
 
 		activities = append(activities, a)
 	}
 
-	return activities, total, nil
+	activities, result := pagination.Build(page, activities, func(a *models.Activity) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: a.CreatedAt, ID: int64(a.ID)}
+	})
+	result.Total = total
+
+	return activities, result, nil
 }
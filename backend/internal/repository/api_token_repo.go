@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+// APITokenRepository handles database operations for personal API tokens
+type APITokenRepository struct {
+	db *DB
+}
+
+// NewAPITokenRepository creates a new API token repository
+func NewAPITokenRepository(db *DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+// Create stores a new API token hash
+func (r *APITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	query := `
+		INSERT INTO api_tokens (developer_id, name, token_prefix, token_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx,
+		query,
+		token.DeveloperID,
+		token.Name,
+		token.Prefix,
+		token.TokenHash,
+		strings.Join(token.Scopes, " "),
+		time.Now(),
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash retrieves an API token by its hash, together with its owner's email and role
+func (r *APITokenRepository) GetByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	query := `
+		SELECT t.id, t.developer_id, t.name, t.token_prefix, t.scopes, t.created_at,
+		       t.last_used_at, t.last_used_ip, t.expires_at, t.revoked_at,
+		       d.email, COALESCE(d.role, 'developer')
+		FROM api_tokens t
+		JOIN developers d ON d.id = t.developer_id
+		WHERE t.token_hash = $1
+	`
+
+	token := &models.APIToken{}
+	var scopes string
+	var lastUsedIP sql.NullString
+	var lastUsedAt, expiresAt, revokedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.ID,
+		&token.DeveloperID,
+		&token.Name,
+		&token.Prefix,
+		&scopes,
+		&token.CreatedAt,
+		&lastUsedAt,
+		&lastUsedIP,
+		&expiresAt,
+		&revokedAt,
+		&token.DeveloperEmail,
+		&token.DeveloperRole,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	token.LastUsedIP = lastUsedIP.String
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return token, nil
+}
+
+// ListByDeveloper retrieves a developer's tokens that have not been revoked
+func (r *APITokenRepository) ListByDeveloper(ctx context.Context, developerID int) ([]*models.APIToken, error) {
+	query := `
+		SELECT id, developer_id, name, token_prefix, scopes, created_at, last_used_at, last_used_ip, expires_at
+		FROM api_tokens
+		WHERE developer_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, developerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []*models.APIToken{}
+	for rows.Next() {
+		t := &models.APIToken{}
+		var scopes string
+		var lastUsedIP sql.NullString
+		var lastUsedAt, expiresAt sql.NullTime
+		err := rows.Scan(
+			&t.ID,
+			&t.DeveloperID,
+			&t.Name,
+			&t.Prefix,
+			&scopes,
+			&t.CreatedAt,
+			&lastUsedAt,
+			&lastUsedIP,
+			&expiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		t.Scopes = strings.Fields(scopes)
+		t.LastUsedIP = lastUsedIP.String
+		if lastUsedAt.Valid {
+			t.LastUsedAt = &lastUsedAt.Time
+		}
+		if expiresAt.Valid {
+			t.ExpiresAt = &expiresAt.Time
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, nil
+}
+
+// MarkUsed records a use of a token. Writes are skipped while the previous
+// timestamp is younger than minInterval.
+func (r *APITokenRepository) MarkUsed(ctx context.Context, id int, ipAddress string, minInterval time.Duration) error {
+	now := time.Now()
+
+	query := `
+		UPDATE api_tokens
+		SET last_used_at = $2, last_used_ip = $3
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $4)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, now, ipAddress, now.Add(-minInterval))
+	if err != nil {
+		return fmt.Errorf("failed to mark API token used: %w", err)
+	}
+
+	return nil
+}
+
+// Revoke revokes a developer's token. It returns false if the developer has
+// no such active token.
+func (r *APITokenRepository) Revoke(ctx context.Context, id, developerID int) (bool, error) {
+	query := `
+		UPDATE api_tokens
+		SET revoked_at = $3
+		WHERE id = $1 AND developer_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, developerID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// RevokeAllForDeveloper revokes every token of a developer and returns how many were revoked
+func (r *APITokenRepository) RevokeAllForDeveloper(ctx context.Context, developerID int) (int, error) {
+	query := `
+		UPDATE api_tokens
+		SET revoked_at = $2
+		WHERE developer_id = $1 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, developerID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke API tokens: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return int(rows), nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+)
+
+// AuditRepository handles database operations for the audit log
+type AuditRepository struct {
+	db *DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create stores an audit entry
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (entity_type, entity_id, action, actor_id, request_id, ip_address, changes, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx,
+		query,
+		entry.EntityType,
+		entry.EntityID,
+		entry.Action,
+		entry.ActorID,
+		entry.RequestID,
+		entry.IPAddress,
+		entry.Changes,
+		time.Now(),
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListByEntity retrieves the history of an entity, newest first
+func (r *AuditRepository) ListByEntity(ctx context.Context, entityType string, entityID int, page pagination.Params) ([]*models.AuditEntry, *pagination.Page, error) {
+	whereClause := "WHERE l.entity_type = $1 AND l.entity_id = $2"
+	args := []interface{}{entityType, entityID}
+
+	total, err := r.db.countTotal(ctx, page, "SELECT COUNT(*) FROM audit_log l "+whereClause, args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	cond, tail, args := pageClause("l.", page, args)
+	query := fmt.Sprintf(`
+		SELECT l.id, l.entity_type, l.entity_id, l.action, l.actor_id, l.request_id, l.ip_address, l.changes, l.created_at,
+		       d.name, d.email
+		FROM audit_log l
+		LEFT JOIN developers d ON l.actor_id = d.id
+		%s%s
+		%s
+	`, whereClause, cond, tail)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		e := &models.AuditEntry{}
+		var actorID sql.NullInt64
+		var requestID, ipAddress, name, email sql.NullString
+
+		err := rows.Scan(
+			&e.ID,
+			&e.EntityType,
+			&e.EntityID,
+			&e.Action,
+			&actorID,
+			&requestID,
+			&ipAddress,
+			&e.Changes,
+			&e.CreatedAt,
+			&name,
+			&email,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+
+		e.ActorID = nullIntPtr(actorID)
+		if e.ActorID != nil && name.Valid {
+			e.Actor = &models.Developer{ID: *e.ActorID, Name: name.String, Email: email.String}
+		}
+		e.RequestID = requestID.String
+		e.IPAddress = ipAddress.String
+
+		entries = append(entries, e)
+	}
+
+	entries, result := pagination.Build(page, entries, func(e *models.AuditEntry) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: e.CreatedAt, ID: e.ID}
+	})
+	result.Total = total
+
+	return entries, result, nil
+}
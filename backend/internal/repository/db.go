@@ -1,27 +1,63 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ardani17/taskmanager/internal/config"
 	_ "github.com/lib/pq"
 	"github.com/rs/zerolog/log"
+	_ "modernc.org/sqlite"
 )
 
-// DB holds the database connection
+// ErrNotFound is wrapped by the errors of mutations whose row does not exist
+var ErrNotFound = errors.New("not found")
+
+// Supported database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// DB holds the database connection. A DB created by UnitOfWork.Do is bound
+// to a transaction and runs every query in it.
 type DB struct {
 	*sql.DB
+	tx     *sql.Tx
+	driver string
 }
 
-// NewDB creates a new database connection
+// NewDB creates a new database connection for the configured driver
 func NewDB(cfg *config.Config) (*DB, error) {
+	switch cfg.DBDriver {
+	case DriverPostgres:
+		return openPostgres(cfg)
+	case DriverSQLite:
+		return openSQLite(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q, use %q or %q", cfg.DBDriver, DriverPostgres, DriverSQLite)
+	}
+}
+
+func openPostgres(cfg *config.Config) (*DB, error) {
 	connStr := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName,
 	)
 
+	// Postgres cancels statements running longer than the timeout; queries
+	// are also cancelled when their request's context ends
+	statementTimeout, err := time.ParseDuration(cfg.DBStatementTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_STATEMENT_TIMEOUT: %w", err)
+	}
+	if statementTimeout > 0 {
+		connStr += fmt.Sprintf(" statement_timeout=%d", statementTimeout.Milliseconds())
+	}
+
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -39,10 +75,183 @@ func NewDB(cfg *config.Config) (*DB, error) {
 
 	log.Info().Str("host", cfg.DBHost).Str("db", cfg.DBName).Msg("Database connected")
 
-	return &DB{db}, nil
+	return &DB{DB: db, driver: DriverPostgres}, nil
+}
+
+// openSQLite opens the SQLite database file at cfg.DBPath, creating it when
+// it does not exist. SQLite has no statement timeout; queries are cancelled
+// when their request's context ends.
+func openSQLite(cfg *config.Config) (*DB, error) {
+	// Transactions take the write lock when they begin, and writers wait for
+	// each other instead of failing with "database is locked". Times are
+	// written in a format the driver parses back.
+	connStr := "file:" + cfg.DBPath +
+		"?_pragma=foreign_keys(1)" +
+		"&_pragma=journal_mode(WAL)" +
+		"&_pragma=busy_timeout(5000)" +
+		"&_txlock=immediate" +
+		"&_time_format=sqlite"
+
+	db, err := sql.Open("sqlite", connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Test connection
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	log.Info().Str("path", cfg.DBPath).Msg("SQLite database opened")
+
+	return &DB{DB: db, driver: DriverSQLite}, nil
+}
+
+// Driver returns the name of the database driver
+func (db *DB) Driver() string {
+	return db.driver
 }
 
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.DB.Close()
 }
+
+// ExecContext executes a query without returning rows
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	args = db.args(args)
+	if db.tx != nil {
+		return db.tx.ExecContext(ctx, query, args...)
+	}
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+// QueryContext executes a query that returns rows
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	args = db.args(args)
+	if db.tx != nil {
+		return db.tx.QueryContext(ctx, query, args...)
+	}
+	return db.DB.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext executes a query that returns at most one row
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	args = db.args(args)
+	if db.tx != nil {
+		return db.tx.QueryRowContext(ctx, query, args...)
+	}
+	return db.DB.QueryRowContext(ctx, query, args...)
+}
+
+// args prepares query arguments for the driver. SQLite stores times as text
+// and compares them as strings, so they are all written in UTC.
+func (db *DB) args(args []interface{}) []interface{} {
+	if db.driver != DriverSQLite {
+		return args
+	}
+
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			converted[i] = v.UTC()
+		case *time.Time:
+			if v != nil {
+				converted[i] = v.UTC()
+			} else {
+				converted[i] = v
+			}
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
+
+// forUpdate returns the clause locking the rows read by a SELECT until the
+// end of the transaction. SQLite transactions hold the write lock of the
+// whole database from their start, so it needs none.
+func (db *DB) forUpdate() string {
+	if db.driver == DriverSQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// Keys of the transaction locks taken with lock
+const (
+	// lockTaskLinks guards the cycle check of blocks and duplicates links
+	lockTaskLinks int64 = iota + 1
+	// lockTaskTree guards the cycle check of moving tasks between parents
+	lockTaskTree
+)
+
+// lock takes a lock on key until the end of the transaction, so checks that
+// read many rows, such as following links for cycles, cannot interleave with
+// the same check of another transaction. SQLite transactions hold the write
+// lock of the whole database from their start, so it needs none.
+func (db *DB) lock(ctx context.Context, key int64) error {
+	if db.driver == DriverSQLite {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", key); err != nil {
+		return fmt.Errorf("failed to take lock: %w", err)
+	}
+	return nil
+}
+
+// dbTx is a transaction started by a repository method that needs several
+// statements to succeed together
+type dbTx struct {
+	*sql.Tx
+	// joined is set when the transaction is the one of an enclosing unit of
+	// work, which commits or rolls back as a whole
+	joined bool
+	db     *DB
+}
+
+// begin starts a transaction. Inside a unit of work it joins the unit's
+// transaction instead: Commit and Rollback are then left to the unit, which
+// rolls back everything when the repository method returns an error.
+func (db *DB) begin(ctx context.Context) (*dbTx, error) {
+	if db.tx != nil {
+		return &dbTx{Tx: db.tx, joined: true, db: db}, nil
+	}
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &dbTx{Tx: tx, db: db}, nil
+}
+
+// ExecContext executes a query without returning rows
+func (t *dbTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.ExecContext(ctx, query, t.db.args(args)...)
+}
+
+// QueryContext executes a query that returns rows
+func (t *dbTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.Tx.QueryContext(ctx, query, t.db.args(args)...)
+}
+
+// QueryRowContext executes a query that returns at most one row
+func (t *dbTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRowContext(ctx, query, t.db.args(args)...)
+}
+
+// Commit commits the transaction unless it belongs to a unit of work
+func (t *dbTx) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+// Rollback rolls the transaction back unless it belongs to a unit of work
+func (t *dbTx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
 )
 
 // DeveloperRepository handles database operations for developers
@@ -19,19 +21,20 @@ func NewDeveloperRepository(db *DB) *DeveloperRepository {
 }
 
 // Create creates a new developer
-func (r *DeveloperRepository) Create(developer *models.Developer) error {
+func (r *DeveloperRepository) Create(ctx context.Context, developer *models.Developer) error {
 	now := time.Now()
 
 	query := `
-		INSERT INTO developers (name, email, role, avatar_url, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO developers (name, email, password_hash, role, avatar_url, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		query,
 		developer.Name,
 		developer.Email,
+		developer.PasswordHash,
 		developer.Role,
 		developer.AvatarURL,
 		developer.Status,
@@ -47,9 +50,9 @@ func (r *DeveloperRepository) Create(developer *models.Developer) error {
 }
 
 // GetByID retrieves a developer by ID
-func (r *DeveloperRepository) GetByID(id int) (*models.Developer, error) {
+func (r *DeveloperRepository) GetByID(ctx context.Context, id int) (*models.Developer, error) {
 	query := `
-		SELECT id, name, email, COALESCE(role, 'developer') as role, team_id, avatar_url, status, created_at, updated_at
+		SELECT id, name, email, COALESCE(role, 'developer') as role, team_id, avatar_url, status, email_verified_at IS NOT NULL, totp_enabled_at IS NOT NULL, created_at, updated_at
 		FROM developers
 		WHERE id = $1
 	`
@@ -58,7 +61,7 @@ func (r *DeveloperRepository) GetByID(id int) (*models.Developer, error) {
 	var role, avatarURL sql.NullString
 	var teamID sql.NullInt64
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&developer.ID,
 		&developer.Name,
 		&developer.Email,
@@ -66,6 +69,8 @@ func (r *DeveloperRepository) GetByID(id int) (*models.Developer, error) {
 		&teamID,
 		&avatarURL,
 		&developer.Status,
+		&developer.EmailVerified,
+		&developer.TwoFactorEnabled,
 		&developer.CreatedAt,
 		&developer.UpdatedAt,
 	)
@@ -91,26 +96,29 @@ func (r *DeveloperRepository) GetByID(id int) (*models.Developer, error) {
 	return developer, nil
 }
 
-// GetByEmail retrieves a developer by email
-func (r *DeveloperRepository) GetByEmail(email string) (*models.Developer, error) {
+// GetByEmail retrieves a developer by email, including the password hash
+func (r *DeveloperRepository) GetByEmail(ctx context.Context, email string) (*models.Developer, error) {
 	query := `
-		SELECT id, name, email, COALESCE(role, 'developer') as role, team_id, avatar_url, status, created_at, updated_at
+		SELECT id, name, email, password_hash, COALESCE(role, 'developer') as role, team_id, avatar_url, status, email_verified_at IS NOT NULL, totp_enabled_at IS NOT NULL, created_at, updated_at
 		FROM developers
 		WHERE email = $1
 	`
 
 	developer := &models.Developer{}
-	var role, avatarURL sql.NullString
+	var passwordHash, role, avatarURL sql.NullString
 	var teamID sql.NullInt64
 
-	err := r.db.QueryRow(query, email).Scan(
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&developer.ID,
 		&developer.Name,
 		&developer.Email,
+		&passwordHash,
 		&role,
 		&teamID,
 		&avatarURL,
 		&developer.Status,
+		&developer.EmailVerified,
+		&developer.TwoFactorEnabled,
 		&developer.CreatedAt,
 		&developer.UpdatedAt,
 	)
@@ -122,6 +130,9 @@ func (r *DeveloperRepository) GetByEmail(email string) (*models.Developer, error
 		return nil, fmt.Errorf("failed to get developer by email: %w", err)
 	}
 
+	if passwordHash.Valid {
+		developer.PasswordHash = passwordHash.String
+	}
 	if role.Valid {
 		developer.Role = role.String
 	}
@@ -136,26 +147,52 @@ func (r *DeveloperRepository) GetByEmail(email string) (*models.Developer, error
 	return developer, nil
 }
 
-// List retrieves all developers with pagination
-func (r *DeveloperRepository) List(limit, offset int) ([]*models.Developer, int, error) {
+// GetByOIDCSubject retrieves the developer linked to an identity provider account
+func (r *DeveloperRepository) GetByOIDCSubject(ctx context.Context, issuer, subject string) (*models.Developer, error) {
+	var id int
+	query := "SELECT id FROM developers WHERE oidc_issuer = $1 AND oidc_subject = $2"
+	err := r.db.QueryRowContext(ctx, query, issuer, subject).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get developer by OIDC subject: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// List retrieves all developers with pagination, optionally limited to a team
+func (r *DeveloperRepository) List(ctx context.Context, page pagination.Params, teamID int) ([]*models.Developer, *pagination.Page, error) {
+	// Build query with filters
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+	argIndex := 1
+
+	if teamID > 0 {
+		whereClause += fmt.Sprintf(" AND team_id = $%d", argIndex)
+		args = append(args, teamID)
+		argIndex++
+	}
+
 	// Get total count
-	var total int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM developers").Scan(&total)
+	total, err := r.db.countTotal(ctx, page, "SELECT COUNT(*) FROM developers "+whereClause, args)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count developers: %w", err)
+		return nil, nil, fmt.Errorf("failed to count developers: %w", err)
 	}
 
 	// Get developers
-	query := `
-		SELECT id, name, email, COALESCE(role, 'developer') as role, team_id, avatar_url, status, created_at, updated_at
+	cond, tail, args := pageClause("", page, args)
+	query := fmt.Sprintf(`
+		SELECT id, name, email, COALESCE(role, 'developer') as role, team_id, avatar_url, status, email_verified_at IS NOT NULL, totp_enabled_at IS NOT NULL, created_at, updated_at
 		FROM developers
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+		%s%s
+		%s
+	`, whereClause, cond, tail)
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list developers: %w", err)
+		return nil, nil, fmt.Errorf("failed to list developers: %w", err)
 	}
 	defer rows.Close()
 
@@ -172,11 +209,13 @@ func (r *DeveloperRepository) List(limit, offset int) ([]*models.Developer, int,
 			&teamID,
 			&avatarURL,
 			&d.Status,
+			&d.EmailVerified,
+			&d.TwoFactorEnabled,
 			&d.CreatedAt,
 			&d.UpdatedAt,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan developer: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan developer: %w", err)
 		}
 		if role.Valid {
 			d.Role = role.String
@@ -191,11 +230,16 @@ func (r *DeveloperRepository) List(limit, offset int) ([]*models.Developer, int,
 		developers = append(developers, d)
 	}
 
-	return developers, total, nil
+	developers, result := pagination.Build(page, developers, func(d *models.Developer) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: d.CreatedAt, ID: int64(d.ID)}
+	})
+	result.Total = total
+
+	return developers, result, nil
 }
 
 // Update updates a developer
-func (r *DeveloperRepository) Update(id int, req *models.UpdateDeveloperRequest) (*models.Developer, error) {
+func (r *DeveloperRepository) Update(ctx context.Context, id int, req *models.UpdateDeveloperRequest) (*models.Developer, error) {
 	query := `
 		UPDATE developers
 		SET name = COALESCE($2, name),
@@ -203,7 +247,7 @@ func (r *DeveloperRepository) Update(id int, req *models.UpdateDeveloperRequest)
 		    status = COALESCE($4, status),
 		    updated_at = $5
 		WHERE id = $1
-		RETURNING id, name, email, COALESCE(role, 'developer') as role, team_id, avatar_url, status, created_at, updated_at
+		RETURNING id, name, email, COALESCE(role, 'developer') as role, team_id, avatar_url, status, email_verified_at IS NOT NULL, totp_enabled_at IS NOT NULL, created_at, updated_at
 	`
 
 	developer := &models.Developer{}
@@ -215,7 +259,7 @@ func (r *DeveloperRepository) Update(id int, req *models.UpdateDeveloperRequest)
 		avatarURLParam = req.AvatarURL
 	}
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		query,
 		id,
 		req.Name,
@@ -230,6 +274,8 @@ func (r *DeveloperRepository) Update(id int, req *models.UpdateDeveloperRequest)
 		&teamID,
 		&avatarURL,
 		&developer.Status,
+		&developer.EmailVerified,
+		&developer.TwoFactorEnabled,
 		&developer.CreatedAt,
 		&developer.UpdatedAt,
 	)
@@ -256,14 +302,14 @@ func (r *DeveloperRepository) Update(id int, req *models.UpdateDeveloperRequest)
 }
 
 // UpdateStatus updates developer status
-func (r *DeveloperRepository) UpdateStatus(id int, status string) error {
+func (r *DeveloperRepository) UpdateStatus(ctx context.Context, id int, status string) error {
 	query := `
 		UPDATE developers
 		SET status = $2, updated_at = $3
 		WHERE id = $1
 	`
 
-	_, err := r.db.Exec(query, id, status, time.Now())
+	_, err := r.db.ExecContext(ctx, query, id, status, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to update developer status: %w", err)
 	}
@@ -271,10 +317,99 @@ func (r *DeveloperRepository) UpdateStatus(id int, status string) error {
 	return nil
 }
 
+// UpdatePassword replaces a developer's password hash
+func (r *DeveloperRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	query := `
+		UPDATE developers
+		SET password_hash = $2, updated_at = $3
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, passwordHash, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update developer password: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records that a developer has verified their email address
+func (r *DeveloperRepository) MarkEmailVerified(ctx context.Context, id int) error {
+	query := `
+		UPDATE developers
+		SET email_verified_at = COALESCE(email_verified_at, $2), updated_at = $2
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	return nil
+}
+
+// LinkOIDC links a developer to an identity provider account
+func (r *DeveloperRepository) LinkOIDC(ctx context.Context, id int, issuer, subject string) error {
+	query := `
+		UPDATE developers
+		SET oidc_issuer = $2, oidc_subject = $3, updated_at = $4
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, issuer, subject, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to link developer to OIDC account: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRole changes a developer's global role
+func (r *DeveloperRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	query := `
+		UPDATE developers
+		SET role = $2, updated_at = $3
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, role, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update developer role: %w", err)
+	}
+
+	return nil
+}
+
+// SetTeam moves a developer into a team, or out of any team when teamID is nil
+func (r *DeveloperRepository) SetTeam(ctx context.Context, id int, teamID *int) error {
+	query := `
+		UPDATE developers
+		SET team_id = $2, updated_at = $3
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, teamID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update developer team: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("developer %w", ErrNotFound)
+	}
+
+	return nil
+}
+
 // Delete deletes a developer
-func (r *DeveloperRepository) Delete(id int) error {
+func (r *DeveloperRepository) Delete(ctx context.Context, id int) error {
 	query := "DELETE FROM developers WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete developer: %w", err)
 	}
@@ -285,7 +420,7 @@ func (r *DeveloperRepository) Delete(id int) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("developer not found")
+		return fmt.Errorf("developer %w", ErrNotFound)
 	}
 
 	return nil
@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+)
+
+// InvitationRepository handles database operations for invitations
+type InvitationRepository struct {
+	db *DB
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(db *DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+const invitationColumns = `
+	id, email, role, project_id, project_role, team_id, invited_by,
+	created_at, expires_at, accepted_at, accepted_by, revoked_at
+`
+
+// Create stores a new invitation
+func (r *InvitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	query := `
+		INSERT INTO invitations (email, role, project_id, project_role, team_id, invited_by, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx,
+		query,
+		invitation.Email,
+		invitation.Role,
+		invitation.ProjectID,
+		invitation.ProjectRole,
+		invitation.TeamID,
+		invitation.InvitedBy,
+		invitation.TokenHash,
+		time.Now(),
+		invitation.ExpiresAt,
+	).Scan(&invitation.ID, &invitation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	invitation.Status = invitation.CurrentStatus()
+	return nil
+}
+
+// GetByID retrieves an invitation by ID
+func (r *InvitationRepository) GetByID(ctx context.Context, id int) (*models.Invitation, error) {
+	query := "SELECT " + invitationColumns + " FROM invitations WHERE id = $1"
+
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// GetByHash retrieves an invitation by the hash of its token
+func (r *InvitationRepository) GetByHash(ctx context.Context, hash string) (*models.Invitation, error) {
+	query := "SELECT " + invitationColumns + " FROM invitations WHERE token_hash = $1"
+
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, query, hash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// List retrieves invitations, newest first. When invitedBy is set, only the
+// invitations sent by that developer are included.
+func (r *InvitationRepository) List(ctx context.Context, page pagination.Params, invitedBy int) ([]*models.Invitation, *pagination.Page, error) {
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+	if invitedBy > 0 {
+		whereClause += " AND invited_by = $1"
+		args = append(args, invitedBy)
+	}
+
+	total, err := r.db.countTotal(ctx, page, "SELECT COUNT(*) FROM invitations "+whereClause, args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count invitations: %w", err)
+	}
+
+	cond, tail, args := pageClause("", page, args)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM invitations
+		%s%s
+		%s
+	`, invitationColumns, whereClause, cond, tail)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []*models.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	invitations, result := pagination.Build(page, invitations, func(i *models.Invitation) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: i.CreatedAt, ID: int64(i.ID)}
+	})
+	result.Total = total
+
+	return invitations, result, nil
+}
+
+// Revoke revokes a pending invitation. It returns false if the invitation
+// does not exist or was already accepted or revoked.
+func (r *InvitationRepository) Revoke(ctx context.Context, id int) (bool, error) {
+	query := `
+		UPDATE invitations
+		SET revoked_at = $2
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// Accept redeems an invitation: it creates the developer with the invited
+// email, role and team, marks the email verified, adds the project
+// membership and marks the invitation accepted, all in one transaction.
+// It returns nil if the invitation is unknown, used, revoked or expired.
+func (r *InvitationRepository) Accept(ctx context.Context, hash string, developer *models.Developer) (*models.Invitation, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the invitation so it can only be accepted once
+	query := "SELECT " + invitationColumns + " FROM invitations WHERE token_hash = $1" + r.db.forUpdate()
+	invitation, err := scanInvitation(tx.QueryRowContext(ctx, query, hash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if invitation.CurrentStatus() != models.InvitationPending {
+		return nil, nil
+	}
+
+	now := time.Now()
+	developer.Email = invitation.Email
+	developer.Role = invitation.Role
+	developer.TeamID = invitation.TeamID
+	developer.EmailVerified = true
+
+	query = `
+		INSERT INTO developers (name, email, password_hash, role, team_id, status, email_verified_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $7)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRowContext(ctx,
+		query,
+		developer.Name,
+		developer.Email,
+		developer.PasswordHash,
+		developer.Role,
+		developer.TeamID,
+		developer.Status,
+		now,
+	).Scan(&developer.ID, &developer.CreatedAt, &developer.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create developer: %w", err)
+	}
+
+	if invitation.ProjectID != nil {
+		query = `
+			INSERT INTO project_members (project_id, developer_id, role, joined_at)
+			VALUES ($1, $2, $3, $4)
+		`
+		if _, err := tx.ExecContext(ctx, query, *invitation.ProjectID, developer.ID, invitation.ProjectRole, now); err != nil {
+			return nil, fmt.Errorf("failed to add project member: %w", err)
+		}
+	}
+
+	query = "UPDATE invitations SET accepted_at = $2, accepted_by = $3 WHERE id = $1"
+	if _, err := tx.ExecContext(ctx, query, invitation.ID, now, developer.ID); err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	invitation.AcceptedAt = &now
+	invitation.AcceptedBy = &developer.ID
+	invitation.Status = models.InvitationAccepted
+	return invitation, nil
+}
+
+// scanInvitation scans a row selected with invitationColumns
+func scanInvitation(row interface{ Scan(...interface{}) error }) (*models.Invitation, error) {
+	invitation := &models.Invitation{}
+	var projectID, teamID, invitedBy, acceptedBy sql.NullInt64
+	var projectRole sql.NullString
+	var acceptedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.Email,
+		&invitation.Role,
+		&projectID,
+		&projectRole,
+		&teamID,
+		&invitedBy,
+		&invitation.CreatedAt,
+		&invitation.ExpiresAt,
+		&acceptedAt,
+		&acceptedBy,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.ProjectID = nullIntPtr(projectID)
+	invitation.TeamID = nullIntPtr(teamID)
+	invitation.InvitedBy = nullIntPtr(invitedBy)
+	invitation.AcceptedBy = nullIntPtr(acceptedBy)
+	invitation.ProjectRole = projectRole.String
+	if acceptedAt.Valid {
+		invitation.AcceptedAt = &acceptedAt.Time
+	}
+	if revokedAt.Valid {
+		invitation.RevokedAt = &revokedAt.Time
+	}
+	invitation.Status = invitation.CurrentStatus()
+
+	return invitation, nil
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
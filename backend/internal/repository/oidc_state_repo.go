@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+// OIDCStateRepository handles database operations for pending OIDC logins
+type OIDCStateRepository struct {
+	db *DB
+}
+
+// NewOIDCStateRepository creates a new OIDC state repository
+func NewOIDCStateRepository(db *DB) *OIDCStateRepository {
+	return &OIDCStateRepository{db: db}
+}
+
+// Create stores a pending login and removes expired ones
+func (r *OIDCStateRepository) Create(ctx context.Context, state *models.OIDCLoginState) error {
+	now := time.Now()
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM oidc_login_states WHERE expires_at < $1", now); err != nil {
+		return fmt.Errorf("failed to delete expired OIDC states: %w", err)
+	}
+
+	query := `
+		INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, state.StateHash, state.CodeVerifier, state.Nonce, now, state.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create OIDC state: %w", err)
+	}
+
+	state.CreatedAt = now
+	return nil
+}
+
+// Take retrieves and deletes a pending login, so each state can be used once.
+// It returns nil if the state is unknown or has expired.
+func (r *OIDCStateRepository) Take(ctx context.Context, stateHash string) (*models.OIDCLoginState, error) {
+	query := `
+		DELETE FROM oidc_login_states
+		WHERE state_hash = $1
+		RETURNING state_hash, code_verifier, nonce, created_at, expires_at
+	`
+
+	state := &models.OIDCLoginState{}
+	err := r.db.QueryRowContext(ctx, query, stateHash).Scan(
+		&state.StateHash,
+		&state.CodeVerifier,
+		&state.Nonce,
+		&state.CreatedAt,
+		&state.ExpiresAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take OIDC state: %w", err)
+	}
+
+	if time.Now().After(state.ExpiresAt) {
+		return nil, nil
+	}
+
+	return state, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ardani17/taskmanager/internal/pagination"
+)
+
+// pageClause returns the condition and the ORDER BY and LIMIT clauses that
+// select page p of a list ordered newest first by created_at and id, and
+// args with their arguments appended. prefix qualifies the columns, e.g.
+// "t." for a table aliased t. The condition, empty on the first page, starts
+// with AND so it can follow the list's WHERE clause. One row more than the
+// page size is fetched for pagination.Build to tell whether more follow.
+func pageClause(prefix string, p pagination.Params, args []interface{}) (string, string, []interface{}) {
+	cond := ""
+	if p.Cursor != nil {
+		op := "<"
+		if p.Backward() {
+			op = ">"
+		}
+		cond = fmt.Sprintf(" AND (%[1]screated_at, %[1]sid) %[2]s ($%[3]d, $%[4]d)", prefix, op, len(args)+1, len(args)+2)
+		args = append(args, p.Cursor.CreatedAt, p.Cursor.ID)
+	}
+
+	dir := "DESC"
+	if p.Backward() {
+		dir = "ASC"
+	}
+	tail := fmt.Sprintf("ORDER BY %[1]screated_at %[2]s, %[1]sid %[2]s LIMIT $%[3]d", prefix, dir, len(args)+1)
+	args = append(args, p.Limit+1)
+
+	return cond, tail, args
+}
+
+// countTotal runs the count query of a list when page p asks for the total
+func (db *DB) countTotal(ctx context.Context, p pagination.Params, query string, args []interface{}) (*int, error) {
+	if !p.WithTotal {
+		return nil, nil
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+	return &total, nil
+}
+
+// orderKey is an expression a list is sorted by
+type orderKey struct {
+	expr     string
+	desc     bool
+	nullable bool
+}
+
+// keysetClause is pageClause for lists sorted by keys, the last of which
+// must be unique, such as the ID. values are the keys of the cursor row, nil
+// where they are NULL; NULLs sort last.
+func keysetClause(keys []orderKey, values []interface{}, p pagination.Params, args []interface{}) (string, string, []interface{}) {
+	backward := p.Backward()
+
+	cond := ""
+	if p.Cursor != nil {
+		// A backward page holds the rows after the cursor in the reverse
+		// order, where NULLs come first
+		params := make([]string, len(values))
+		for i, v := range values {
+			if v != nil {
+				args = append(args, v)
+				params[i] = fmt.Sprintf("$%d", len(args))
+			}
+		}
+
+		var terms []string
+		for i, key := range keys {
+			after := ""
+			switch {
+			case values[i] == nil && backward:
+				after = key.expr + " IS NOT NULL"
+			case values[i] == nil:
+				// Nothing follows NULL
+				continue
+			default:
+				op := ">"
+				if key.desc != backward {
+					op = "<"
+				}
+				after = fmt.Sprintf("%s %s %s", key.expr, op, params[i])
+				if key.nullable && !backward {
+					after = fmt.Sprintf("(%s OR %s IS NULL)", after, key.expr)
+				}
+			}
+
+			parts := []string{}
+			for j := 0; j < i; j++ {
+				if values[j] == nil {
+					parts = append(parts, keys[j].expr+" IS NULL")
+				} else {
+					parts = append(parts, fmt.Sprintf("%s = %s", keys[j].expr, params[j]))
+				}
+			}
+			terms = append(terms, "("+strings.Join(append(parts, after), " AND ")+")")
+		}
+		cond = " AND (" + strings.Join(terms, " OR ") + ")"
+	}
+
+	order := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key.nullable {
+			if backward {
+				order = append(order, key.expr+" IS NULL DESC")
+			} else {
+				order = append(order, key.expr+" IS NULL")
+			}
+		}
+		dir := "ASC"
+		if key.desc != backward {
+			dir = "DESC"
+		}
+		order = append(order, key.expr+" "+dir)
+	}
+	tail := fmt.Sprintf("ORDER BY %s LIMIT $%d", strings.Join(order, ", "), len(args)+1)
+	args = append(args, p.Limit+1)
+
+	return cond, tail, args
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+// ErrLastOwner is returned when a change would leave a project without an owner
+var ErrLastOwner = errors.New("a project must keep at least one owner")
+
+// ProjectMemberRepository handles database operations for project members
+type ProjectMemberRepository struct {
+	db *DB
+}
+
+// NewProjectMemberRepository creates a new project member repository
+func NewProjectMemberRepository(db *DB) *ProjectMemberRepository {
+	return &ProjectMemberRepository{db: db}
+}
+
+// Add adds a developer to a project, or updates their role if already a
+// member. It fails with ErrLastOwner if it demotes the project's last owner.
+// Run it in a unit of work, which holds off concurrent changes to the owners
+// until it commits.
+func (r *ProjectMemberRepository) Add(ctx context.Context, projectID, developerID int, role string) error {
+	if role != models.ProjectRoleOwner {
+		if err := r.keepOwner(ctx, projectID, developerID); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO project_members (project_id, developer_id, role, joined_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id, developer_id) DO UPDATE SET role = EXCLUDED.role
+	`
+
+	_, err := r.db.ExecContext(ctx, query, projectID, developerID, role, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to add project member: %w", err)
+	}
+
+	return nil
+}
+
+// GetRole returns the developer's role in a project, or "" if not a member
+func (r *ProjectMemberRepository) GetRole(ctx context.Context, projectID, developerID int) (string, error) {
+	query := "SELECT role FROM project_members WHERE project_id = $1 AND developer_id = $2"
+
+	var role string
+	err := r.db.QueryRowContext(ctx, query, projectID, developerID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project member role: %w", err)
+	}
+
+	return role, nil
+}
+
+// List retrieves all members of a project
+func (r *ProjectMemberRepository) List(ctx context.Context, projectID int) ([]*models.ProjectMember, error) {
+	query := `
+		SELECT pm.project_id, pm.developer_id, pm.role, pm.joined_at,
+		       d.id, d.name, d.email, COALESCE(d.role, 'developer'), d.status
+		FROM project_members pm
+		JOIN developers d ON pm.developer_id = d.id
+		WHERE pm.project_id = $1
+		ORDER BY pm.joined_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*models.ProjectMember
+	for rows.Next() {
+		m := &models.ProjectMember{Developer: &models.Developer{}}
+		err := rows.Scan(
+			&m.ProjectID,
+			&m.DeveloperID,
+			&m.Role,
+			&m.JoinedAt,
+			&m.Developer.ID,
+			&m.Developer.Name,
+			&m.Developer.Email,
+			&m.Developer.Role,
+			&m.Developer.Status,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project member: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	return members, nil
+}
+
+// keepOwner fails with ErrLastOwner if developerID is the project's only
+// owner. It locks the owners until the end of the transaction, so two
+// owners cannot step down at the same time.
+func (r *ProjectMemberRepository) keepOwner(ctx context.Context, projectID, developerID int) error {
+	query := "SELECT developer_id FROM project_members WHERE project_id = $1 AND role = $2" + r.db.forUpdate()
+	rows, err := r.db.QueryContext(ctx, query, projectID, models.ProjectRoleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to lock project owners: %w", err)
+	}
+	defer rows.Close()
+
+	owners, isOwner := 0, false
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan project owner: %w", err)
+		}
+		owners++
+		isOwner = isOwner || id == developerID
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to lock project owners: %w", err)
+	}
+
+	if isOwner && owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// Remove removes a developer from a project. It fails with ErrLastOwner if
+// the developer is the project's last owner; run it in a unit of work, as Add.
+func (r *ProjectMemberRepository) Remove(ctx context.Context, projectID, developerID int) error {
+	if err := r.keepOwner(ctx, projectID, developerID); err != nil {
+		return err
+	}
+
+	query := "DELETE FROM project_members WHERE project_id = $1 AND developer_id = $2"
+	result, err := r.db.ExecContext(ctx, query, projectID, developerID)
+	if err != nil {
+		return fmt.Errorf("failed to remove project member: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("project member %w", ErrNotFound)
+	}
+
+	return nil
+}
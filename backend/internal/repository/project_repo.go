@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
 )
 
 // ProjectRepository handles database operations for projects
@@ -19,7 +21,7 @@ func NewProjectRepository(db *DB) *ProjectRepository {
 }
 
 // Create creates a new project
-func (r *ProjectRepository) Create(project *models.Project) error {
+func (r *ProjectRepository) Create(ctx context.Context, project *models.Project) error {
 	now := time.Now()
 
 	query := `
@@ -28,7 +30,7 @@ func (r *ProjectRepository) Create(project *models.Project) error {
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		query,
 		project.Name,
 		project.Description,
@@ -46,7 +48,7 @@ func (r *ProjectRepository) Create(project *models.Project) error {
 }
 
 // GetByID retrieves a project by ID
-func (r *ProjectRepository) GetByID(id int) (*models.Project, error) {
+func (r *ProjectRepository) GetByID(ctx context.Context, id int) (*models.Project, error) {
 	query := `
 		SELECT id, name, description, status, start_date, end_date, team_id, created_at, updated_at
 		FROM projects
@@ -57,7 +59,7 @@ func (r *ProjectRepository) GetByID(id int) (*models.Project, error) {
 	var startDate, endDate sql.NullString
 	var teamID sql.NullInt64
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&project.ID,
 		&project.Name,
 		&project.Description,
@@ -88,7 +90,7 @@ func (r *ProjectRepository) GetByID(id int) (*models.Project, error) {
 	}
 
 	// Get task count
-	err = r.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE project_id = $1", id).Scan(&project.TaskCount)
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE project_id = $1", id).Scan(&project.TaskCount)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, fmt.Errorf("failed to get task count: %w", err)
 	}
@@ -96,8 +98,10 @@ func (r *ProjectRepository) GetByID(id int) (*models.Project, error) {
 	return project, nil
 }
 
-// List retrieves all projects with pagination
-func (r *ProjectRepository) List(limit, offset int, status string) ([]*models.Project, int, error) {
+// List retrieves all projects with pagination. When memberID is set, only
+// projects that developer belongs to are returned; teamID limits the
+// result to one team's projects.
+func (r *ProjectRepository) List(ctx context.Context, page pagination.Params, status string, memberID, teamID int) ([]*models.Project, *pagination.Page, error) {
 	// Build query with filters
 	whereClause := "WHERE 1=1"
 	args := []interface{}{}
@@ -108,28 +112,35 @@ func (r *ProjectRepository) List(limit, offset int, status string) ([]*models.Pr
 		args = append(args, status)
 		argIndex++
 	}
+	if memberID > 0 {
+		whereClause += fmt.Sprintf(" AND id IN (SELECT project_id FROM project_members WHERE developer_id = $%d)", argIndex)
+		args = append(args, memberID)
+		argIndex++
+	}
+	if teamID > 0 {
+		whereClause += fmt.Sprintf(" AND team_id = $%d", argIndex)
+		args = append(args, teamID)
+		argIndex++
+	}
 
 	// Get total count
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM projects %s", whereClause)
-	var total int
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	total, err := r.db.countTotal(ctx, page, "SELECT COUNT(*) FROM projects "+whereClause, args)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count projects: %w", err)
+		return nil, nil, fmt.Errorf("failed to count projects: %w", err)
 	}
 
 	// Get projects
-	args = append(args, limit, offset)
+	cond, tail, args := pageClause("", page, args)
 	query := fmt.Sprintf(`
 		SELECT id, name, description, status, start_date, end_date, team_id, created_at, updated_at
 		FROM projects
+		%s%s
 		%s
-		ORDER BY created_at DESC
-		LIMIT $%d OFFSET $%d
-	`, whereClause, argIndex, argIndex+1)
+	`, whereClause, cond, tail)
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list projects: %w", err)
+		return nil, nil, fmt.Errorf("failed to list projects: %w", err)
 	}
 	defer rows.Close()
 
@@ -150,7 +161,7 @@ func (r *ProjectRepository) List(limit, offset int, status string) ([]*models.Pr
 			&p.UpdatedAt,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan project: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan project: %w", err)
 		}
 		if startDate.Valid {
 			p.StartDate = &startDate.String
@@ -165,11 +176,16 @@ func (r *ProjectRepository) List(limit, offset int, status string) ([]*models.Pr
 		projects = append(projects, p)
 	}
 
-	return projects, total, nil
+	projects, result := pagination.Build(page, projects, func(p *models.Project) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: p.CreatedAt, ID: int64(p.ID)}
+	})
+	result.Total = total
+
+	return projects, result, nil
 }
 
 // Update updates a project
-func (r *ProjectRepository) Update(id int, req *models.UpdateProjectRequest) (*models.Project, error) {
+func (r *ProjectRepository) Update(ctx context.Context, id int, req *models.UpdateProjectRequest) (*models.Project, error) {
 	query := `
 		UPDATE projects
 		SET name = COALESCE($2, name),
@@ -185,7 +201,7 @@ func (r *ProjectRepository) Update(id int, req *models.UpdateProjectRequest) (*m
 	var startDate, endDate sql.NullString
 	var teamID sql.NullInt64
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		query,
 		id,
 		req.Name,
@@ -227,9 +243,9 @@ func (r *ProjectRepository) Update(id int, req *models.UpdateProjectRequest) (*m
 }
 
 // Delete deletes a project
-func (r *ProjectRepository) Delete(id int) error {
+func (r *ProjectRepository) Delete(ctx context.Context, id int) error {
 	query := "DELETE FROM projects WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete project: %w", err)
 	}
@@ -240,7 +256,7 @@ func (r *ProjectRepository) Delete(id int) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("project not found")
+		return fmt.Errorf("project %w", ErrNotFound)
 	}
 
 	return nil
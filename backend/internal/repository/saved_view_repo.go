@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+)
+
+// SavedViewRepository handles database operations for saved views
+type SavedViewRepository struct {
+	db *DB
+}
+
+// NewSavedViewRepository creates a new saved view repository
+func NewSavedViewRepository(db *DB) *SavedViewRepository {
+	return &SavedViewRepository{db: db}
+}
+
+const savedViewColumns = "id, developer_id, project_id, name, query, columns, created_at, updated_at"
+
+// Create stores a new saved view
+func (r *SavedViewRepository) Create(ctx context.Context, view *models.SavedView) error {
+	query := `
+		INSERT INTO saved_views (developer_id, project_id, name, query, columns, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx,
+		query,
+		view.DeveloperID,
+		view.ProjectID,
+		view.Name,
+		view.Query,
+		view.Columns,
+		time.Now(),
+	).Scan(&view.ID, &view.CreatedAt, &view.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create saved view: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a saved view by ID
+func (r *SavedViewRepository) GetByID(ctx context.Context, id int) (*models.SavedView, error) {
+	query := "SELECT " + savedViewColumns + " FROM saved_views WHERE id = $1"
+
+	view, err := scanSavedView(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved view: %w", err)
+	}
+
+	return view, nil
+}
+
+// List retrieves the saved views of developerID and the views shared with
+// the projects visibleTo is a member of, newest first. visibleTo 0 includes
+// every shared view. When projectID is set, only the views shared with that
+// project are included.
+func (r *SavedViewRepository) List(ctx context.Context, page pagination.Params, developerID, visibleTo, projectID int) ([]*models.SavedView, *pagination.Page, error) {
+	args := []interface{}{developerID}
+	shared := "project_id IS NOT NULL"
+	if visibleTo > 0 {
+		args = append(args, visibleTo)
+		shared = fmt.Sprintf("project_id IN (SELECT project_id FROM project_members WHERE developer_id = $%d)", len(args))
+	}
+	whereClause := fmt.Sprintf("WHERE ((project_id IS NULL AND developer_id = $1) OR %s)", shared)
+	if projectID > 0 {
+		args = append(args, projectID)
+		whereClause += fmt.Sprintf(" AND project_id = $%d", len(args))
+	}
+
+	total, err := r.db.countTotal(ctx, page, "SELECT COUNT(*) FROM saved_views "+whereClause, args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count saved views: %w", err)
+	}
+
+	cond, tail, args := pageClause("", page, args)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM saved_views
+		%s%s
+		%s
+	`, savedViewColumns, whereClause, cond, tail)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list saved views: %w", err)
+	}
+	defer rows.Close()
+
+	views := []*models.SavedView{}
+	for rows.Next() {
+		view, err := scanSavedView(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan saved view: %w", err)
+		}
+		views = append(views, view)
+	}
+
+	views, result := pagination.Build(page, views, func(v *models.SavedView) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: v.CreatedAt, ID: int64(v.ID)}
+	})
+	result.Total = total
+
+	return views, result, nil
+}
+
+// Update replaces the name, query, columns and project of a saved view
+func (r *SavedViewRepository) Update(ctx context.Context, view *models.SavedView) error {
+	query := `
+		UPDATE saved_views
+		SET project_id = $2, name = $3, query = $4, columns = $5, updated_at = $6
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx,
+		query,
+		view.ID,
+		view.ProjectID,
+		view.Name,
+		view.Query,
+		view.Columns,
+		time.Now(),
+	).Scan(&view.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("saved view %w", ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update saved view: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a saved view
+func (r *SavedViewRepository) Delete(ctx context.Context, id int) error {
+	query := "DELETE FROM saved_views WHERE id = $1"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved view: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("saved view %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// scanSavedView scans a row selected with savedViewColumns
+func scanSavedView(row interface{ Scan(...interface{}) error }) (*models.SavedView, error) {
+	view := &models.SavedView{}
+	var projectID sql.NullInt64
+
+	err := row.Scan(
+		&view.ID,
+		&view.DeveloperID,
+		&projectID,
+		&view.Name,
+		&view.Query,
+		&view.Columns,
+		&view.CreatedAt,
+		&view.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	view.ProjectID = nullIntPtr(projectID)
+	return view, nil
+}
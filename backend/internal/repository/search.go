@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+// SearchQuery is a full-text search
+type SearchQuery struct {
+	// Text is what to search for, as typed by the user
+	Text string
+	// Types are the result types to search; empty means all of them
+	Types []string
+	// Limit is the number of results
+	Limit int
+	// VisibleTo limits the results to what the developer can see, as the
+	// task and project lists do; 0 means no limit
+	VisibleTo int
+}
+
+// Searcher runs full-text searches over tasks, projects and activities.
+// Security events are never searched.
+type Searcher interface {
+	// Search returns the best results for q, best first
+	Search(ctx context.Context, q SearchQuery) ([]*models.SearchResult, error)
+}
+
+// NewSearcher returns the searcher of the database's driver: tsvector
+// indexes on Postgres and FTS5 tables on SQLite
+func NewSearcher(db *DB) Searcher {
+	if db.Driver() == DriverSQLite {
+		return &sqliteSearcher{db: db}
+	}
+	return &postgresSearcher{db: db}
+}
+
+// Snippets are marked up by the database with these control characters,
+// which are replaced with <mark> elements once the text is escaped
+const (
+	markStart = "\x02"
+	markStop  = "\x03"
+)
+
+var markReplacer = strings.NewReplacer(markStart, "<mark>", markStop, "</mark>")
+
+// searchQueryFunc returns the query and arguments searching one result type.
+// The query selects the ID, title, snippet, rank, project ID, task ID and
+// creation time of the results, best first.
+type searchQueryFunc func(resultType string, q SearchQuery) (string, []interface{})
+
+// runSearch runs the query of every type asked for by q and merges their
+// results by rank
+func runSearch(ctx context.Context, db *DB, q SearchQuery, build searchQueryFunc) ([]*models.SearchResult, error) {
+	types := q.Types
+	if len(types) == 0 {
+		types = models.SearchTypes
+	}
+
+	results := []*models.SearchResult{}
+	for _, resultType := range types {
+		query, args := build(resultType, q)
+		found, err := scanSearchResults(ctx, db, resultType, query, args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %ss: %w", resultType, err)
+		}
+		results = append(results, found...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].CreatedAt.After(results[j].CreatedAt)
+	})
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+func scanSearchResults(ctx context.Context, db *DB, resultType, query string, args []interface{}) ([]*models.SearchResult, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*models.SearchResult
+	for rows.Next() {
+		res := &models.SearchResult{Type: resultType}
+		var projectID, taskID sql.NullInt64
+		if err := rows.Scan(&res.ID, &res.Title, &res.Snippet, &res.Rank, &projectID, &taskID, &res.CreatedAt); err != nil {
+			return nil, err
+		}
+		res.Snippet = markReplacer.Replace(html.EscapeString(res.Snippet))
+		res.ProjectID = nullIntPtr(projectID)
+		res.TaskID = nullIntPtr(taskID)
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// taskVisibleClause returns the condition limiting the tasks aliased by
+// prefix, e.g. "t.", to those developer param can see: tasks in their
+// projects, tasks without a project and tasks assigned to them
+func taskVisibleClause(prefix, param string) string {
+	return fmt.Sprintf(
+		"(%[1]sproject_id IS NULL OR %[1]sassignee_id = %[2]s OR %[1]sproject_id IN (SELECT project_id FROM project_members WHERE developer_id = %[2]s))",
+		prefix, param,
+	)
+}
+
+// activityVisibleClause returns the condition limiting the activities
+// aliased by a to those developer param can see. Activities of tasks are
+// visible with their task; other activities are visible to the members of
+// their project and to the developer who caused them.
+func activityVisibleClause(param string) string {
+	return fmt.Sprintf(
+		"((a.task_id IS NULL AND (a.developer_id = %[1]s OR a.project_id IN (SELECT project_id FROM project_members WHERE developer_id = %[1]s)))"+
+			" OR a.task_id IN (SELECT vt.id FROM tasks vt WHERE %[2]s))",
+		param, taskVisibleClause("vt.", param),
+	)
+}
+
+// searchVisibility returns the conditions limiting the results of a search
+// to what q.VisibleTo can see, and args with their arguments appended
+func searchVisibility(resultType string, q SearchQuery, args []interface{}) (string, []interface{}) {
+	cond := ""
+	if resultType == models.SearchTypeActivity {
+		args = append(args, models.SecurityActionPrefix+"%")
+		cond = fmt.Sprintf(" AND a.action NOT LIKE $%d", len(args))
+	}
+	if q.VisibleTo == 0 {
+		return cond, args
+	}
+
+	args = append(args, q.VisibleTo)
+	param := fmt.Sprintf("$%d", len(args))
+	switch resultType {
+	case models.SearchTypeTask:
+		cond += " AND " + taskVisibleClause("t.", param)
+	case models.SearchTypeProject:
+		cond += fmt.Sprintf(" AND p.id IN (SELECT project_id FROM project_members WHERE developer_id = %s)", param)
+	case models.SearchTypeActivity:
+		cond += " AND " + activityVisibleClause(param)
+	}
+	return cond, args
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+// postgresSearcher searches the tsvector columns of migration 015. The text
+// is read by websearch_to_tsquery, which accepts "quoted phrases", OR and
+// -excluded words and never fails on malformed input.
+type postgresSearcher struct {
+	db *DB
+}
+
+// headlineOptions are the ts_headline options of the snippets
+const headlineOptions = "StartSel=" + markStart + ", StopSel=" + markStop + ", MaxWords=30, MinWords=10, MaxFragments=2"
+
+// Search implements Searcher
+func (s *postgresSearcher) Search(ctx context.Context, q SearchQuery) ([]*models.SearchResult, error) {
+	return runSearch(ctx, s.db, q, s.query)
+}
+
+func (s *postgresSearcher) query(resultType string, q SearchQuery) (string, []interface{}) {
+	args := []interface{}{q.Text, headlineOptions}
+	cond, args := searchVisibility(resultType, q, args)
+	args = append(args, q.Limit)
+	limit := len(args)
+
+	switch resultType {
+	case models.SearchTypeTask:
+		return fmt.Sprintf(`
+			SELECT t.id, t.title, ts_headline('english', t.title || ' ' || t.description, query, $2),
+			       ts_rank(t.search_vector, query), t.project_id, NULL, t.created_at
+			FROM tasks t
+			CROSS JOIN websearch_to_tsquery('english', $1) query
+			WHERE t.search_vector @@ query%s
+			ORDER BY 4 DESC, t.id DESC
+			LIMIT $%d
+		`, cond, limit), args
+	case models.SearchTypeProject:
+		return fmt.Sprintf(`
+			SELECT p.id, p.name, ts_headline('english', p.name || ' ' || p.description, query, $2),
+			       ts_rank(p.search_vector, query), p.id, NULL, p.created_at
+			FROM projects p
+			CROSS JOIN websearch_to_tsquery('english', $1) query
+			WHERE p.search_vector @@ query%s
+			ORDER BY 4 DESC, p.id DESC
+			LIMIT $%d
+		`, cond, limit), args
+	default:
+		return fmt.Sprintf(`
+			SELECT a.id, a.action, ts_headline('english', a.description, query, $2),
+			       ts_rank(a.search_vector, query), COALESCE(t.project_id, a.project_id), a.task_id, a.created_at
+			FROM activities a
+			LEFT JOIN tasks t ON t.id = a.task_id
+			CROSS JOIN websearch_to_tsquery('english', $1) query
+			WHERE a.search_vector @@ query%s
+			ORDER BY 4 DESC, a.id DESC
+			LIMIT $%d
+		`, cond, limit), args
+	}
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+// sqliteSearcher searches the FTS5 tables of the SQLite migration 015. It
+// understands the same words, "quoted phrases", OR and -excluded words as
+// the Postgres searcher, though it ranks and stems a little differently.
+type sqliteSearcher struct {
+	db *DB
+}
+
+// Search implements Searcher
+func (s *sqliteSearcher) Search(ctx context.Context, q SearchQuery) ([]*models.SearchResult, error) {
+	match := ftsQuery(q.Text)
+	if match == "" {
+		return []*models.SearchResult{}, nil
+	}
+
+	return runSearch(ctx, s.db, q, func(resultType string, q SearchQuery) (string, []interface{}) {
+		return s.query(resultType, match, q)
+	})
+}
+
+func (s *sqliteSearcher) query(resultType, match string, q SearchQuery) (string, []interface{}) {
+	args := []interface{}{match, markStart, markStop}
+	cond, args := searchVisibility(resultType, q, args)
+	args = append(args, q.Limit)
+	limit := len(args)
+
+	switch resultType {
+	case models.SearchTypeTask:
+		return fmt.Sprintf(`
+			SELECT t.id, t.title, snippet(tasks_fts, -1, $2, $3, '…', 16),
+			       -bm25(tasks_fts, 2.0, 1.0), t.project_id, NULL, t.created_at
+			FROM tasks_fts
+			JOIN tasks t ON t.id = tasks_fts.rowid
+			WHERE tasks_fts MATCH $1%s
+			ORDER BY 4 DESC, t.id DESC
+			LIMIT $%d
+		`, cond, limit), args
+	case models.SearchTypeProject:
+		return fmt.Sprintf(`
+			SELECT p.id, p.name, snippet(projects_fts, -1, $2, $3, '…', 16),
+			       -bm25(projects_fts, 2.0, 1.0), p.id, NULL, p.created_at
+			FROM projects_fts
+			JOIN projects p ON p.id = projects_fts.rowid
+			WHERE projects_fts MATCH $1%s
+			ORDER BY 4 DESC, p.id DESC
+			LIMIT $%d
+		`, cond, limit), args
+	default:
+		return fmt.Sprintf(`
+			SELECT a.id, a.action, snippet(activities_fts, -1, $2, $3, '…', 16),
+			       -bm25(activities_fts), COALESCE(t.project_id, a.project_id), a.task_id, a.created_at
+			FROM activities_fts
+			JOIN activities a ON a.id = activities_fts.rowid
+			LEFT JOIN tasks t ON t.id = a.task_id
+			WHERE activities_fts MATCH $1%s
+			ORDER BY 4 DESC, a.id DESC
+			LIMIT $%d
+		`, cond, limit), args
+	}
+}
+
+// ftsQuery turns search text into an FTS5 query. Only letters and digits of
+// the text reach the query, quoted, so it cannot be malformed. It returns ""
+// when there is nothing to search for.
+func ftsQuery(text string) string {
+	var include, exclude []string
+	pendingOr := false
+	add := func(term string, negated bool) {
+		switch {
+		case negated:
+			exclude = append(exclude, term)
+		case pendingOr && len(include) > 0:
+			include = append(include, "OR", term)
+		default:
+			include = append(include, term)
+		}
+		pendingOr = false
+	}
+
+	// Odd parts are inside double quotes
+	for i, part := range strings.Split(text, `"`) {
+		if i%2 == 1 {
+			if words := ftsWords(part); len(words) > 0 {
+				add(`"`+strings.Join(words, " ")+`"`, false)
+			}
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			if field == "OR" || field == "or" {
+				pendingOr = true
+				continue
+			}
+			negated := strings.HasPrefix(field, "-")
+			for _, word := range ftsWords(field) {
+				add(`"`+word+`"`, negated)
+			}
+		}
+	}
+
+	if len(include) == 0 {
+		return ""
+	}
+	query := strings.Join(include, " ")
+	if len(exclude) > 0 {
+		query = "(" + query + ")"
+		for _, term := range exclude {
+			query += " NOT " + term
+		}
+	}
+	return query
+}
+
+// ftsWords splits s into words of letters and digits
+func ftsWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+// SessionRepository handles database operations for sessions and refresh tokens
+type SessionRepository struct {
+	db *DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create creates a new session
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	now := time.Now()
+
+	query := `
+		INSERT INTO sessions (id, developer_id, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.ExecContext(ctx,
+		query,
+		session.ID,
+		session.DeveloperID,
+		session.UserAgent,
+		session.IPAddress,
+		now,
+		now,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	session.CreatedAt = now
+	session.LastUsedAt = now
+	return nil
+}
+
+// GetByID retrieves a session by ID
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	query := `
+		SELECT id, developer_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at, revoked_reason
+		FROM sessions
+		WHERE id = $1
+	`
+
+	session := &models.Session{}
+	var revokedAt sql.NullTime
+	var revokedReason sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&session.ID,
+		&session.DeveloperID,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&revokedAt,
+		&revokedReason,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	if revokedReason.Valid {
+		session.RevokedReason = revokedReason.String
+	}
+
+	return session, nil
+}
+
+// ListActiveByDeveloper retrieves a developer's sessions that are neither revoked nor expired
+func (r *SessionRepository) ListActiveByDeveloper(ctx context.Context, developerID int) ([]*models.Session, error) {
+	query := `
+		SELECT id, developer_id, user_agent, ip_address, created_at, last_used_at, expires_at
+		FROM sessions
+		WHERE developer_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, developerID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		s := &models.Session{}
+		err := rows.Scan(
+			&s.ID,
+			&s.DeveloperID,
+			&s.UserAgent,
+			&s.IPAddress,
+			&s.CreatedAt,
+			&s.LastUsedAt,
+			&s.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, nil
+}
+
+// MarkUsed records that an access token of the session was used. Writes are
+// skipped while the previous timestamp is younger than minInterval.
+func (r *SessionRepository) MarkUsed(ctx context.Context, id, ipAddress string, minInterval time.Duration) error {
+	now := time.Now()
+
+	query := `
+		UPDATE sessions
+		SET last_used_at = $2, ip_address = $3
+		WHERE id = $1 AND last_used_at < $4
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, now, ipAddress, now.Add(-minInterval))
+	if err != nil {
+		return fmt.Errorf("failed to mark session used: %w", err)
+	}
+
+	return nil
+}
+
+// Touch records a use of the session and extends its expiry
+func (r *SessionRepository) Touch(ctx context.Context, id, userAgent, ipAddress string, expiresAt time.Time) error {
+	query := `
+		UPDATE sessions
+		SET user_agent = $2, ip_address = $3, last_used_at = $4, expires_at = $5
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, userAgent, ipAddress, time.Now(), expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return nil
+}
+
+// Revoke revokes a session. Revoking an already revoked session keeps the original reason.
+func (r *SessionRepository) Revoke(ctx context.Context, id, reason string) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = $2, revoked_reason = $3
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, time.Now(), reason)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllForDeveloper revokes every active session of a developer and
+// returns how many were revoked
+func (r *SessionRepository) RevokeAllForDeveloper(ctx context.Context, developerID int, reason string) (int, error) {
+	query := `
+		UPDATE sessions
+		SET revoked_at = $2, revoked_reason = $3
+		WHERE developer_id = $1 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, developerID, time.Now(), reason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return int(rows), nil
+}
+
+// CreateRefreshToken stores a refresh token hash
+func (r *SessionRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (session_id, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, token.SessionID, token.TokenHash, time.Now(), token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by its hash
+func (r *SessionRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	query := `
+		SELECT id, session_id, token_hash, created_at, expires_at, rotated_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+
+	token := &models.RefreshToken{}
+	var rotatedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.ID,
+		&token.SessionID,
+		&token.TokenHash,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&rotatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if rotatedAt.Valid {
+		token.RotatedAt = &rotatedAt.Time
+	}
+
+	return token, nil
+}
+
+// MarkRefreshTokenRotated marks a refresh token as used. It returns false if
+// the token had already been rotated, which means it is being reused.
+func (r *SessionRepository) MarkRefreshTokenRotated(ctx context.Context, id int) (bool, error) {
+	query := "UPDATE refresh_tokens SET rotated_at = $2 WHERE id = $1 AND rotated_at IS NULL"
+	result, err := r.db.ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows == 1, nil
+}
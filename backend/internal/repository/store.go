@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+	"github.com/ardani17/taskmanager/internal/taskquery"
+)
+
+// Handlers and services depend on these stores rather than on the
+// repositories, so they can run against any implementation. The repositories
+// implement them for both Postgres and SQLite.
+
+// TaskStore stores tasks
+type TaskStore interface {
+	// Create stores a new task and fills in its ID and timestamps
+	Create(ctx context.Context, task *models.Task) error
+	// GetByID returns a task, or nil if it does not exist
+	GetByID(ctx context.Context, id int) (*models.Task, error)
+	// List returns a page of the tasks matching filter, in its sort order.
+	// visibleTo limits them to tasks the developer can see; 0 means no
+	// limit.
+	List(ctx context.Context, page pagination.Params, filter *taskquery.Filter, visibleTo int) ([]*models.Task, *pagination.Page, error)
+	// ListByProject returns every task of a project, oldest first
+	ListByProject(ctx context.Context, projectID int) ([]*models.Task, error)
+	// Update applies the set fields of req and returns the task, or nil
+	Update(ctx context.Context, id int, req *models.UpdateTaskRequest) (*models.Task, error)
+	// UpdateStatus changes the status of a task
+	UpdateStatus(ctx context.Context, id int, status string) error
+	// Delete removes a task; the error wraps ErrNotFound if it does not exist.
+	// Its subtasks become top-level tasks.
+	Delete(ctx context.Context, id int) error
+	// Move moves a task and its subtasks under parentID, or to the top level
+	// when it is nil, and into projectID. It fails with ErrTaskCycle if the
+	// parent is in the task's subtree.
+	Move(ctx context.Context, id int, parentID, projectID *int) error
+	// Rollup sums up the subtasks of a task at every depth
+	Rollup(ctx context.Context, id int) (*models.TaskRollup, error)
+	// CountOpenSubtasks counts the direct subtasks of a task not yet done
+	CountOpenSubtasks(ctx context.Context, id int) (int, error)
+}
+
+// ProjectStore stores projects
+type ProjectStore interface {
+	// Create stores a new project and fills in its ID and timestamps
+	Create(ctx context.Context, project *models.Project) error
+	// GetByID returns a project, or nil if it does not exist
+	GetByID(ctx context.Context, id int) (*models.Project, error)
+	// List returns a page of projects, newest first. memberID limits them to
+	// those the developer is a member of and teamID to those of the team; 0
+	// means no limit.
+	List(ctx context.Context, page pagination.Params, status string, memberID, teamID int) ([]*models.Project, *pagination.Page, error)
+	// Update applies the set fields of req and returns the project, or nil
+	Update(ctx context.Context, id int, req *models.UpdateProjectRequest) (*models.Project, error)
+	// Delete removes a project; the error wraps ErrNotFound if it does not exist
+	Delete(ctx context.Context, id int) error
+}
+
+// DeveloperStore stores developers and their credentials
+type DeveloperStore interface {
+	// Create stores a new developer and fills in its ID and timestamps
+	Create(ctx context.Context, developer *models.Developer) error
+	// GetByID returns a developer, or nil if it does not exist
+	GetByID(ctx context.Context, id int) (*models.Developer, error)
+	// GetByEmail returns the developer with the email, including the
+	// password hash, or nil
+	GetByEmail(ctx context.Context, email string) (*models.Developer, error)
+	// GetByOIDCSubject returns the developer linked to an identity provider
+	// account, or nil
+	GetByOIDCSubject(ctx context.Context, issuer, subject string) (*models.Developer, error)
+	// List returns a page of developers, newest first; teamID limits them to
+	// a team, 0 means no limit
+	List(ctx context.Context, page pagination.Params, teamID int) ([]*models.Developer, *pagination.Page, error)
+	// Update applies the set fields of req and returns the developer, or nil
+	Update(ctx context.Context, id int, req *models.UpdateDeveloperRequest) (*models.Developer, error)
+	// UpdateStatus changes the presence status of a developer
+	UpdateStatus(ctx context.Context, id int, status string) error
+	// UpdatePassword replaces the password hash of a developer
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	// MarkEmailVerified records that the developer verified their email
+	MarkEmailVerified(ctx context.Context, id int) error
+	// LinkOIDC links a developer to an identity provider account
+	LinkOIDC(ctx context.Context, id int, issuer, subject string) error
+	// UpdateRole changes the role of a developer
+	UpdateRole(ctx context.Context, id int, role string) error
+	// SetTeam moves a developer to a team, or out of any team when teamID
+	// is nil; the error wraps ErrNotFound if the developer does not exist
+	SetTeam(ctx context.Context, id int, teamID *int) error
+	// Delete removes a developer; the error wraps ErrNotFound if it does not exist
+	Delete(ctx context.Context, id int) error
+}
+
+// ActivityStore stores the activity feed
+type ActivityStore interface {
+	// Create stores a new activity and fills in its ID and creation time
+	Create(ctx context.Context, activity *models.Activity) error
+	// List returns a page of activities, newest first, leaving out security
+	// events; visibleTo, developerID and taskID filter them, 0 means no filter
+	List(ctx context.Context, page pagination.Params, visibleTo, developerID, taskID int) ([]*models.Activity, *pagination.Page, error)
+	// ListSecurityEvents returns a page of security events, newest first
+	ListSecurityEvents(ctx context.Context, page pagination.Params) ([]*models.Activity, *pagination.Page, error)
+}
+
+var (
+	_ TaskStore      = (*TaskRepository)(nil)
+	_ ProjectStore   = (*ProjectRepository)(nil)
+	_ DeveloperStore = (*DeveloperRepository)(nil)
+	_ ActivityStore  = (*ActivityRepository)(nil)
+)
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+	"github.com/ardani17/taskmanager/internal/taskquery"
+)
+
+// taskField is how a filter or sort field of a task list reads in SQL
+type taskField struct {
+	expr     string
+	nullable bool
+	// sortExpr, if set, is sorted by instead of expr
+	sortExpr string
+	// key returns the sort key of a task, nil when it is NULL, and parse
+	// reads it back from a cursor
+	key   func(t *models.Task) interface{}
+	parse func(v interface{}) (interface{}, bool)
+}
+
+// taskFields maps the fields of taskquery to the tasks table
+var taskFields = map[taskquery.Field]taskField{
+	taskquery.FieldStatus: {
+		expr:     "status",
+		sortExpr: rankExpr("status", taskquery.Statuses),
+		key:      func(t *models.Task) interface{} { return rank(t.Status, taskquery.Statuses) },
+		parse:    parseIntKey,
+	},
+	taskquery.FieldPriority: {
+		expr:     "priority",
+		sortExpr: rankExpr("priority", taskquery.Priorities),
+		key:      func(t *models.Task) interface{} { return rank(t.Priority, taskquery.Priorities) },
+		parse:    parseIntKey,
+	},
+	taskquery.FieldAssignee: {expr: "assignee_id", nullable: true},
+	taskquery.FieldProject:  {expr: "project_id", nullable: true},
+	taskquery.FieldTeam:     {expr: "(SELECT team_id FROM projects WHERE projects.id = tasks.project_id)", nullable: true},
+	taskquery.FieldParent:   {expr: "parent_id", nullable: true},
+	taskquery.FieldDue: {
+		expr:     "due_date",
+		nullable: true,
+		key: func(t *models.Task) interface{} {
+			if t.DueDate == nil {
+				return nil
+			}
+			return *t.DueDate
+		},
+		parse: parseTimeKey,
+	},
+	taskquery.FieldCreated: {
+		expr:  "created_at",
+		key:   func(t *models.Task) interface{} { return t.CreatedAt },
+		parse: parseTimeKey,
+	},
+	taskquery.FieldUpdated: {
+		expr:  "updated_at",
+		key:   func(t *models.Task) interface{} { return t.UpdatedAt },
+		parse: parseTimeKey,
+	},
+	// Tasks without an estimate are listed with 0 hours, so they filter and
+	// sort as 0 hours too
+	taskquery.FieldEstimate: {
+		expr:  "COALESCE(estimated_hours, 0)",
+		key:   func(t *models.Task) interface{} { return t.EstimatedHours },
+		parse: parseFloatKey,
+	},
+	taskquery.FieldTitle: {
+		expr: "title",
+		key:  func(t *models.Task) interface{} { return t.Title },
+		parse: func(v interface{}) (interface{}, bool) {
+			s, ok := v.(string)
+			return s, ok
+		},
+	},
+}
+
+// taskFilterClause returns the conditions of filter f, each starting with
+// AND, and args with their arguments appended
+func taskFilterClause(f *taskquery.Filter, args []interface{}) (string, []interface{}) {
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var clause strings.Builder
+	for _, c := range f.Conditions {
+		field := taskFields[c.Field]
+
+		var matches []string
+		switch {
+		case len(c.Values) > 0:
+			params := make([]string, len(c.Values))
+			for i, v := range c.Values {
+				params[i] = arg(v)
+			}
+			matches = append(matches, fmt.Sprintf("%s IN (%s)", field.expr, strings.Join(params, ", ")))
+		case len(c.IDs) > 0:
+			params := make([]string, len(c.IDs))
+			for i, id := range c.IDs {
+				params[i] = arg(id)
+			}
+			matches = append(matches, fmt.Sprintf("%s IN (%s)", field.expr, strings.Join(params, ", ")))
+		}
+		if c.Null {
+			matches = append(matches, field.expr+" IS NULL")
+		}
+
+		if len(matches) > 0 {
+			match := strings.Join(matches, " OR ")
+			if c.Not {
+				// NOT IN is never true for NULLs, which do not match the values
+				match = fmt.Sprintf("NOT (%s)", match)
+				if !c.Null && field.nullable {
+					match = fmt.Sprintf("(%s OR %s IS NULL)", match, field.expr)
+				}
+			}
+			fmt.Fprintf(&clause, " AND (%s)", match)
+		}
+
+		if c.Min != nil {
+			op := ">"
+			if c.Min.Inclusive {
+				op = ">="
+			}
+			fmt.Fprintf(&clause, " AND %s %s %s", field.expr, op, arg(c.Min.Value))
+		}
+		if c.Max != nil {
+			op := "<"
+			if c.Max.Inclusive {
+				op = "<="
+			}
+			fmt.Fprintf(&clause, " AND %s %s %s", field.expr, op, arg(c.Max.Value))
+		}
+	}
+
+	for _, text := range f.Text {
+		fmt.Fprintf(&clause, ` AND LOWER(title) LIKE %s ESCAPE '\'`, arg("%"+escapeLike(strings.ToLower(text))+"%"))
+	}
+
+	return clause.String(), args
+}
+
+// taskOrder returns the sort keys of filter f, ending with the ID, and the
+// keys of the cursor c read back for them. ok is false if c was issued for
+// another order.
+func taskOrder(f *taskquery.Filter, c *pagination.Cursor) (keys []orderKey, values []interface{}, ok bool) {
+	for _, key := range f.Sort {
+		field := taskFields[key.Field]
+		expr := field.expr
+		if field.sortExpr != "" {
+			expr = field.sortExpr
+		}
+		keys = append(keys, orderKey{expr: expr, desc: key.Desc, nullable: field.nullable})
+	}
+	keys = append(keys, orderKey{expr: "id", desc: keys[len(keys)-1].desc})
+
+	if c == nil {
+		return keys, nil, true
+	}
+	if c.Sort != f.SortString() || len(c.Values) != len(f.Sort) {
+		return nil, nil, false
+	}
+	for i, key := range f.Sort {
+		if c.Values[i] == nil {
+			values = append(values, nil)
+			continue
+		}
+		v, ok := taskFields[key.Field].parse(c.Values[i])
+		if !ok {
+			return nil, nil, false
+		}
+		values = append(values, v)
+	}
+	return keys, append(values, c.ID), true
+}
+
+// taskCursor returns the cursor pointing at task t in the order of filter f
+func taskCursor(f *taskquery.Filter, t *models.Task) pagination.Cursor {
+	c := pagination.Cursor{CreatedAt: t.CreatedAt, ID: int64(t.ID)}
+	if len(f.Sort) > 0 {
+		c.Sort = f.SortString()
+		for _, key := range f.Sort {
+			c.Values = append(c.Values, taskFields[key.Field].key(t))
+		}
+	}
+	return c
+}
+
+// rankExpr returns an expression numbering the values of column in order,
+// so that they sort by meaning rather than alphabetically; unknown values
+// come last. The values are constants, never user input.
+func rankExpr(column string, values []string) string {
+	var expr strings.Builder
+	expr.WriteString("CASE " + column)
+	for i, v := range values {
+		fmt.Fprintf(&expr, " WHEN '%s' THEN %d", v, i)
+	}
+	fmt.Fprintf(&expr, " ELSE %d END", len(values))
+	return expr.String()
+}
+
+// rank returns the position of v in values as rankExpr does
+func rank(v string, values []string) interface{} {
+	for i, value := range values {
+		if value == v {
+			return i
+		}
+	}
+	return len(values)
+}
+
+// escapeLike escapes the LIKE wildcards in s, with backslash as the escape
+// character
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func parseIntKey(v interface{}) (interface{}, bool) {
+	f, ok := v.(float64)
+	return int(f), ok
+}
+
+func parseFloatKey(v interface{}) (interface{}, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func parseTimeKey(v interface{}) (interface{}, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+// Errors of TaskLinkRepository.Create
+var (
+	ErrLinkExists = errors.New("tasks are already linked")
+	ErrLinkCycle  = errors.New("link would create a cycle")
+)
+
+// LinkCycleError is the ErrLinkCycle returned by Create. TaskIDs lists the
+// tasks of the cycle the link would close, starting at the lowest ID, each
+// linked to the next and the last to the first.
+type LinkCycleError struct {
+	TaskIDs []int
+}
+
+func (e *LinkCycleError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrLinkCycle, e.TaskIDs)
+}
+
+// Is makes errors.Is(err, ErrLinkCycle) match
+func (e *LinkCycleError) Is(target error) bool {
+	return target == ErrLinkCycle
+}
+
+// TaskLinkRepository handles database operations for links between tasks
+type TaskLinkRepository struct {
+	db *DB
+}
+
+// NewTaskLinkRepository creates a new task link repository
+func NewTaskLinkRepository(db *DB) *TaskLinkRepository {
+	return &TaskLinkRepository{db: db}
+}
+
+const taskLinkColumns = "id, source_id, target_id, type, created_by, created_at"
+
+// Create stores a new link. It fails with ErrLinkExists if the tasks are
+// already linked that way, and with ErrLinkCycle if a blocks or duplicates
+// link would lead back to its source. Run it in a unit of work, which holds
+// off concurrent links until it commits.
+func (r *TaskLinkRepository) Create(ctx context.Context, link *models.TaskLink) error {
+	var count int
+	query := "SELECT COUNT(*) FROM task_links WHERE source_id = $1 AND target_id = $2 AND type = $3"
+	if err := r.db.QueryRowContext(ctx, query, link.SourceID, link.TargetID, link.Type).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check task links: %w", err)
+	}
+	if count > 0 {
+		return ErrLinkExists
+	}
+
+	if link.Type != models.LinkRelatesTo {
+		if err := r.db.lock(ctx, lockTaskLinks); err != nil {
+			return err
+		}
+
+		// Links of the type reachable from the target must not include the source
+		cycle, err := r.findCycle(ctx, link)
+		if err != nil {
+			return err
+		}
+		if cycle != nil {
+			return &LinkCycleError{TaskIDs: cycle}
+		}
+	}
+
+	query = `
+		INSERT INTO task_links (source_id, target_id, type, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRowContext(ctx,
+		query,
+		link.SourceID,
+		link.TargetID,
+		link.Type,
+		link.CreatedBy,
+		time.Now(),
+	).Scan(&link.ID, &link.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create task link: %w", err)
+	}
+
+	return nil
+}
+
+// findCycle returns the cycle a new link would close, or nil. It follows the
+// links of the new link's type from its target and returns the shortest way
+// back to its source.
+func (r *TaskLinkRepository) findCycle(ctx context.Context, link *models.TaskLink) ([]int, error) {
+	query := `
+		WITH RECURSIVE reachable(id) AS (
+			SELECT CAST($1 AS INTEGER)
+			UNION
+			SELECT task_links.target_id FROM task_links
+			JOIN reachable ON task_links.source_id = reachable.id
+			WHERE task_links.type = $2
+		)
+		SELECT task_links.source_id, task_links.target_id FROM task_links
+		JOIN reachable ON task_links.source_id = reachable.id
+		WHERE task_links.type = $2
+		ORDER BY task_links.source_id, task_links.target_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, link.TargetID, link.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check task links: %w", err)
+	}
+	defer rows.Close()
+
+	next := map[int][]int{}
+	for rows.Next() {
+		var sourceID, targetID int
+		if err := rows.Scan(&sourceID, &targetID); err != nil {
+			return nil, fmt.Errorf("failed to scan task link: %w", err)
+		}
+		next[sourceID] = append(next[sourceID], targetID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check task links: %w", err)
+	}
+
+	// Breadth-first from the target, remembering how each task was reached
+	from := map[int]int{link.TargetID: link.TargetID}
+	queue := []int{link.TargetID}
+	for len(queue) > 0 && link.SourceID != link.TargetID {
+		id := queue[0]
+		queue = queue[1:]
+		for _, n := range next[id] {
+			if _, ok := from[n]; !ok {
+				from[n] = id
+				queue = append(queue, n)
+			}
+		}
+		if _, ok := from[link.SourceID]; ok {
+			break
+		}
+	}
+	if _, ok := from[link.SourceID]; !ok {
+		return nil, nil
+	}
+
+	// The way back runs from the source to the target; the new link closes it
+	var walk []int
+	for id := link.SourceID; id != link.TargetID; id = from[id] {
+		walk = append(walk, id)
+	}
+	walk = append(walk, link.TargetID)
+
+	// walk lists each task before the one linking to it; the cycle starts at its lowest ID
+	first := 0
+	for i, id := range walk {
+		if id < walk[first] {
+			first = i
+		}
+	}
+	cycle := make([]int, 0, len(walk))
+	for i := range walk {
+		cycle = append(cycle, walk[(first-i+len(walk))%len(walk)])
+	}
+	return cycle, nil
+}
+
+// GetByID retrieves a link by ID
+func (r *TaskLinkRepository) GetByID(ctx context.Context, id int) (*models.TaskLink, error) {
+	query := "SELECT " + taskLinkColumns + " FROM task_links WHERE id = $1"
+
+	link, err := scanTaskLink(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task link: %w", err)
+	}
+
+	return link, nil
+}
+
+// ListByTask retrieves the links from and to a task, oldest first
+func (r *TaskLinkRepository) ListByTask(ctx context.Context, taskID int) ([]*models.TaskLink, error) {
+	query := "SELECT " + taskLinkColumns + " FROM task_links WHERE source_id = $1 OR target_id = $1 ORDER BY id"
+	return r.list(ctx, query, taskID)
+}
+
+// ListByProject retrieves the links between the tasks of a project, oldest
+// first. Links to tasks of other projects are left out.
+func (r *TaskLinkRepository) ListByProject(ctx context.Context, projectID int) ([]*models.TaskLink, error) {
+	query := `
+		SELECT l.id, l.source_id, l.target_id, l.type, l.created_by, l.created_at
+		FROM task_links l
+		JOIN tasks s ON s.id = l.source_id
+		JOIN tasks t ON t.id = l.target_id
+		WHERE s.project_id = $1 AND t.project_id = $1
+		ORDER BY l.id
+	`
+	return r.list(ctx, query, projectID)
+}
+
+// OpenBlockers returns the IDs of the tasks blocking a task that are not
+// done yet
+func (r *TaskLinkRepository) OpenBlockers(ctx context.Context, taskID int) ([]int, error) {
+	query := `
+		SELECT t.id
+		FROM task_links l
+		JOIN tasks t ON t.id = l.source_id
+		WHERE l.target_id = $1 AND l.type = $2 AND t.status <> 'done'
+		ORDER BY t.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, taskID, models.LinkBlocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blockers: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan blocker: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Delete removes a link
+func (r *TaskLinkRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM task_links WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task link: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("task link %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *TaskLinkRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.TaskLink, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []*models.TaskLink{}
+	for rows.Next() {
+		link, err := scanTaskLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task link: %w", err)
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// scanTaskLink scans a row selected with taskLinkColumns
+func scanTaskLink(row interface{ Scan(...interface{}) error }) (*models.TaskLink, error) {
+	link := &models.TaskLink{}
+	var createdBy sql.NullInt64
+
+	err := row.Scan(&link.ID, &link.SourceID, &link.TargetID, &link.Type, &createdBy, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	link.CreatedBy = nullIntPtr(createdBy)
+	return link, nil
+}
@@ -1,11 +1,15 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+	"github.com/ardani17/taskmanager/internal/taskquery"
 )
 
 // TaskRepository handles database operations for tasks
@@ -18,26 +22,39 @@ func NewTaskRepository(db *DB) *TaskRepository {
 	return &TaskRepository{db: db}
 }
 
+// taskColumns are the columns of a task, read by scanTask
+const taskColumns = `
+	id, title, description, status, priority, project_id, parent_id, assignee_id,
+	due_date, CAST(COALESCE(estimated_hours, 0) AS DOUBLE PRECISION), CAST(COALESCE(actual_hours, 0) AS DOUBLE PRECISION),
+	auto_complete, created_by, created_at, updated_at
+`
+
+// ErrTaskCycle is returned when a task would become its own ancestor
+var ErrTaskCycle = errors.New("task cannot be its own ancestor")
+
 // Create creates a new task
-func (r *TaskRepository) Create(task *models.Task) error {
+func (r *TaskRepository) Create(ctx context.Context, task *models.Task) error {
 	now := time.Now()
 
 	query := `
-		INSERT INTO tasks (title, description, status, priority, project_id, assignee_id, due_date, estimated_hours, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO tasks (title, description, status, priority, project_id, parent_id, assignee_id, due_date, estimated_hours, auto_complete, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		query,
 		task.Title,
 		task.Description,
 		task.Status,
 		task.Priority,
 		task.ProjectID,
+		task.ParentID,
 		task.AssigneeID,
 		task.DueDate,
 		task.EstimatedHours,
+		task.AutoComplete,
+		task.CreatedBy,
 		now,
 		now,
 	).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
@@ -50,30 +67,10 @@ func (r *TaskRepository) Create(task *models.Task) error {
 }
 
 // GetByID retrieves a task by ID
-func (r *TaskRepository) GetByID(id int) (*models.Task, error) {
-	query := `
-		SELECT id, title, description, status, priority, project_id, assignee_id, 
-		       due_date, COALESCE(estimated_hours, 0)::float, COALESCE(actual_hours, 0)::float, created_at, updated_at
-		FROM tasks
-		WHERE id = $1
-	`
-
-	task := &models.Task{}
-	err := r.db.QueryRow(query, id).Scan(
-		&task.ID,
-		&task.Title,
-		&task.Description,
-		&task.Status,
-		&task.Priority,
-		&task.ProjectID,
-		&task.AssigneeID,
-		&task.DueDate,
-		&task.EstimatedHours,
-		&task.ActualHours,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
+func (r *TaskRepository) GetByID(ctx context.Context, id int) (*models.Task, error) {
+	query := "SELECT " + taskColumns + " FROM tasks WHERE id = $1"
 
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -84,77 +81,90 @@ func (r *TaskRepository) GetByID(id int) (*models.Task, error) {
 	return task, nil
 }
 
-// List retrieves all tasks with pagination and filters
-func (r *TaskRepository) List(limit, offset int, status, priority string) ([]*models.Task, int, error) {
+// List retrieves a page of the tasks matching filter, in its sort order.
+// When visibleTo is set, only tasks in that developer's projects, tasks
+// without a project and tasks assigned to them are returned. A cursor issued
+// for another sort order fails with pagination.ErrInvalidCursor.
+func (r *TaskRepository) List(ctx context.Context, page pagination.Params, filter *taskquery.Filter, visibleTo int) ([]*models.Task, *pagination.Page, error) {
 	// Build query with filters
-	whereClause := "WHERE 1=1"
-	args := []interface{}{}
-	argIndex := 1
+	whereClause, args := taskFilterClause(filter, []interface{}{})
+	whereClause = "WHERE 1=1" + whereClause
 
-	if status != "" {
-		whereClause += fmt.Sprintf(" AND status = $%d", argIndex)
-		args = append(args, status)
-		argIndex++
-	}
-	if priority != "" {
-		whereClause += fmt.Sprintf(" AND priority = $%d", argIndex)
-		args = append(args, priority)
-		argIndex++
+	if visibleTo > 0 {
+		args = append(args, visibleTo)
+		whereClause += " AND " + taskVisibleClause("", fmt.Sprintf("$%d", len(args)))
 	}
 
 	// Get total count
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM tasks %s", whereClause)
-	var total int
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	total, err := r.db.countTotal(ctx, page, "SELECT COUNT(*) FROM tasks "+whereClause, args)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count tasks: %w", err)
+		return nil, nil, fmt.Errorf("failed to count tasks: %w", err)
 	}
 
 	// Get tasks
-	args = append(args, limit, offset)
+	var cond, tail string
+	if len(filter.Sort) == 0 {
+		cond, tail, args = pageClause("", page, args)
+	} else {
+		keys, values, ok := taskOrder(filter, page.Cursor)
+		if !ok {
+			return nil, nil, fmt.Errorf("failed to list tasks: %w", pagination.ErrInvalidCursor)
+		}
+		cond, tail, args = keysetClause(keys, values, page, args)
+	}
 	query := fmt.Sprintf(`
-		SELECT id, title, description, status, priority, project_id, assignee_id, 
-		       due_date, COALESCE(estimated_hours, 0)::float, COALESCE(actual_hours, 0)::float, created_at, updated_at
+		SELECT %s
 		FROM tasks
+		%s%s
 		%s
-		ORDER BY created_at DESC
-		LIMIT $%d OFFSET $%d
-	`, whereClause, argIndex, argIndex+1)
+	`, taskColumns, whereClause, cond, tail)
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list tasks: %w", err)
+		return nil, nil, fmt.Errorf("failed to list tasks: %w", err)
 	}
 	defer rows.Close()
 
 	var tasks []*models.Task
 	for rows.Next() {
-		t := &models.Task{}
-		err := rows.Scan(
-			&t.ID,
-			&t.Title,
-			&t.Description,
-			&t.Status,
-			&t.Priority,
-			&t.ProjectID,
-			&t.AssigneeID,
-			&t.DueDate,
-			&t.EstimatedHours,
-			&t.ActualHours,
-			&t.CreatedAt,
-			&t.UpdatedAt,
-		)
+		t, err := scanTask(rows)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan task: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan task: %w", err)
 		}
 		tasks = append(tasks, t)
 	}
 
-	return tasks, total, nil
+	tasks, result := pagination.Build(page, tasks, func(t *models.Task) pagination.Cursor {
+		return taskCursor(filter, t)
+	})
+	result.Total = total
+
+	return tasks, result, nil
+}
+
+// ListByProject retrieves every task of a project, oldest first
+func (r *TaskRepository) ListByProject(ctx context.Context, projectID int) ([]*models.Task, error) {
+	query := "SELECT " + taskColumns + " FROM tasks WHERE project_id = $1 ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []*models.Task{}
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
 }
 
 // Update updates a task
-func (r *TaskRepository) Update(id int, req *models.UpdateTaskRequest) (*models.Task, error) {
+func (r *TaskRepository) Update(ctx context.Context, id int, req *models.UpdateTaskRequest) (*models.Task, error) {
 	query := `
 		UPDATE tasks
 		SET title = COALESCE($2, title),
@@ -166,14 +176,12 @@ func (r *TaskRepository) Update(id int, req *models.UpdateTaskRequest) (*models.
 		    due_date = COALESCE($8, due_date),
 		    estimated_hours = COALESCE($9, estimated_hours),
 		    actual_hours = COALESCE($10, actual_hours),
-		    updated_at = $11
+		    auto_complete = COALESCE($11, auto_complete),
+		    updated_at = $12
 		WHERE id = $1
-		RETURNING id, title, description, status, priority, project_id, assignee_id, 
-		          due_date, COALESCE(estimated_hours, 0)::float, COALESCE(actual_hours, 0)::float, created_at, updated_at
-	`
+		RETURNING ` + taskColumns
 
-	task := &models.Task{}
-	err := r.db.QueryRow(
+	task, err := scanTask(r.db.QueryRowContext(ctx,
 		query,
 		id,
 		req.Title,
@@ -185,21 +193,9 @@ func (r *TaskRepository) Update(id int, req *models.UpdateTaskRequest) (*models.
 		req.DueDate,
 		req.EstimatedHours,
 		req.ActualHours,
+		req.AutoComplete,
 		time.Now(),
-	).Scan(
-		&task.ID,
-		&task.Title,
-		&task.Description,
-		&task.Status,
-		&task.Priority,
-		&task.ProjectID,
-		&task.AssigneeID,
-		&task.DueDate,
-		&task.EstimatedHours,
-		&task.ActualHours,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -212,9 +208,9 @@ func (r *TaskRepository) Update(id int, req *models.UpdateTaskRequest) (*models.
 }
 
 // UpdateStatus updates task status
-func (r *TaskRepository) UpdateStatus(id int, status string) error {
+func (r *TaskRepository) UpdateStatus(ctx context.Context, id int, status string) error {
 	query := "UPDATE tasks SET status = $2, updated_at = $3 WHERE id = $1"
-	_, err := r.db.Exec(query, id, status, time.Now())
+	_, err := r.db.ExecContext(ctx, query, id, status, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to update task status: %w", err)
 	}
@@ -222,9 +218,9 @@ func (r *TaskRepository) UpdateStatus(id int, status string) error {
 }
 
 // Delete deletes a task
-func (r *TaskRepository) Delete(id int) error {
+func (r *TaskRepository) Delete(ctx context.Context, id int) error {
 	query := "DELETE FROM tasks WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete task: %w", err)
 	}
@@ -235,8 +231,119 @@ func (r *TaskRepository) Delete(id int) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("task not found")
+		return fmt.Errorf("task %w", ErrNotFound)
 	}
 
 	return nil
 }
+
+// subtreeQuery selects the IDs of task $1 and all of its subtasks
+const subtreeQuery = `
+	WITH RECURSIVE subtree(id) AS (
+		SELECT id FROM tasks WHERE id = $1
+		UNION
+		SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id
+	)
+	SELECT id FROM subtree
+`
+
+// Move moves a task and its subtasks under another parent, or to the top
+// level when parentID is nil, and into projectID. It fails with ErrTaskCycle
+// if the parent is the task or one of its subtasks. Run it in a unit of work,
+// which holds off concurrent moves until it commits.
+func (r *TaskRepository) Move(ctx context.Context, id int, parentID, projectID *int) error {
+	if parentID != nil {
+		if err := r.db.lock(ctx, lockTaskTree); err != nil {
+			return err
+		}
+
+		var cycles int
+		query := "SELECT COUNT(*) FROM (" + subtreeQuery + ") subtree WHERE id = $2"
+		if err := r.db.QueryRowContext(ctx, query, id, *parentID).Scan(&cycles); err != nil {
+			return fmt.Errorf("failed to check task hierarchy: %w", err)
+		}
+		if cycles > 0 {
+			return ErrTaskCycle
+		}
+	}
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, "UPDATE tasks SET parent_id = $2, updated_at = $3 WHERE id = $1", id, parentID, now)
+	if err != nil {
+		return fmt.Errorf("failed to move task: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("task %w", ErrNotFound)
+	}
+
+	// Subtasks are always in the project of the task they belong to
+	query := "UPDATE tasks SET project_id = $2, updated_at = $3 WHERE id IN (" + subtreeQuery + ")"
+	if _, err := r.db.ExecContext(ctx, query, id, projectID, now); err != nil {
+		return fmt.Errorf("failed to move subtasks: %w", err)
+	}
+
+	return nil
+}
+
+// Rollup sums up the subtasks of a task at every depth
+func (r *TaskRepository) Rollup(ctx context.Context, id int) (*models.TaskRollup, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN id <> $1 THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN id <> $1 AND status = 'done' THEN 1 ELSE 0 END), 0),
+		       CAST(COALESCE(SUM(estimated_hours), 0) AS DOUBLE PRECISION),
+		       CAST(COALESCE(SUM(actual_hours), 0) AS DOUBLE PRECISION)
+		FROM tasks
+		WHERE id IN (` + subtreeQuery + `)
+	`
+
+	rollup := &models.TaskRollup{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&rollup.Subtasks, &rollup.Done, &rollup.EstimatedHours, &rollup.ActualHours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll up subtasks: %w", err)
+	}
+
+	if rollup.Subtasks > 0 {
+		rollup.Progress = rollup.Done * 100 / rollup.Subtasks
+	}
+	return rollup, nil
+}
+
+// CountOpenSubtasks counts the direct subtasks of a task that are not done
+func (r *TaskRepository) CountOpenSubtasks(ctx context.Context, id int) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM tasks WHERE parent_id = $1 AND status <> 'done'"
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count open subtasks: %w", err)
+	}
+	return count, nil
+}
+
+// scanTask scans a row selected with taskColumns
+func scanTask(row interface{ Scan(...interface{}) error }) (*models.Task, error) {
+	task := &models.Task{}
+	err := row.Scan(
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&task.Status,
+		&task.Priority,
+		&task.ProjectID,
+		&task.ParentID,
+		&task.AssigneeID,
+		&task.DueDate,
+		&task.EstimatedHours,
+		&task.ActualHours,
+		&task.AutoComplete,
+		&task.CreatedBy,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+)
+
+// TeamRepository handles database operations for teams
+type TeamRepository struct {
+	db *DB
+}
+
+// NewTeamRepository creates a new team repository
+func NewTeamRepository(db *DB) *TeamRepository {
+	return &TeamRepository{db: db}
+}
+
+// Create creates a new team
+func (r *TeamRepository) Create(ctx context.Context, team *models.Team) error {
+	now := time.Now()
+
+	query := `
+		INSERT INTO teams (name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, team.Name, team.Description, now, now).
+		Scan(&team.ID, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create team: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a team by ID
+func (r *TeamRepository) GetByID(ctx context.Context, id int) (*models.Team, error) {
+	query := `
+		SELECT t.id, t.name, t.description, t.created_at, t.updated_at,
+		       (SELECT COUNT(*) FROM developers d WHERE d.team_id = t.id)
+		FROM teams t
+		WHERE t.id = $1
+	`
+
+	team := &models.Team{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&team.ID,
+		&team.Name,
+		&team.Description,
+		&team.CreatedAt,
+		&team.UpdatedAt,
+		&team.MemberCount,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	return team, nil
+}
+
+// GetByName retrieves a team by name
+func (r *TeamRepository) GetByName(ctx context.Context, name string) (*models.Team, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, "SELECT id FROM teams WHERE name = $1", name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team by name: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// List retrieves teams with their member counts, by name. A cursor issued
+// for another list fails with pagination.ErrInvalidCursor.
+func (r *TeamRepository) List(ctx context.Context, page pagination.Params) ([]*models.Team, *pagination.Page, error) {
+	// Get total count
+	total, err := r.db.countTotal(ctx, page, "SELECT COUNT(*) FROM teams", nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count teams: %w", err)
+	}
+
+	// Get teams, keyed by name and ID
+	keys := []orderKey{{expr: "t.name"}, {expr: "t.id"}}
+	var values []interface{}
+	if c := page.Cursor; c != nil {
+		if c.Sort != teamSort || len(c.Values) != 1 {
+			return nil, nil, fmt.Errorf("failed to list teams: %w", pagination.ErrInvalidCursor)
+		}
+		name, ok := c.Values[0].(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("failed to list teams: %w", pagination.ErrInvalidCursor)
+		}
+		values = []interface{}{name, c.ID}
+	}
+	cond, tail, args := keysetClause(keys, values, page, nil)
+	query := fmt.Sprintf(`
+		SELECT t.id, t.name, t.description, t.created_at, t.updated_at,
+		       (SELECT COUNT(*) FROM developers d WHERE d.team_id = t.id)
+		FROM teams t
+		WHERE 1=1%s
+		%s
+	`, cond, tail)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	defer rows.Close()
+
+	var teams []*models.Team
+	for rows.Next() {
+		t := &models.Team{}
+		err := rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Description,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+			&t.MemberCount,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		teams = append(teams, t)
+	}
+
+	teams, result := pagination.Build(page, teams, func(t *models.Team) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: t.CreatedAt, ID: int64(t.ID), Sort: teamSort, Values: []interface{}{t.Name}}
+	})
+	result.Total = total
+
+	return teams, result, nil
+}
+
+// teamSort marks the cursors of the team list
+const teamSort = "name"
+
+// Update updates a team
+func (r *TeamRepository) Update(ctx context.Context, id int, req *models.UpdateTeamRequest) (*models.Team, error) {
+	query := `
+		UPDATE teams
+		SET name = COALESCE(NULLIF($2, ''), name),
+		    description = COALESCE(NULLIF($3, ''), description),
+		    updated_at = $4
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, req.Name, req.Description, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update team: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return nil, nil
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// Delete deletes a team. Developers and projects of the team are unlinked.
+func (r *TeamRepository) Delete(ctx context.Context, id int) error {
+	query := "DELETE FROM teams WHERE id = $1"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("team %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// TaskStats returns task counts by status for the projects of each team.
+// When teamID is set, only that team is included. When memberID is set, only
+// the projects that developer is a member of are counted.
+func (r *TeamRepository) TaskStats(ctx context.Context, teamID, memberID int) ([]*models.TeamTaskStats, error) {
+	projectClause := ""
+	whereClause := ""
+	args := []interface{}{}
+	if memberID > 0 {
+		args = append(args, memberID)
+		projectClause = fmt.Sprintf(" AND p.id IN (SELECT project_id FROM project_members WHERE developer_id = $%d)", len(args))
+	}
+	if teamID > 0 {
+		args = append(args, teamID)
+		whereClause = fmt.Sprintf("WHERE t.id = $%d", len(args))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT t.id, t.name, tk.status, COUNT(tk.id)
+		FROM teams t
+		LEFT JOIN projects p ON p.team_id = t.id%s
+		LEFT JOIN tasks tk ON tk.project_id = p.id
+		%s
+		GROUP BY t.id, t.name, tk.status
+		ORDER BY t.name ASC
+	`, projectClause, whereClause)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team task stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*models.TeamTaskStats
+	byTeam := make(map[int]*models.TeamTaskStats)
+	for rows.Next() {
+		var id, count int
+		var name string
+		var status sql.NullString
+		if err := rows.Scan(&id, &name, &status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan team task stats: %w", err)
+		}
+
+		s, ok := byTeam[id]
+		if !ok {
+			s = &models.TeamTaskStats{
+				TeamID:   id,
+				TeamName: name,
+				StatusCounts: map[string]int{
+					"todo": 0, "in_progress": 0, "review": 0, "done": 0,
+				},
+			}
+			byTeam[id] = s
+			stats = append(stats, s)
+		}
+
+		// Teams without tasks produce a single row with a NULL status
+		if status.Valid {
+			s.StatusCounts[status.String] += count
+			s.Total += count
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TwoFactorRepository handles database operations for TOTP secrets,
+// recovery codes and the roles that require two-factor authentication
+type TwoFactorRepository struct {
+	db *DB
+}
+
+// NewTwoFactorRepository creates a new two-factor repository
+func NewTwoFactorRepository(db *DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// GetSecret retrieves a developer's TOTP secret and whether enrollment was confirmed.
+// The secret is empty when the developer never started enrolling.
+func (r *TwoFactorRepository) GetSecret(ctx context.Context, developerID int) (string, bool, error) {
+	query := `
+		SELECT totp_secret, totp_enabled_at IS NOT NULL
+		FROM developers
+		WHERE id = $1
+	`
+
+	var secret sql.NullString
+	var enabled bool
+	err := r.db.QueryRowContext(ctx, query, developerID).Scan(&secret, &enabled)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get TOTP secret: %w", err)
+	}
+
+	return secret.String, enabled, nil
+}
+
+// SetPendingSecret stores a secret for an enrollment that still has to be confirmed
+func (r *TwoFactorRepository) SetPendingSecret(ctx context.Context, developerID int, secret string) error {
+	query := `
+		UPDATE developers
+		SET totp_secret = $2, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = $3
+		WHERE id = $1 AND totp_enabled_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, developerID, secret, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+
+	return nil
+}
+
+// Enable confirms a pending enrollment
+func (r *TwoFactorRepository) Enable(ctx context.Context, developerID int) error {
+	now := time.Now()
+
+	query := `
+		UPDATE developers
+		SET totp_enabled_at = $2, updated_at = $2
+		WHERE id = $1 AND totp_secret IS NOT NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, developerID, now)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes a developer's secret and recovery codes
+func (r *TwoFactorRepository) Disable(ctx context.Context, developerID int) error {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE developers
+		SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = $2
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, developerID, time.Now()); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE developer_id = $1", developerID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AdvanceStep records the time step of an accepted TOTP code. It returns
+// false if a code from this or a later step was already accepted, so every
+// code works only once.
+func (r *TwoFactorRepository) AdvanceStep(ctx context.Context, developerID int, step int64) (bool, error) {
+	query := `
+		UPDATE developers
+		SET totp_last_step = $2
+		WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, developerID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// ReplaceRecoveryCodes replaces every recovery code of a developer
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, developerID int, codeHashes []string) error {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE developer_id = $1", developerID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	now := time.Now()
+	for _, hash := range codeHashes {
+		query := "INSERT INTO recovery_codes (developer_id, code_hash, created_at) VALUES ($1, $2, $3)"
+		if _, err := tx.ExecContext(ctx, query, developerID, hash, now); err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used. It returns false if
+// the developer has no such unused code.
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, developerID int, codeHash string) (bool, error) {
+	query := `
+		UPDATE recovery_codes
+		SET used_at = $3
+		WHERE id = (
+			SELECT id FROM recovery_codes
+			WHERE developer_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, developerID, codeHash, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes a developer has left
+func (r *TwoFactorRepository) CountUnusedRecoveryCodes(ctx context.Context, developerID int) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM recovery_codes WHERE developer_id = $1 AND used_at IS NULL"
+	if err := r.db.QueryRowContext(ctx, query, developerID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// RequiredRoles lists the roles that require two-factor authentication
+func (r *TwoFactorRepository) RequiredRoles(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT role FROM two_factor_required_roles ORDER BY role ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list two-factor roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("failed to scan two-factor role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+// IsRequiredForRole reports whether members of a role must use two-factor authentication
+func (r *TwoFactorRepository) IsRequiredForRole(ctx context.Context, role string) (bool, error) {
+	var exists bool
+	query := "SELECT EXISTS(SELECT 1 FROM two_factor_required_roles WHERE role = $1)"
+	if err := r.db.QueryRowContext(ctx, query, role).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check two-factor role: %w", err)
+	}
+
+	return exists, nil
+}
+
+// SetRequiredRoles replaces the roles that require two-factor authentication
+func (r *TwoFactorRepository) SetRequiredRoles(ctx context.Context, roles []string) error {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM two_factor_required_roles"); err != nil {
+		return fmt.Errorf("failed to clear two-factor roles: %w", err)
+	}
+
+	now := time.Now()
+	for _, role := range roles {
+		query := "INSERT INTO two_factor_required_roles (role, created_at) VALUES ($1, $2) ON CONFLICT (role) DO NOTHING"
+		if _, err := tx.ExecContext(ctx, query, role, now); err != nil {
+			return fmt.Errorf("failed to add two-factor role: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreateChallenge stores a pending two-factor login and removes expired ones
+func (r *TwoFactorRepository) CreateChallenge(ctx context.Context, id string, developerID int, expiresAt time.Time) error {
+	now := time.Now()
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM two_factor_challenges WHERE expires_at < $1", now); err != nil {
+		return fmt.Errorf("failed to delete expired challenges: %w", err)
+	}
+
+	query := `
+		INSERT INTO two_factor_challenges (id, developer_id, failures, created_at, expires_at)
+		VALUES ($1, $2, 0, $3, $4)
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, developerID, now, expiresAt); err != nil {
+		return fmt.Errorf("failed to create challenge: %w", err)
+	}
+	return nil
+}
+
+// ChallengeActive reports whether a challenge of the developer is pending:
+// not used, burned by wrong codes, or expired
+func (r *TwoFactorRepository) ChallengeActive(ctx context.Context, id string, developerID int) (bool, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM two_factor_challenges WHERE id = $1 AND developer_id = $2 AND expires_at > $3"
+	if err := r.db.QueryRowContext(ctx, query, id, developerID, time.Now()).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check challenge: %w", err)
+	}
+	return count > 0, nil
+}
+
+// FailChallenge counts a wrong code against a challenge and removes the
+// challenge once it has maxFailures of them
+func (r *TwoFactorRepository) FailChallenge(ctx context.Context, id string, maxFailures int) error {
+	if _, err := r.db.ExecContext(ctx, "UPDATE two_factor_challenges SET failures = failures + 1 WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to record challenge failure: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM two_factor_challenges WHERE id = $1 AND failures >= $2", id, maxFailures); err != nil {
+		return fmt.Errorf("failed to delete challenge: %w", err)
+	}
+	return nil
+}
+
+// UseChallenge removes a challenge after a successful login. It returns false
+// if the challenge was already gone, so every challenge logs in only once.
+func (r *TwoFactorRepository) UseChallenge(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM two_factor_challenges WHERE id = $1 AND expires_at > $2", id, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to use challenge: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows == 1, nil
+}
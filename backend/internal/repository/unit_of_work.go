@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// UnitOfWork runs a mutation together with the activity and audit entries
+// that record it in one transaction, so they are stored together or not at
+// all
+type UnitOfWork struct {
+	db *DB
+}
+
+// NewUnitOfWork creates a new unit of work
+func NewUnitOfWork(db *DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do runs fn in a transaction. The repositories fn gets from tx run their
+// queries in that transaction. It commits when fn returns nil and rolls back
+// when fn returns an error or panics, or when ctx is cancelled first, e.g.
+// because the client went away.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(tx *Tx) error) error {
+	sqlTx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	if err := fn(&Tx{db: &DB{DB: u.db.DB, tx: sqlTx, driver: u.db.driver}}); err != nil {
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Tx gives access to repositories bound to a unit of work's transaction
+type Tx struct {
+	db *DB
+}
+
+// Tasks returns the task repository of the transaction
+func (t *Tx) Tasks() TaskStore {
+	return NewTaskRepository(t.db)
+}
+
+// TaskLinks returns the task link repository of the transaction
+func (t *Tx) TaskLinks() *TaskLinkRepository {
+	return NewTaskLinkRepository(t.db)
+}
+
+// Projects returns the project repository of the transaction
+func (t *Tx) Projects() ProjectStore {
+	return NewProjectRepository(t.db)
+}
+
+// ProjectMembers returns the project member repository of the transaction
+func (t *Tx) ProjectMembers() *ProjectMemberRepository {
+	return NewProjectMemberRepository(t.db)
+}
+
+// Developers returns the developer repository of the transaction
+func (t *Tx) Developers() DeveloperStore {
+	return NewDeveloperRepository(t.db)
+}
+
+// Teams returns the team repository of the transaction
+func (t *Tx) Teams() *TeamRepository {
+	return NewTeamRepository(t.db)
+}
+
+// Invitations returns the invitation repository of the transaction
+func (t *Tx) Invitations() *InvitationRepository {
+	return NewInvitationRepository(t.db)
+}
+
+// Activities returns the activity repository of the transaction
+func (t *Tx) Activities() ActivityStore {
+	return NewActivityRepository(t.db)
+}
+
+// Audit returns the audit repository of the transaction
+func (t *Tx) Audit() *AuditRepository {
+	return NewAuditRepository(t.db)
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/mailer"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/pkg/utils"
+)
+
+// Lifetimes of emailed account tokens
+const (
+	verifyEmailTokenExpiry   = 48 * time.Hour
+	passwordResetTokenExpiry = time.Hour
+)
+
+// ErrInvalidAccountToken is returned for unknown, used or expired account tokens
+var ErrInvalidAccountToken = errors.New("token is invalid or has expired")
+
+// AccountService handles email verification and password reset.
+//
+// Both flows email the developer a random token. Only its hash is stored, it
+// expires, and it can be redeemed once.
+type AccountService struct {
+	tokenRepo      *repository.AccountTokenRepository
+	userRepo       repository.DeveloperStore
+	sessionService *SessionService
+	mailer         mailer.Mailer
+	appURL         string
+}
+
+// NewAccountService creates a new account service. appURL is the base URL of
+// the frontend, used to build the links in emails.
+func NewAccountService(tokenRepo *repository.AccountTokenRepository, userRepo repository.DeveloperStore, sessionService *SessionService, mailer mailer.Mailer, appURL string) *AccountService {
+	return &AccountService{
+		tokenRepo:      tokenRepo,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		mailer:         mailer,
+		appURL:         appURL,
+	}
+}
+
+// SendVerification emails a developer a link to verify their address
+func (s *AccountService) SendVerification(ctx context.Context, developer *models.Developer) error {
+	token, err := s.issue(ctx, developer.ID, models.TokenPurposeVerifyEmail, verifyEmailTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      developer.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+			developer.Name, s.link("/verify-email", token), verifyEmailTokenExpiry),
+	})
+}
+
+// VerifyEmail redeems a verification token
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	stored, err := s.redeem(ctx, token, models.TokenPurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	return s.userRepo.MarkEmailVerified(ctx, stored.DeveloperID)
+}
+
+// RequestPasswordReset emails a password reset link. Unknown addresses are
+// ignored so callers cannot find out which emails are registered.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	developer, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if developer == nil {
+		return nil
+	}
+
+	token, err := s.issue(ctx, developer.ID, models.TokenPurposePasswordReset, passwordResetTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      developer.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. To choose a new password, open the link below:\n\n%s\n\nThe link expires in %s. If you did not ask for this, you can ignore this email.\n",
+			developer.Name, s.link("/reset-password", token), passwordResetTokenExpiry),
+	})
+}
+
+// ResetPassword redeems a reset token and sets a new password. Every session
+// of the developer is revoked, so stolen tokens stop working.
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	stored, err := s.redeem(ctx, token, models.TokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, stored.DeveloperID, passwordHash); err != nil {
+		return err
+	}
+
+	// Receiving the email proves the developer owns the address
+	if err := s.userRepo.MarkEmailVerified(ctx, stored.DeveloperID); err != nil {
+		return err
+	}
+
+	_, err = s.sessionService.RevokeAll(ctx, stored.DeveloperID, models.RevokeReasonPasswordReset)
+	return err
+}
+
+// issue creates a token, replacing any unused token for the same purpose
+func (s *AccountService) issue(ctx context.Context, developerID int, purpose string, expiry time.Duration) (string, error) {
+	if err := s.tokenRepo.InvalidateForDeveloper(ctx, developerID, purpose); err != nil {
+		return "", err
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	stored := &models.AccountToken{
+		DeveloperID: developerID,
+		Purpose:     purpose,
+		TokenHash:   utils.HashToken(token),
+		ExpiresAt:   time.Now().Add(expiry),
+	}
+	if err := s.tokenRepo.Create(ctx, stored); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// redeem looks up a token and marks it as used
+func (s *AccountService) redeem(ctx context.Context, token, purpose string) (*models.AccountToken, error) {
+	stored, err := s.tokenRepo.GetByHash(ctx, utils.HashToken(token), purpose)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, ErrInvalidAccountToken
+	}
+
+	consumed, err := s.tokenRepo.Consume(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidAccountToken
+	}
+
+	return stored, nil
+}
+
+// link builds a frontend URL carrying a token
+func (s *AccountService) link(path, token string) string {
+	return s.appURL + path + "?token=" + url.QueryEscape(token)
+}
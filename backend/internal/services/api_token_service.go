@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/pkg/utils"
+)
+
+// APITokenPrefix starts every personal API token, which tells them apart from JWTs
+const APITokenPrefix = "tm_pat_"
+
+// API token errors
+var (
+	ErrInvalidAPIToken = errors.New("invalid, revoked or expired API token")
+	ErrScopeNotAllowed = errors.New("only admins can create tokens with the admin scope")
+)
+
+// APITokenService manages personal API tokens for scripts and CI
+type APITokenService struct {
+	repo *repository.APITokenRepository
+}
+
+// NewAPITokenService creates a new API token service
+func NewAPITokenService(repo *repository.APITokenRepository) *APITokenService {
+	return &APITokenService{repo: repo}
+}
+
+// IsAPIToken reports whether a bearer token is a personal API token rather than a JWT
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// Create issues a new token for a developer. The returned token string is
+// not stored and cannot be retrieved again.
+func (s *APITokenService) Create(ctx context.Context, developer *models.Developer, req *models.CreateAPITokenRequest) (string, *models.APIToken, error) {
+	if developer.Role != models.RoleAdmin && models.ScopesAllow(req.Scopes, models.ScopeAdmin) {
+		return "", nil, ErrScopeNotAllowed
+	}
+
+	random, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate API token: %w", err)
+	}
+	secret := APITokenPrefix + random
+
+	token := &models.APIToken{
+		DeveloperID: developer.ID,
+		Name:        req.Name,
+		Prefix:      secret[:len(APITokenPrefix)+4],
+		TokenHash:   utils.HashToken(secret),
+		Scopes:      req.Scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.repo.Create(ctx, token); err != nil {
+		return "", nil, err
+	}
+
+	return secret, token, nil
+}
+
+// List returns a developer's active tokens
+func (s *APITokenService) List(ctx context.Context, developerID int) ([]*models.APIToken, error) {
+	return s.repo.ListByDeveloper(ctx, developerID)
+}
+
+// Revoke revokes one of a developer's tokens. It returns false if there is no such token.
+func (s *APITokenService) Revoke(ctx context.Context, developerID, tokenID int) (bool, error) {
+	return s.repo.Revoke(ctx, tokenID, developerID)
+}
+
+// RevokeAll revokes every token of a developer and returns how many were revoked
+func (s *APITokenService) RevokeAll(ctx context.Context, developerID int) (int, error) {
+	return s.repo.RevokeAllForDeveloper(ctx, developerID)
+}
+
+// Authenticate looks up an active token and records its use
+func (s *APITokenService) Authenticate(ctx context.Context, secret, ipAddress string) (*models.APIToken, error) {
+	token, err := s.repo.GetByHash(ctx, utils.HashToken(secret))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || !token.IsActive() {
+		return nil, ErrInvalidAPIToken
+	}
+
+	if err := s.repo.MarkUsed(ctx, token.ID, ipAddress, lastUsedResolution); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/mailer"
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/pagination"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/pkg/utils"
+)
+
+// invitationExpiry is how long an invitation can be accepted
+const invitationExpiry = 7 * 24 * time.Hour
+
+// Invitation errors
+var (
+	ErrInvalidInvitation = errors.New("invitation is invalid, has been used or has expired")
+	ErrEmailRegistered   = errors.New("email is already registered")
+	ErrInvitationProject = errors.New("project not found")
+	ErrInvitationTeam    = errors.New("team not found")
+	ErrInvitationNotSent = errors.New("failed to send invitation email")
+)
+
+// InvitationService handles invitation-based onboarding.
+//
+// An invitation fixes the email, global role and optional project membership
+// and team of the account it creates. The invitee gets a random token by
+// email; only its hash is stored, it expires, and it can be accepted once.
+type InvitationService struct {
+	repo        *repository.InvitationRepository
+	userRepo    repository.DeveloperStore
+	projectRepo repository.ProjectStore
+	teamRepo    *repository.TeamRepository
+	mailer      mailer.Mailer
+	appURL      string
+}
+
+// NewInvitationService creates a new invitation service. appURL is the base
+// URL of the frontend, used to build the link in the invitation email.
+func NewInvitationService(repo *repository.InvitationRepository, userRepo repository.DeveloperStore, projectRepo repository.ProjectStore, teamRepo *repository.TeamRepository, mailer mailer.Mailer, appURL string) *InvitationService {
+	return &InvitationService{
+		repo:        repo,
+		userRepo:    userRepo,
+		projectRepo: projectRepo,
+		teamRepo:    teamRepo,
+		mailer:      mailer,
+		appURL:      appURL,
+	}
+}
+
+// WithTx returns a copy of the service whose reads and writes run in the
+// transaction of a unit of work
+func (s *InvitationService) WithTx(tx *repository.Tx) *InvitationService {
+	scoped := *s
+	scoped.repo = tx.Invitations()
+	scoped.userRepo = tx.Developers()
+	scoped.projectRepo = tx.Projects()
+	scoped.teamRepo = tx.Teams()
+	return &scoped
+}
+
+// Create stores an invitation and returns it with the email that carries its
+// token. The email is sent with Send once the invitation is committed, so it
+// never links to an invitation that was rolled back. The request must have
+// been validated.
+func (s *InvitationService) Create(ctx context.Context, inviter *models.Developer, req *models.CreateInvitationRequest) (*models.Invitation, *mailer.Message, error) {
+	existing, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing != nil {
+		return nil, nil, ErrEmailRegistered
+	}
+
+	var projectName string
+	if req.ProjectID != nil {
+		project, err := s.projectRepo.GetByID(ctx, *req.ProjectID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if project == nil {
+			return nil, nil, ErrInvitationProject
+		}
+		projectName = project.Name
+	}
+	if req.TeamID != nil {
+		team, err := s.teamRepo.GetByID(ctx, *req.TeamID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if team == nil {
+			return nil, nil, ErrInvitationTeam
+		}
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	invitation := &models.Invitation{
+		Email:       req.Email,
+		Role:        req.Role,
+		ProjectID:   req.ProjectID,
+		ProjectRole: req.ProjectRole,
+		TeamID:      req.TeamID,
+		InvitedBy:   &inviter.ID,
+		TokenHash:   utils.HashToken(token),
+		ExpiresAt:   time.Now().Add(invitationExpiry),
+	}
+	if err := s.repo.Create(ctx, invitation); err != nil {
+		return nil, nil, err
+	}
+
+	joining := "Task Manager"
+	if projectName != "" {
+		joining = "the project " + projectName + " on Task Manager"
+	}
+	message := &mailer.Message{
+		To:      invitation.Email,
+		Subject: "You have been invited to Task Manager",
+		Body: fmt.Sprintf("Hi,\n\n%s has invited you to join %s. To create your account, open the link below:\n\n%s\n\nThe invitation expires in %s.\n",
+			inviter.Name, joining, s.appURL+"/accept-invitation?token="+url.QueryEscape(token), invitationExpiry),
+	}
+
+	return invitation, message, nil
+}
+
+// Send emails an invitation created by Create. Nobody can accept an
+// invitation that never arrived, so the caller should revoke it when this
+// fails.
+func (s *InvitationService) Send(message *mailer.Message) error {
+	if err := s.mailer.Send(message); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvitationNotSent, err)
+	}
+	return nil
+}
+
+// Accept redeems an invitation and creates the invitee's account. The
+// invitee only chooses a name and password; everything else comes from the
+// invitation.
+func (s *InvitationService) Accept(ctx context.Context, token, name, password string) (*models.Developer, *models.Invitation, error) {
+	hash := utils.HashToken(token)
+
+	invitation, err := s.repo.GetByHash(ctx, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if invitation == nil || invitation.Status != models.InvitationPending {
+		return nil, nil, ErrInvalidInvitation
+	}
+
+	existing, err := s.userRepo.GetByEmail(ctx, invitation.Email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing != nil {
+		return nil, nil, ErrEmailRegistered
+	}
+
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	developer := &models.Developer{
+		Name:         name,
+		PasswordHash: passwordHash,
+		Status:       "active",
+	}
+	invitation, err = s.repo.Accept(ctx, hash, developer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if invitation == nil {
+		return nil, nil, ErrInvalidInvitation
+	}
+
+	return developer, invitation, nil
+}
+
+// Get returns an invitation by ID, or nil
+func (s *InvitationService) Get(ctx context.Context, id int) (*models.Invitation, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// List returns invitations, newest first. When invitedBy is set, only the
+// invitations sent by that developer are included.
+func (s *InvitationService) List(ctx context.Context, page pagination.Params, invitedBy int) ([]*models.Invitation, *pagination.Page, error) {
+	return s.repo.List(ctx, page, invitedBy)
+}
+
+// Revoke revokes a pending invitation. It returns false if it is no longer pending.
+func (s *InvitationService) Revoke(ctx context.Context, id int) (bool, error) {
+	return s.repo.Revoke(ctx, id)
+}
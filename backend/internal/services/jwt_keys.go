@@ -0,0 +1,165 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key set errors
+var (
+	ErrNoSigningKey   = errors.New("signing key not found in key set")
+	ErrUnsupportedKey = errors.New("unsupported key type, expected RSA or Ed25519")
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing or verification
+const minRSAKeyBits = 2048
+
+// SigningKey is an asymmetric key used to sign or verify tokens.
+// Keys loaded from a public key file can only verify.
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	PublicKey crypto.PublicKey
+}
+
+// KeySet holds the key used to sign new tokens and every key whose tokens
+// are still accepted.
+//
+// Rotating keys: add the new key next to the old one, switch the signing key
+// ID to it, and remove the old key once the tokens it signed have expired
+// (the refresh token lifetime).
+type KeySet struct {
+	signing *SigningKey
+	keys    map[string]*SigningKey
+	ids     []string
+}
+
+// LoadKeySet loads every .pem file of dir. The file name without extension
+// is the key ID. signingKeyID selects the key that signs new tokens; it must
+// be a private key.
+func LoadKeySet(dir, signingKeyID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	keys := make([]*SigningKey, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+		}
+
+		key, err := ParseSigningKey(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(signingKeyID, keys...)
+}
+
+// NewKeySet creates a key set from already parsed keys
+func NewKeySet(signingKeyID string, keys ...*SigningKey) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*SigningKey, len(keys))}
+	for _, key := range keys {
+		if _, exists := set.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+		set.keys[key.ID] = key
+		set.ids = append(set.ids, key.ID)
+	}
+	sort.Strings(set.ids)
+
+	signing, ok := set.keys[signingKeyID]
+	if !ok || signing.Private == nil {
+		return nil, fmt.Errorf("%w: %q", ErrNoSigningKey, signingKeyID)
+	}
+	set.signing = signing
+
+	return set, nil
+}
+
+// ParseSigningKey parses a PEM encoded RSA or Ed25519 key. Both private keys
+// (PKCS#1 or PKCS#8) and public keys (PKIX) are accepted.
+func ParseSigningKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: id}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.PublicKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	if pub, ok := key.PublicKey.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+	}
+
+	return key, nil
+}
+
+// Signing returns the key that signs new tokens
+func (s *KeySet) Signing() *SigningKey {
+	return s.signing
+}
+
+// Get returns the key with the given ID, or nil
+func (s *KeySet) Get(id string) *SigningKey {
+	return s.keys[id]
+}
+
+// JWKS returns the public keys as a JSON Web Key Set
+func (s *KeySet) JWKS() jose.JSONWebKeySet {
+	set := jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, 0, len(s.ids))}
+	for _, id := range s.ids {
+		key := s.keys[id]
+		set.Keys = append(set.Keys, jose.JSONWebKey{
+			Key:       key.PublicKey,
+			KeyID:     key.ID,
+			Algorithm: key.Method.Alg(),
+			Use:       "sig",
+		})
+	}
+	return set
+}
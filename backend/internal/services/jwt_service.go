@@ -5,21 +5,46 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-jose/go-jose/v4"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// JWTService handles JWT token operations
+// JWTService handles JWT token operations.
+//
+// Tokens are signed with HS256 and a shared secret, or, when a key set is
+// configured, with the set's RS256 or EdDSA signing key. Asymmetric tokens
+// carry the key ID in their kid header so they can be verified by other
+// services through the JWKS endpoint.
+//
+// Every token names the service as its issuer, and has an audience of its
+// own per token type (see Audience), so a refresh or challenge token is
+// rejected wherever an access token is expected.
 type JWTService struct {
 	secretKey     string
+	keys          *KeySet
+	issuer        string
 	tokenExpiry   time.Duration
 	refreshExpiry time.Duration
 }
 
+// DefaultIssuer is the issuer of tokens when none is configured
+const DefaultIssuer = "taskmanager"
+
+// Token types carried in the token_type claim
+const (
+	TokenTypeAccess    = "access"
+	TokenTypeRefresh   = "refresh"
+	TokenTypeChallenge = "2fa_challenge"
+)
+
 // JWTClaims represents the custom claims in the JWT token
 type JWTClaims struct {
 	DeveloperID string `json:"developer_id"`
 	Email       string `json:"email"`
 	Role        string `json:"role"`
+	TokenType   string `json:"token_type"`
+	SessionID   string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -33,10 +58,11 @@ type TokenPair struct {
 
 // JWT errors
 var (
-	ErrInvalidToken      = errors.New("invalid token")
-	ErrExpiredToken      = errors.New("token has expired")
-	ErrInvalidClaims     = errors.New("invalid token claims")
-	ErrMissingSecretKey  = errors.New("JWT secret key is required")
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrExpiredToken     = errors.New("token has expired")
+	ErrInvalidClaims    = errors.New("invalid token claims")
+	ErrWrongTokenType   = errors.New("wrong token type")
+	ErrMissingSecretKey = errors.New("JWT secret key is required")
 )
 
 // NewJWTService creates a new JWT service
@@ -47,26 +73,70 @@ func NewJWTService(secretKey string) *JWTService {
 
 	return &JWTService{
 		secretKey:     secretKey,
-		tokenExpiry:   24 * time.Hour,       // 24 hours
-		refreshExpiry: 7 * 24 * time.Hour,   // 7 days
+		issuer:        DefaultIssuer,
+		tokenExpiry:   24 * time.Hour,     // 24 hours
+		refreshExpiry: 7 * 24 * time.Hour, // 7 days
 	}
 }
 
-// NewJWTServiceWithExpiry creates a new JWT service with custom expiry times
-func NewJWTServiceWithExpiry(secretKey string, tokenExpiry, refreshExpiry time.Duration) *JWTService {
+// NewJWTServiceWithExpiry creates a new JWT service with a custom issuer and expiry times
+func NewJWTServiceWithExpiry(secretKey, issuer string, tokenExpiry, refreshExpiry time.Duration) *JWTService {
 	if secretKey == "" {
 		panic(ErrMissingSecretKey)
 	}
 
 	return &JWTService{
 		secretKey:     secretKey,
+		issuer:        issuer,
 		tokenExpiry:   tokenExpiry,
 		refreshExpiry: refreshExpiry,
 	}
 }
 
-// GenerateToken generates a new access token for a developer
-func (s *JWTService) GenerateToken(developerID, email, role string) (*TokenPair, error) {
+// NewJWTServiceWithKeys creates a new JWT service that signs tokens with an asymmetric key set
+func NewJWTServiceWithKeys(keys *KeySet, issuer string, tokenExpiry, refreshExpiry time.Duration) *JWTService {
+	if keys == nil {
+		panic(ErrNoSigningKey)
+	}
+
+	return &JWTService{
+		keys:          keys,
+		issuer:        issuer,
+		tokenExpiry:   tokenExpiry,
+		refreshExpiry: refreshExpiry,
+	}
+}
+
+// Audience returns the aud claim of tokens of a type: the issuer and the
+// token type, e.g. "taskmanager:access"
+func (s *JWTService) Audience(tokenType string) string {
+	return s.issuer + ":" + tokenType
+}
+
+// Algorithm returns the algorithm new tokens are signed with
+func (s *JWTService) Algorithm() string {
+	if s.keys != nil {
+		return s.keys.Signing().Method.Alg()
+	}
+	return jwt.SigningMethodHS256.Alg()
+}
+
+// JWKS returns the public verification keys. It is empty when tokens are
+// signed with a shared secret.
+func (s *JWTService) JWKS() jose.JSONWebKeySet {
+	if s.keys == nil {
+		return jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	}
+	return s.keys.JWKS()
+}
+
+// RefreshExpiry returns how long refresh tokens stay valid
+func (s *JWTService) RefreshExpiry() time.Duration {
+	return s.refreshExpiry
+}
+
+// GenerateToken generates a new access and refresh token pair for a session
+func (s *JWTService) GenerateToken(developerID, email, role, sessionID string) (*TokenPair, error) {
 	now := time.Now()
 
 	// Access token
@@ -74,7 +144,11 @@ func (s *JWTService) GenerateToken(developerID, email, role string) (*TokenPair,
 		DeveloperID: developerID,
 		Email:       email,
 		Role:        role,
+		TokenType:   TokenTypeAccess,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Audience:  jwt.ClaimStrings{s.Audience(TokenTypeAccess)},
 			Subject:   developerID,
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.tokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -82,18 +156,22 @@ func (s *JWTService) GenerateToken(developerID, email, role string) (*TokenPair,
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString([]byte(s.secretKey))
+	accessTokenString, err := s.sign(accessClaims)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign access token: %w", err)
 	}
 
-	// Refresh token
+	// Refresh token (the unique ID keeps every issued token distinct)
 	refreshClaims := JWTClaims{
 		DeveloperID: developerID,
 		Email:       email,
 		Role:        role,
+		TokenType:   TokenTypeRefresh,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    s.issuer,
+			Audience:  jwt.ClaimStrings{s.Audience(TokenTypeRefresh)},
 			Subject:   developerID,
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.refreshExpiry)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -101,8 +179,7 @@ func (s *JWTService) GenerateToken(developerID, email, role string) (*TokenPair,
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(s.secretKey))
+	refreshTokenString, err := s.sign(refreshClaims)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign refresh token: %w", err)
 	}
@@ -115,14 +192,56 @@ func (s *JWTService) GenerateToken(developerID, email, role string) (*TokenPair,
 	}, nil
 }
 
+// GenerateChallengeToken generates a short-lived token proving that a
+// developer passed the password step of a two-factor login. challengeID
+// becomes its unique ID. It cannot be used to access the API.
+func (s *JWTService) GenerateChallengeToken(developerID, challengeID string, expiry time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := JWTClaims{
+		DeveloperID: developerID,
+		TokenType:   TokenTypeChallenge,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        challengeID,
+			Issuer:    s.issuer,
+			Audience:  jwt.ClaimStrings{s.Audience(TokenTypeChallenge)},
+			Subject:   developerID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token, err := s.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign challenge token: %w", err)
+	}
+
+	return token, nil
+}
+
 // ValidateToken validates an access token and returns the claims
 func (s *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.secretKey), nil
-	})
+	return s.validate(tokenString, TokenTypeAccess)
+}
+
+// ValidateRefreshToken validates a refresh token and returns the claims
+func (s *JWTService) ValidateRefreshToken(tokenString string) (*JWTClaims, error) {
+	return s.validate(tokenString, TokenTypeRefresh)
+}
+
+// ValidateChallengeToken validates a two-factor challenge token and returns the claims
+func (s *JWTService) ValidateChallengeToken(tokenString string) (*JWTClaims, error) {
+	return s.validate(tokenString, TokenTypeChallenge)
+}
+
+// validate parses a token and checks that it has the issuer, audience and
+// token type of the expected token type
+func (s *JWTService) validate(tokenString, tokenType string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, s.verificationKey,
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.Audience(tokenType)),
+	)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -136,18 +255,44 @@ func (s *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
 		return nil, ErrInvalidClaims
 	}
 
+	if claims.TokenType != tokenType {
+		return nil, ErrWrongTokenType
+	}
+
 	return claims, nil
 }
 
-// RefreshToken generates a new access token using a refresh token
-func (s *JWTService) RefreshToken(refreshTokenString string) (*TokenPair, error) {
-	claims, err := s.ValidateToken(refreshTokenString)
-	if err != nil {
-		return nil, err
+// sign signs claims with the configured secret or signing key
+func (s *JWTService) sign(claims JWTClaims) (string, error) {
+	if s.keys == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.secretKey))
+	}
+
+	key := s.keys.Signing()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// verificationKey returns the key for a token being parsed. The algorithm
+// must match the key, so a public key can never be used as an HMAC secret.
+func (s *JWTService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if s.keys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.secretKey), nil
 	}
 
-	// Generate new token pair
-	return s.GenerateToken(claims.DeveloperID, claims.Email, claims.Role)
+	kid, _ := token.Header["kid"].(string)
+	key := s.keys.Get(kid)
+	if key == nil {
+		return nil, fmt.Errorf("unknown key ID: %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.PublicKey, nil
 }
 
 // ExtractToken extracts the JWT token from the Authorization header
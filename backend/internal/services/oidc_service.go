@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/oidc"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"golang.org/x/oauth2"
+)
+
+// oidcStateExpiry is how long a developer has to log in at the identity provider
+const oidcStateExpiry = 10 * time.Minute
+
+// OIDC errors
+var (
+	ErrInvalidOIDCState     = errors.New("login state is invalid or has expired")
+	ErrOIDCEmailMissing     = errors.New("identity provider did not return an email address")
+	ErrOIDCEmailNotVerified = errors.New("email belongs to an existing account but is not verified by the identity provider")
+)
+
+// OIDCService logs developers in through an OpenID Connect identity provider
+// using the authorization code flow with PKCE.
+//
+// Developers are provisioned on their first login. Existing accounts are
+// linked by email when the provider has verified the address. When a role
+// mapping is configured, the provider's claims decide the developer's role
+// on every login.
+type OIDCService struct {
+	provider  *oidc.Provider
+	roles     *oidc.RoleMapping
+	stateRepo *repository.OIDCStateRepository
+	userRepo  repository.DeveloperStore
+}
+
+// NewOIDCService creates a new OIDC service
+func NewOIDCService(provider *oidc.Provider, roles *oidc.RoleMapping, stateRepo *repository.OIDCStateRepository, userRepo repository.DeveloperStore) *OIDCService {
+	return &OIDCService{
+		provider:  provider,
+		roles:     roles,
+		stateRepo: stateRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// WithTx returns a copy of the service whose developer reads and writes run
+// in the transaction of a unit of work
+func (s *OIDCService) WithTx(tx *repository.Tx) *OIDCService {
+	scoped := *s
+	scoped.userRepo = tx.Developers()
+	return &scoped
+}
+
+// Enabled reports whether single sign-on is configured
+func (s *OIDCService) Enabled() bool {
+	return s.provider.Enabled()
+}
+
+// BeginLogin starts a login and returns the identity provider URL to send the developer to
+func (s *OIDCService) BeginLogin(ctx context.Context) (string, error) {
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	pending := &models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oidcStateExpiry),
+	}
+	if err := s.stateRepo.Create(ctx, pending); err != nil {
+		return "", err
+	}
+
+	return authURL, nil
+}
+
+// OIDCLogin is the developer a single sign-on login provisioned
+type OIDCLogin struct {
+	Developer *models.Developer
+	// Previous is the developer as it was before the login applied the
+	// provider's identity, or nil if the login created the account
+	Previous *models.Developer
+}
+
+// CompleteLogin handles the identity provider's callback and returns the
+// identity it confirmed. The developer is provisioned with Provision.
+func (s *OIDCService) CompleteLogin(ctx context.Context, code, state string) (*oidc.Identity, error) {
+	pending, err := s.stateRepo.Take(ctx, utils.HashToken(state))
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, ErrInvalidOIDCState
+	}
+
+	return s.provider.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+}
+
+// Provision finds, links or creates the developer for an identity and
+// applies the role mapping. Its writes belong together, so it should run
+// in a unit of work (see WithTx). Starting the session, or the two-factor
+// challenge the developer still has to pass, is left to the caller.
+func (s *OIDCService) Provision(ctx context.Context, identity *oidc.Identity) (*OIDCLogin, error) {
+	developer, previous, err := s.provision(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCLogin{Developer: developer, Previous: previous}, nil
+}
+
+// provision does the work of Provision. It also returns a copy of the
+// developer from before the changes, or nil if the developer was created.
+func (s *OIDCService) provision(ctx context.Context, identity *oidc.Identity) (*models.Developer, *models.Developer, error) {
+	var previous *models.Developer
+
+	developer, err := s.userRepo.GetByOIDCSubject(ctx, identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if developer == nil {
+		if identity.Email == "" {
+			return nil, nil, ErrOIDCEmailMissing
+		}
+
+		developer, err = s.userRepo.GetByEmail(ctx, identity.Email)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if developer != nil {
+			// Linking an unverified address would let anyone claim the account
+			if !identity.EmailVerified {
+				return nil, nil, ErrOIDCEmailNotVerified
+			}
+			linked := *developer
+			previous = &linked
+		} else {
+			developer = &models.Developer{
+				Name:   identity.Name,
+				Email:  identity.Email,
+				Role:   s.roles.Role(identity.Claims),
+				Status: "active",
+			}
+			if developer.Name == "" {
+				developer.Name, _, _ = strings.Cut(identity.Email, "@")
+			}
+			if err := s.userRepo.Create(ctx, developer); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if err := s.userRepo.LinkOIDC(ctx, developer.ID, identity.Issuer, identity.Subject); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		existing := *developer
+		previous = &existing
+	}
+
+	if identity.EmailVerified && !developer.EmailVerified {
+		if err := s.userRepo.MarkEmailVerified(ctx, developer.ID); err != nil {
+			return nil, nil, err
+		}
+		developer.EmailVerified = true
+	}
+
+	// The identity provider is the source of truth for roles once a mapping is configured
+	if len(s.roles.Roles) > 0 {
+		if role := s.roles.Role(identity.Claims); role != developer.Role {
+			if err := s.userRepo.UpdateRole(ctx, developer.ID, role); err != nil {
+				return nil, nil, err
+			}
+			developer.Role = role
+		}
+	}
+
+	return developer, previous, nil
+}
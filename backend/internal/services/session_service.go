@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// lastUsedResolution limits how often a session's last-used time is written
+const lastUsedResolution = time.Minute
+
+// Session errors
+var (
+	ErrSessionRevoked = errors.New("session has been revoked or has expired")
+	ErrTokenReused    = errors.New("refresh token has already been used")
+)
+
+// SessionService issues tokens for login sessions and rotates refresh tokens.
+//
+// Each refresh token may be exchanged exactly once. Presenting a token that
+// was already rotated means it has leaked, so the whole session (the token
+// family) is revoked.
+type SessionService struct {
+	jwtService *JWTService
+	repo       *repository.SessionRepository
+	userRepo   repository.DeveloperStore
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(jwtService *JWTService, repo *repository.SessionRepository, userRepo repository.DeveloperStore) *SessionService {
+	return &SessionService{
+		jwtService: jwtService,
+		repo:       repo,
+		userRepo:   userRepo,
+	}
+}
+
+// Start creates a new session for a developer and issues its first token pair
+func (s *SessionService) Start(ctx context.Context, developer *models.Developer, userAgent, ipAddress string) (*TokenPair, error) {
+	session := &models.Session{
+		ID:          uuid.NewString(),
+		DeveloperID: developer.ID,
+		UserAgent:   userAgent,
+		IPAddress:   ipAddress,
+		ExpiresAt:   time.Now().Add(s.jwtService.RefreshExpiry()),
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, developer, session.ID)
+}
+
+// Refresh exchanges a refresh token for a new token pair in the same session
+func (s *SessionService) Refresh(ctx context.Context, refreshToken, userAgent, ipAddress string) (*TokenPair, error) {
+	if _, err := s.jwtService.ValidateRefreshToken(refreshToken); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, ErrInvalidToken
+	}
+
+	session, err := s.repo.GetByID(ctx, stored.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || !session.IsActive() {
+		return nil, ErrSessionRevoked
+	}
+
+	// Only one caller can rotate a token; anyone else is replaying it
+	rotated, err := s.repo.MarkRefreshTokenRotated(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if stored.RotatedAt != nil || !rotated {
+		if err := s.repo.Revoke(ctx, session.ID, models.RevokeReasonTokenReuse); err != nil {
+			return nil, err
+		}
+		log.Warn().
+			Str("session_id", session.ID).
+			Int("developer_id", session.DeveloperID).
+			Str("ip", ipAddress).
+			Msg("Refresh token reuse detected, session revoked")
+		return nil, ErrTokenReused
+	}
+
+	developer, err := s.userRepo.GetByID(ctx, session.DeveloperID)
+	if err != nil {
+		return nil, err
+	}
+	if developer == nil {
+		return nil, ErrInvalidToken
+	}
+
+	expiresAt := time.Now().Add(s.jwtService.RefreshExpiry())
+	if err := s.repo.Touch(ctx, session.ID, userAgent, ipAddress, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, developer, session.ID)
+}
+
+// Revoke revokes a session and every refresh token issued for it
+func (s *SessionService) Revoke(ctx context.Context, sessionID, reason string) error {
+	return s.repo.Revoke(ctx, sessionID, reason)
+}
+
+// Use reports whether a session may still be used and records its last use
+func (s *SessionService) Use(ctx context.Context, sessionID, ipAddress string) (bool, error) {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	if session == nil || !session.IsActive() {
+		return false, nil
+	}
+
+	if err := s.repo.MarkUsed(ctx, sessionID, ipAddress, lastUsedResolution); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns a developer's active sessions, flagging currentSessionID
+func (s *SessionService) List(ctx context.Context, developerID int, currentSessionID string) ([]*models.Session, error) {
+	sessions, err := s.repo.ListActiveByDeveloper(ctx, developerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Device = utils.DeviceFromUserAgent(session.UserAgent)
+		session.Current = session.ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeOwned revokes a session only if it belongs to the developer.
+// It returns false when no such session exists.
+func (s *SessionService) RevokeOwned(ctx context.Context, developerID int, sessionID, reason string) (bool, error) {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	if session == nil || session.DeveloperID != developerID {
+		return false, nil
+	}
+
+	if err := s.repo.Revoke(ctx, sessionID, reason); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RevokeAll revokes every session of a developer and returns how many were revoked
+func (s *SessionService) RevokeAll(ctx context.Context, developerID int, reason string) (int, error) {
+	return s.repo.RevokeAllForDeveloper(ctx, developerID, reason)
+}
+
+// issue generates a token pair and stores the hash of its refresh token
+func (s *SessionService) issue(ctx context.Context, developer *models.Developer, sessionID string) (*TokenPair, error) {
+	tokenPair, err := s.jwtService.GenerateToken(strconv.Itoa(developer.ID), developer.Email, developer.Role, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &models.RefreshToken{
+		SessionID: sessionID,
+		TokenHash: utils.HashToken(tokenPair.RefreshToken),
+		ExpiresAt: time.Now().Add(s.jwtService.RefreshExpiry()),
+	}
+	if err := s.repo.CreateRefreshToken(ctx, stored); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return tokenPair, nil
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+	"github.com/ardani17/taskmanager/internal/repository"
+	"github.com/ardani17/taskmanager/pkg/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	// challengeExpiry is how long a developer has to enter their second factor
+	challengeExpiry = 5 * time.Minute
+
+	// challengeMaxFailures is how many wrong codes burn a challenge, so the
+	// password step has to be passed again
+	challengeMaxFailures = 5
+
+	// recoveryCodeCount is how many recovery codes are issued at a time
+	recoveryCodeCount = 10
+)
+
+// Two-factor errors
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotSetUp       = errors.New("two-factor authentication has not been set up")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for this role")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+)
+
+// LoginChallenge is returned by a login that still needs a second factor
+type LoginChallenge struct {
+	Token     string `json:"challenge_token"`
+	ExpiresIn int64  `json:"expires_in"` // seconds
+
+	// EnrollmentRequired is set when the developer's role requires two-factor
+	// authentication but they have not enrolled yet
+	EnrollmentRequired bool `json:"enrollment_required"`
+}
+
+// TwoFactorService handles TOTP (RFC 6238) enrollment, recovery codes and
+// the second step of logins.
+//
+// Logins of developers with two-factor authentication, or whose role
+// requires it, first return a challenge token. Only exchanging that token
+// together with a valid code yields real tokens.
+type TwoFactorService struct {
+	repo       *repository.TwoFactorRepository
+	userRepo   repository.DeveloperStore
+	jwtService *JWTService
+	issuer     string
+}
+
+// NewTwoFactorService creates a new two-factor service. issuer is the name
+// authenticator apps show for the account.
+func NewTwoFactorService(repo *repository.TwoFactorRepository, userRepo repository.DeveloperStore, jwtService *JWTService, issuer string) *TwoFactorService {
+	return &TwoFactorService{
+		repo:       repo,
+		userRepo:   userRepo,
+		jwtService: jwtService,
+		issuer:     issuer,
+	}
+}
+
+// IsRequired reports whether developers with a role must use two-factor authentication
+func (s *TwoFactorService) IsRequired(ctx context.Context, role string) (bool, error) {
+	return s.repo.IsRequiredForRole(ctx, role)
+}
+
+// RemainingRecoveryCodes returns how many unused recovery codes a developer has
+func (s *TwoFactorService) RemainingRecoveryCodes(ctx context.Context, developerID int) (int, error) {
+	return s.repo.CountUnusedRecoveryCodes(ctx, developerID)
+}
+
+// Setup starts enrolling a developer by generating a new secret. The secret
+// only takes effect once Enable confirms a code from it.
+func (s *TwoFactorService) Setup(ctx context.Context, developer *models.Developer) (*models.TwoFactorSetup, error) {
+	_, enabled, err := s.repo.GetSecret(ctx, developer.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	if err := s.repo.SetPendingSecret(ctx, developer.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(s.issuer, developer.Email, secret),
+	}, nil
+}
+
+// Enable confirms an enrollment with a code from the authenticator app and
+// returns the developer's recovery codes. They are shown only this once.
+func (s *TwoFactorService) Enable(ctx context.Context, developerID int, code string) ([]string, error) {
+	secret, enabled, err := s.repo.GetSecret(ctx, developerID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if secret == "" {
+		return nil, ErrTwoFactorNotSetUp
+	}
+
+	if err := s.verifyTOTP(ctx, developerID, secret, code); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Enable(ctx, developerID); err != nil {
+		return nil, err
+	}
+
+	return s.newRecoveryCodes(ctx, developerID)
+}
+
+// Disable turns two-factor authentication off after checking a code.
+// Developers whose role requires two-factor authentication cannot disable it.
+func (s *TwoFactorService) Disable(ctx context.Context, developer *models.Developer, code string) error {
+	if !developer.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	required, err := s.IsRequired(ctx, developer.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+
+	if err := s.Verify(ctx, developer.ID, code); err != nil {
+		return err
+	}
+
+	return s.repo.Disable(ctx, developer.ID)
+}
+
+// RegenerateRecoveryCodes replaces a developer's recovery codes after checking a code
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, developerID int, code string) ([]string, error) {
+	if err := s.Verify(ctx, developerID, code); err != nil {
+		return nil, err
+	}
+
+	return s.newRecoveryCodes(ctx, developerID)
+}
+
+// Verify checks a TOTP code or, failing that, an unused recovery code
+func (s *TwoFactorService) Verify(ctx context.Context, developerID int, code string) error {
+	secret, enabled, err := s.repo.GetSecret(ctx, developerID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == utils.TOTPDigits {
+		return s.verifyTOTP(ctx, developerID, secret, code)
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, developerID, utils.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// BeginLogin returns a challenge if a developer who passed the password
+// check still needs a second factor, or nil if they can be logged in
+func (s *TwoFactorService) BeginLogin(ctx context.Context, developer *models.Developer) (*LoginChallenge, error) {
+	required, err := s.IsRequired(ctx, developer.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !developer.TwoFactorEnabled && !required {
+		return nil, nil
+	}
+
+	challengeID := uuid.NewString()
+	token, err := s.jwtService.GenerateChallengeToken(strconv.Itoa(developer.ID), challengeID, challengeExpiry)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateChallenge(ctx, challengeID, developer.ID, time.Now().Add(challengeExpiry)); err != nil {
+		return nil, err
+	}
+
+	return &LoginChallenge{
+		Token:              token,
+		ExpiresIn:          int64(challengeExpiry.Seconds()),
+		EnrollmentRequired: !developer.TwoFactorEnabled,
+	}, nil
+}
+
+// SetupFromChallenge starts enrolling a developer who must use two-factor
+// authentication but has not enrolled yet
+func (s *TwoFactorService) SetupFromChallenge(ctx context.Context, challengeToken string) (*models.TwoFactorSetup, error) {
+	developer, _, err := s.challengedDeveloper(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Setup(ctx, developer)
+}
+
+// ChallengedDeveloper returns the developer a pending challenge token was
+// issued to
+func (s *TwoFactorService) ChallengedDeveloper(ctx context.Context, challengeToken string) (*models.Developer, error) {
+	developer, _, err := s.challengedDeveloper(ctx, challengeToken)
+	return developer, err
+}
+
+// CompleteLogin checks the second factor of a login. Developers completing a
+// required enrollment also get their new recovery codes. A challenge logs in
+// once and is burned after challengeMaxFailures wrong codes.
+func (s *TwoFactorService) CompleteLogin(ctx context.Context, challengeToken, code string) (*models.Developer, []string, error) {
+	developer, challengeID, err := s.challengedDeveloper(ctx, challengeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var recoveryCodes []string
+	if !developer.TwoFactorEnabled {
+		recoveryCodes, err = s.Enable(ctx, developer.ID, code)
+	} else {
+		err = s.Verify(ctx, developer.ID, code)
+	}
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		if err := s.repo.FailChallenge(ctx, challengeID, challengeMaxFailures); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	used, err := s.repo.UseChallenge(ctx, challengeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !used {
+		return nil, nil, ErrInvalidToken
+	}
+
+	developer.TwoFactorEnabled = true
+	return developer, recoveryCodes, nil
+}
+
+// Settings returns the roles that require two-factor authentication
+func (s *TwoFactorService) Settings(ctx context.Context) (*models.TwoFactorSettings, error) {
+	roles, err := s.repo.RequiredRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSettings{RequiredRoles: roles}, nil
+}
+
+// UpdateSettings replaces the roles that require two-factor authentication.
+// Affected developers have to enroll at their next login.
+func (s *TwoFactorService) UpdateSettings(ctx context.Context, settings *models.TwoFactorSettings) error {
+	return s.repo.SetRequiredRoles(ctx, settings.RequiredRoles)
+}
+
+// challengedDeveloper returns the developer a challenge token was issued to
+// and the ID of the challenge, which must still be pending
+func (s *TwoFactorService) challengedDeveloper(ctx context.Context, challengeToken string) (*models.Developer, string, error) {
+	claims, err := s.jwtService.ValidateChallengeToken(challengeToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	developerID, err := strconv.Atoi(claims.DeveloperID)
+	if err != nil {
+		return nil, "", ErrInvalidClaims
+	}
+
+	active, err := s.repo.ChallengeActive(ctx, claims.ID, developerID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !active {
+		return nil, "", ErrInvalidToken
+	}
+
+	developer, err := s.userRepo.GetByID(ctx, developerID)
+	if err != nil {
+		return nil, "", err
+	}
+	if developer == nil {
+		return nil, "", ErrInvalidToken
+	}
+
+	return developer, claims.ID, nil
+}
+
+// verifyTOTP checks a TOTP code and makes sure it was not used before
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, developerID int, secret, code string) error {
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	fresh, err := s.repo.AdvanceStep(ctx, developerID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// newRecoveryCodes generates and stores a new set of recovery codes
+func (s *TwoFactorService) newRecoveryCodes(ctx context.Context, developerID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codes[i] = code
+		hashes[i] = utils.HashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, developerID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode makes recovery codes case and dash insensitive
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
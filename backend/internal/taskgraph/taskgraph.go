@@ -0,0 +1,253 @@
+// Package taskgraph schedules the tasks of a project along their blocks
+// links and finds the critical path. It is the critical path method with
+// estimated hours as durations and due dates as deadlines: every open task
+// starts when its last open blocker finishes, and the chain of tasks with
+// the least slack is the critical path.
+package taskgraph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+// ErrCycle is returned when the blocks links form a cycle
+var ErrCycle = errors.New("blocks links form a cycle")
+
+// CycleError is the ErrCycle returned by Analyze. TaskIDs lists the tasks of
+// one cycle, each blocking the next and the last blocking the first.
+type CycleError struct {
+	TaskIDs []int
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrCycle, e.TaskIDs)
+}
+
+// Is makes errors.Is(err, ErrCycle) match
+func (e *CycleError) Is(target error) bool {
+	return target == ErrCycle
+}
+
+// epsilon absorbs rounding when comparing hours
+const epsilon = 1e-9
+
+// Analyze builds the dependency graph of tasks and their links, scheduled
+// from now. Links to tasks that are not in tasks are ignored.
+func Analyze(tasks []*models.Task, links []*models.TaskLink, now time.Time) (*models.DependencyGraph, error) {
+	graph := &models.DependencyGraph{
+		Nodes:        make([]*models.DependencyNode, 0, len(tasks)),
+		Edges:        []*models.TaskLink{},
+		CriticalPath: []int{},
+	}
+
+	nodes := map[int]*node{}
+	var order []*node
+	for _, t := range tasks {
+		n := &node{task: t, out: map[int]*node{}}
+		n.DependencyNode = &models.DependencyNode{
+			TaskID:         t.ID,
+			Title:          t.Title,
+			Status:         t.Status,
+			DueDate:        t.DueDate,
+			EstimatedHours: t.EstimatedHours,
+		}
+		nodes[t.ID] = n
+		order = append(order, n)
+		graph.Nodes = append(graph.Nodes, n.DependencyNode)
+	}
+
+	// Only blocks links between open tasks constrain the schedule
+	for _, link := range links {
+		source, target := nodes[link.SourceID], nodes[link.TargetID]
+		if source == nil || target == nil {
+			continue
+		}
+		graph.Edges = append(graph.Edges, link)
+		if link.Type == models.LinkBlocks && source.open() && target.open() {
+			source.out[target.task.ID] = target
+			target.in = append(target.in, source)
+		}
+	}
+
+	sorted, err := topologicalOrder(order)
+	if err != nil {
+		return nil, err
+	}
+
+	// Forward pass: earliest start and finish
+	end := 0.0
+	for _, n := range sorted {
+		for _, blocker := range n.in {
+			n.EarliestStart = math.Max(n.EarliestStart, blocker.EarliestFinish)
+		}
+		n.EarliestFinish = n.EarliestStart + n.duration()
+		end = math.Max(end, n.EarliestFinish)
+	}
+
+	// Backward pass: latest finish, bounded by due dates and by the latest
+	// start of the tasks blocked, or the end of the project for the last ones
+	minSlack := math.Inf(1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		n := sorted[i]
+		n.latestFinish = math.Inf(1)
+		if n.task.DueDate != nil {
+			n.latestFinish = n.task.DueDate.Sub(now).Hours()
+		}
+		for _, blocked := range n.out {
+			n.latestFinish = math.Min(n.latestFinish, blocked.latestFinish-blocked.duration())
+		}
+		if math.IsInf(n.latestFinish, 1) {
+			n.latestFinish = end
+		}
+
+		slack := n.latestFinish - n.EarliestFinish
+		n.Slack = &slack
+		minSlack = math.Min(minSlack, slack)
+	}
+	if len(sorted) == 0 {
+		return graph, nil
+	}
+
+	for _, n := range sorted {
+		n.Critical = *n.Slack <= minSlack+epsilon
+	}
+
+	// The critical path ends with the critical task finishing last and runs
+	// back through the blockers it waits for
+	var last *node
+	for _, n := range sorted {
+		if n.Critical && (last == nil || n.EarliestFinish > last.EarliestFinish+epsilon) {
+			last = n
+		}
+	}
+	path := []int{}
+	for n := last; n != nil; {
+		path = append(path, n.task.ID)
+		next := (*node)(nil)
+		for _, blocker := range n.in {
+			if blocker.Critical && math.Abs(blocker.EarliestFinish-n.EarliestStart) <= epsilon {
+				if next == nil || blocker.task.ID < next.task.ID {
+					next = blocker
+				}
+			}
+		}
+		if next == nil {
+			graph.DurationHours = last.EarliestFinish - n.EarliestStart
+		}
+		n = next
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	graph.CriticalPath = path
+
+	return graph, nil
+}
+
+// node is a task being scheduled
+type node struct {
+	*models.DependencyNode
+	task *models.Task
+	// in are the open tasks blocking this one and out those it blocks
+	in           []*node
+	out          map[int]*node
+	latestFinish float64
+}
+
+func (n *node) open() bool {
+	return n.task.Status != "done"
+}
+
+// duration is the remaining work of the task
+func (n *node) duration() float64 {
+	if !n.open() {
+		return 0
+	}
+	return n.task.EstimatedHours
+}
+
+// topologicalOrder returns the open tasks with every task after its
+// blockers, by ID where the order is free
+func topologicalOrder(nodes []*node) ([]*node, error) {
+	pending := map[*node]int{}
+	var ready []*node
+	open := 0
+	for _, n := range nodes {
+		if !n.open() {
+			continue
+		}
+		open++
+		pending[n] = len(n.in)
+		if len(n.in) == 0 {
+			ready = append(ready, n)
+		}
+	}
+
+	var sorted []*node
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return ready[i].task.ID < ready[j].task.ID })
+		n := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, n)
+		for _, blocked := range n.out {
+			pending[blocked]--
+			if pending[blocked] == 0 {
+				ready = append(ready, blocked)
+			}
+		}
+	}
+
+	if len(sorted) != open {
+		return nil, &CycleError{TaskIDs: findCycle(nodes, pending)}
+	}
+	return sorted, nil
+}
+
+// findCycle returns a cycle among the tasks topologicalOrder could not sort,
+// those still waiting for blockers. Each of them waits for another one, so
+// following blockers back from any of them must come round.
+func findCycle(nodes []*node, pending map[*node]int) []int {
+	var start *node
+	for _, n := range nodes {
+		if pending[n] > 0 && (start == nil || n.task.ID < start.task.ID) {
+			start = n
+		}
+	}
+
+	seen := map[*node]int{}
+	var walk []*node
+	for n := start; ; {
+		if i, ok := seen[n]; ok {
+			walk = walk[i:]
+			break
+		}
+		seen[n] = len(walk)
+		walk = append(walk, n)
+
+		var next *node
+		for _, blocker := range n.in {
+			if pending[blocker] > 0 && (next == nil || blocker.task.ID < next.task.ID) {
+				next = blocker
+			}
+		}
+		n = next
+	}
+
+	// The walk went from blocked to blocker; the cycle starts at its lowest ID
+	first := 0
+	for i, n := range walk {
+		if n.task.ID < walk[first].task.ID {
+			first = i
+		}
+	}
+	cycle := make([]int, 0, len(walk))
+	for i := range walk {
+		cycle = append(cycle, walk[(first-i+len(walk))%len(walk)].task.ID)
+	}
+	return cycle
+}
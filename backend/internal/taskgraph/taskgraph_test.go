@@ -0,0 +1,158 @@
+package taskgraph
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ardani17/taskmanager/internal/models"
+)
+
+var now = time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+func task(id int, hours float64, status string) *models.Task {
+	return &models.Task{ID: id, Title: "Task", Status: status, EstimatedHours: hours}
+}
+
+func blocks(source, target int) *models.TaskLink {
+	return &models.TaskLink{SourceID: source, TargetID: target, Type: models.LinkBlocks}
+}
+
+func TestAnalyze(t *testing.T) {
+	due := now.Add(5 * time.Hour)
+	late := task(5, 8, "todo")
+	late.DueDate = &due
+
+	tests := []struct {
+		name     string
+		tasks    []*models.Task
+		links    []*models.TaskLink
+		path     []int
+		duration float64
+		// slack of each task, nil for done tasks
+		slack map[int]*float64
+	}{
+		{
+			name:  "no tasks",
+			path:  []int{},
+			slack: map[int]*float64{},
+		},
+		{
+			name:     "chain and a loose task",
+			tasks:    []*models.Task{task(1, 4, "todo"), task(2, 2, "in_progress"), task(3, 1, "todo")},
+			links:    []*models.TaskLink{blocks(1, 2)},
+			path:     []int{1, 2},
+			duration: 6,
+			slack:    map[int]*float64{1: hours(0), 2: hours(0), 3: hours(5)},
+		},
+		{
+			name:     "the longer of two blockers is critical",
+			tasks:    []*models.Task{task(1, 4, "todo"), task(2, 1, "todo"), task(3, 2, "todo")},
+			links:    []*models.TaskLink{blocks(1, 3), blocks(2, 3)},
+			path:     []int{1, 3},
+			duration: 6,
+			slack:    map[int]*float64{1: hours(0), 2: hours(3), 3: hours(0)},
+		},
+		{
+			name:     "done blockers take no time",
+			tasks:    []*models.Task{task(1, 4, "done"), task(2, 2, "todo")},
+			links:    []*models.TaskLink{blocks(1, 2)},
+			path:     []int{2},
+			duration: 2,
+			slack:    map[int]*float64{1: nil, 2: hours(0)},
+		},
+		{
+			name:     "a due date that cannot be met",
+			tasks:    []*models.Task{task(4, 1, "todo"), late},
+			links:    []*models.TaskLink{blocks(4, 5), {SourceID: 4, TargetID: 9, Type: models.LinkBlocks}},
+			path:     []int{4, 5},
+			duration: 9,
+			slack:    map[int]*float64{4: hours(-4), 5: hours(-4)},
+		},
+		{
+			name:     "other link types do not schedule",
+			tasks:    []*models.Task{task(1, 4, "todo"), task(2, 2, "todo")},
+			links:    []*models.TaskLink{{SourceID: 1, TargetID: 2, Type: models.LinkRelatesTo}},
+			path:     []int{1},
+			duration: 4,
+			slack:    map[int]*float64{1: hours(0), 2: hours(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, err := Analyze(tt.tasks, tt.links, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(graph.CriticalPath, tt.path) {
+				t.Fatalf("critical path = %v, want %v", graph.CriticalPath, tt.path)
+			}
+			if graph.DurationHours != tt.duration {
+				t.Fatalf("duration = %v, want %v", graph.DurationHours, tt.duration)
+			}
+			for _, n := range graph.Nodes {
+				want := tt.slack[n.TaskID]
+				if (n.Slack == nil) != (want == nil) || (want != nil && *n.Slack != *want) {
+					t.Fatalf("slack of task %d = %v, want %v", n.TaskID, deref(n.Slack), deref(want))
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		links []*models.TaskLink
+		cycle []int
+	}{
+		{
+			name:  "all tasks",
+			links: []*models.TaskLink{blocks(3, 1), blocks(1, 2), blocks(2, 3)},
+			cycle: []int{1, 2, 3},
+		},
+		{
+			name:  "blocking a task outside the cycle",
+			links: []*models.TaskLink{blocks(2, 3), blocks(3, 4), blocks(4, 2), blocks(3, 1)},
+			cycle: []int{2, 3, 4},
+		},
+		{
+			name:  "two tasks",
+			links: []*models.TaskLink{blocks(1, 2), blocks(4, 3), blocks(3, 4)},
+			cycle: []int{3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks := []*models.Task{task(1, 1, "todo"), task(2, 1, "todo"), task(3, 1, "todo"), task(4, 1, "todo")}
+			_, err := Analyze(tasks, tt.links, now)
+			if !errors.Is(err, ErrCycle) {
+				t.Fatalf("got %v, want ErrCycle", err)
+			}
+			var cycle *CycleError
+			if !errors.As(err, &cycle) || !reflect.DeepEqual(cycle.TaskIDs, tt.cycle) {
+				t.Fatalf("got %v, want cycle %v", err, tt.cycle)
+			}
+		})
+	}
+
+	// A cycle through a done task no longer holds anything back
+	tasks := []*models.Task{task(1, 1, "todo"), task(2, 1, "done")}
+	if _, err := Analyze(tasks, []*models.TaskLink{blocks(1, 2), blocks(2, 1)}, now); err != nil {
+		t.Fatalf("cycle through a done task: %v", err)
+	}
+}
+
+func hours(h float64) *float64 {
+	return &h
+}
+
+func deref(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
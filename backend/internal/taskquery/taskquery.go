@@ -0,0 +1,435 @@
+// Package taskquery parses the filters and sort order of task lists. They
+// are given as query parameters of GET /tasks, as a compact query in its q
+// parameter, e.g. "assignee:me status:!done due:<2026-11-01 sort:due", or
+// both. Parsing only builds a Filter; the repository turns it into SQL with
+// every value passed as a parameter.
+package taskquery
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Field is a task attribute that tasks can be filtered or sorted by
+type Field string
+
+// Fields
+const (
+	FieldStatus   Field = "status"
+	FieldPriority Field = "priority"
+	FieldAssignee Field = "assignee"
+	FieldProject  Field = "project"
+	FieldTeam     Field = "team"
+	FieldParent   Field = "parent"
+	FieldDue      Field = "due"
+	FieldCreated  Field = "created"
+	FieldUpdated  Field = "updated"
+	FieldEstimate Field = "estimate"
+	FieldTitle    Field = "title"
+)
+
+// Statuses and Priorities list the task statuses and priorities in the
+// order they sort in
+var (
+	Statuses   = []string{"todo", "in_progress", "review", "done"}
+	Priorities = []string{"low", "medium", "high"}
+)
+
+// Condition is a filter on one field
+type Condition struct {
+	Field Field
+	// Values are the statuses or priorities to match, IDs the assignees,
+	// projects, teams or parent tasks, and Null matches tasks without one. A task matches
+	// if any of them does; with Not, if none does.
+	Values []string
+	IDs    []int
+	Null   bool
+	Not    bool
+	// Min and Max bound dates and estimates; nil leaves that end open
+	Min, Max *Bound
+}
+
+// Bound is one end of a range of dates (time.Time) or estimates (float64)
+type Bound struct {
+	Value     interface{}
+	Inclusive bool
+}
+
+// SortKey orders tasks by a field
+type SortKey struct {
+	Field Field
+	Desc  bool
+}
+
+// Filter selects and orders tasks. All conditions must hold, and the title
+// must contain every one of Text.
+type Filter struct {
+	Conditions []Condition
+	Text       []string
+	// Sort is the sort order; empty means newest first
+	Sort []SortKey
+}
+
+// SortString returns the sort order in the form of the sort parameter, or
+// "" for the default order
+func (f *Filter) SortString() string {
+	keys := make([]string, len(f.Sort))
+	for i, key := range f.Sort {
+		keys[i] = string(key.Field)
+		if key.Desc {
+			keys[i] = "-" + keys[i]
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+// params maps the filter query parameters to the keys of the query
+// language. The _id forms predate the query language.
+var params = []struct{ name, key string }{
+	{"status", "status"},
+	{"priority", "priority"},
+	{"assignee", "assignee"},
+	{"assignee_id", "assignee"},
+	{"project", "project"},
+	{"project_id", "project"},
+	{"team", "team"},
+	{"team_id", "team"},
+	{"parent", "parent"},
+	{"parent_id", "parent"},
+	{"due", "due"},
+	{"created", "created"},
+	{"updated", "updated"},
+	{"estimate", "estimate"},
+	{"sort", "sort"},
+}
+
+// Parse builds the filter of a task list request from its query parameters:
+// the q query and the parameters named after its keys, which take the same
+// values. me is the developer making the request, for assignee:me, and now
+// the current time, for today and is:overdue.
+func Parse(query url.Values, me int, now time.Time) (*Filter, error) {
+	p := &parser{filter: &Filter{}, me: me, today: day(now)}
+
+	for _, param := range params {
+		for _, value := range query[param.name] {
+			if value == "" {
+				continue
+			}
+			if err := p.term(param.key, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if q := query.Get("q"); q != "" {
+		terms, err := split(q)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range terms {
+			key, value, ok := strings.Cut(t, ":")
+			if !ok || !isKey(key) {
+				p.filter.Text = append(p.filter.Text, unquote(t))
+				continue
+			}
+			if err := p.term(strings.ToLower(key), unquote(value)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return p.filter, nil
+}
+
+// split breaks a query into terms at spaces outside double quotes
+func split(q string) ([]string, error) {
+	var terms []string
+	var term strings.Builder
+	quoted := false
+	for _, r := range q {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			term.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in %q", q)
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms, nil
+}
+
+// unquote removes the double quotes around a term or a value
+func unquote(s string) string {
+	return strings.ReplaceAll(s, `"`, "")
+}
+
+// isKey reports whether s can be a key, so that other text with a colon,
+// such as a URL, is searched for instead
+func isKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// parser adds the terms of a query to a filter
+type parser struct {
+	filter *Filter
+	me     int
+	today  time.Time
+}
+
+// term adds one key:value term
+func (p *parser) term(key, value string) error {
+	var err error
+	switch key {
+	case "status":
+		err = p.values(FieldStatus, value, Statuses)
+	case "priority":
+		err = p.values(FieldPriority, value, Priorities)
+	case "assignee":
+		err = p.ids(FieldAssignee, value, true)
+	case "project":
+		err = p.ids(FieldProject, value, false)
+	case "team":
+		err = p.ids(FieldTeam, value, false)
+	case "parent":
+		err = p.ids(FieldParent, value, false)
+	case "due":
+		err = p.dates(FieldDue, value, true)
+	case "created":
+		err = p.dates(FieldCreated, value, false)
+	case "updated":
+		err = p.dates(FieldUpdated, value, false)
+	case "estimate":
+		err = p.numbers(FieldEstimate, value)
+	case "is":
+		err = p.is(value)
+	case "sort":
+		err = p.sort(value)
+	default:
+		return fmt.Errorf("unknown filter %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s:%s: %w", key, value, err)
+	}
+	return nil
+}
+
+// values adds a filter on a field with a fixed set of values, e.g.
+// "done", "todo,review" or "!done"
+func (p *parser) values(field Field, value string, valid []string) error {
+	c := Condition{Field: field}
+	value, c.Not = strings.CutPrefix(value, "!")
+	for _, v := range strings.Split(value, ",") {
+		v = strings.ToLower(v)
+		if !slices.Contains(valid, v) {
+			return fmt.Errorf("must be one of %s", strings.Join(valid, ", "))
+		}
+		c.Values = append(c.Values, v)
+	}
+	p.filter.Conditions = append(p.filter.Conditions, c)
+	return nil
+}
+
+// ids adds a filter on a reference to another entity, e.g. "7", "3,7",
+// "none" or "!none"; "me" is allowed when allowMe is set
+func (p *parser) ids(field Field, value string, allowMe bool) error {
+	c := Condition{Field: field}
+	value, c.Not = strings.CutPrefix(value, "!")
+	for _, v := range strings.Split(value, ",") {
+		switch v = strings.ToLower(v); {
+		case v == "none":
+			c.Null = true
+		case v == "me" && allowMe:
+			c.IDs = append(c.IDs, p.me)
+		default:
+			id, err := strconv.Atoi(v)
+			if err != nil || id <= 0 {
+				if allowMe {
+					return fmt.Errorf("must be IDs, me or none")
+				}
+				return fmt.Errorf("must be IDs or none")
+			}
+			c.IDs = append(c.IDs, id)
+		}
+	}
+	p.filter.Conditions = append(p.filter.Conditions, c)
+	return nil
+}
+
+// dates adds a filter on a date, e.g. "2026-11-01", "<2026-11-01",
+// ">=today" or "2026-10-01..2026-10-31". Dates stand for whole days in UTC,
+// so "<=2026-11-01" includes that day. "none" and "!none" are allowed when
+// allowNone is set.
+func (p *parser) dates(field Field, value string, allowNone bool) error {
+	if allowNone && (value == "none" || value == "!none") {
+		p.filter.Conditions = append(p.filter.Conditions, Condition{Field: field, Null: true, Not: value == "!none"})
+		return nil
+	}
+
+	op, from, to, err := splitRange(value)
+	if err != nil {
+		return err
+	}
+	start, err := p.date(from)
+	if err != nil {
+		return err
+	}
+
+	c := Condition{Field: field}
+	switch op {
+	case "<":
+		c.Max = &Bound{Value: start}
+	case "<=":
+		c.Max = &Bound{Value: start.AddDate(0, 0, 1)}
+	case ">":
+		c.Min = &Bound{Value: start.AddDate(0, 0, 1), Inclusive: true}
+	case ">=":
+		c.Min = &Bound{Value: start, Inclusive: true}
+	case "..":
+		end, err := p.date(to)
+		if err != nil {
+			return err
+		}
+		c.Min = &Bound{Value: start, Inclusive: true}
+		c.Max = &Bound{Value: end.AddDate(0, 0, 1)}
+	default:
+		c.Min = &Bound{Value: start, Inclusive: true}
+		c.Max = &Bound{Value: start.AddDate(0, 0, 1)}
+	}
+	p.filter.Conditions = append(p.filter.Conditions, c)
+	return nil
+}
+
+// date parses a date in the form 2006-01-02, or today
+func (p *parser) date(s string) (time.Time, error) {
+	if strings.EqualFold(s, "today") {
+		return p.today, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("dates must look like 2006-01-02")
+	}
+	return t, nil
+}
+
+// numbers adds a filter on an estimate in hours, e.g. "8", ">=2" or "2..8"
+func (p *parser) numbers(field Field, value string) error {
+	op, from, to, err := splitRange(value)
+	if err != nil {
+		return err
+	}
+	low, err := strconv.ParseFloat(from, 64)
+	if err != nil {
+		return fmt.Errorf("must be a number of hours")
+	}
+
+	c := Condition{Field: field}
+	switch op {
+	case "<", "<=":
+		c.Max = &Bound{Value: low, Inclusive: op == "<="}
+	case ">", ">=":
+		c.Min = &Bound{Value: low, Inclusive: op == ">="}
+	case "..":
+		high, err := strconv.ParseFloat(to, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number of hours")
+		}
+		c.Min = &Bound{Value: low, Inclusive: true}
+		c.Max = &Bound{Value: high, Inclusive: true}
+	default:
+		c.Min = &Bound{Value: low, Inclusive: true}
+		c.Max = &Bound{Value: low, Inclusive: true}
+	}
+	p.filter.Conditions = append(p.filter.Conditions, c)
+	return nil
+}
+
+// splitRange splits a comparison or range into its operator and operands:
+// "<x" gives "<" and x, "x..y" gives "..", x and y, and a lone x gives no
+// operator
+func splitRange(value string) (op, from, to string, err error) {
+	for _, prefix := range []string{"<=", ">=", "<", ">"} {
+		if rest, ok := strings.CutPrefix(value, prefix); ok {
+			return prefix, rest, "", nil
+		}
+	}
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		if from == "" || to == "" {
+			return "", "", "", fmt.Errorf("ranges need both ends")
+		}
+		return "..", from, to, nil
+	}
+	return "", value, "", nil
+}
+
+// is adds a named filter: "overdue" is tasks due before today that are not
+// done
+func (p *parser) is(value string) error {
+	if strings.ToLower(value) != "overdue" {
+		return fmt.Errorf("must be overdue")
+	}
+	p.filter.Conditions = append(p.filter.Conditions,
+		Condition{Field: FieldDue, Max: &Bound{Value: p.today}},
+		Condition{Field: FieldStatus, Values: []string{"done"}, Not: true},
+	)
+	return nil
+}
+
+// sortable lists the fields tasks can be sorted by
+var sortable = []Field{FieldCreated, FieldUpdated, FieldDue, FieldPriority, FieldStatus, FieldTitle, FieldEstimate}
+
+// sort sets the sort order: fields separated by commas, each prefixed with
+// - to sort in descending order, e.g. "-priority,due"
+func (p *parser) sort(value string) error {
+	var keys []SortKey
+	for _, v := range strings.Split(strings.ToLower(value), ",") {
+		key := SortKey{}
+		v, key.Desc = strings.CutPrefix(v, "-")
+		key.Field = Field(v)
+		if !slices.Contains(sortable, key.Field) {
+			return fmt.Errorf("can sort by created, updated, due, priority, status, title or estimate")
+		}
+		for _, k := range keys {
+			if k.Field == key.Field {
+				return fmt.Errorf("%s is sorted by twice", v)
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	// Newest first is the default order
+	if len(keys) == 1 && keys[0] == (SortKey{Field: FieldCreated, Desc: true}) {
+		keys = nil
+	}
+	p.filter.Sort = keys
+	return nil
+}
+
+// day returns the start of the UTC day of t
+func day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,200 @@
+package taskquery
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	const me = 42
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, time.FixedZone("", 2*60*60))
+	today := date("2026-10-17")
+
+	tests := []struct {
+		name  string
+		query string
+		want  *Filter
+	}{
+		{name: "empty", query: "", want: &Filter{}},
+		{
+			name:  "statuses",
+			query: "q=status:todo,REVIEW",
+			want:  &Filter{Conditions: []Condition{{Field: FieldStatus, Values: []string{"todo", "review"}}}},
+		},
+		{
+			name:  "negated status",
+			query: "status=!done",
+			want:  &Filter{Conditions: []Condition{{Field: FieldStatus, Values: []string{"done"}, Not: true}}},
+		},
+		{
+			name:  "assignee me or none",
+			query: "q=assignee:me,none",
+			want:  &Filter{Conditions: []Condition{{Field: FieldAssignee, IDs: []int{me}, Null: true}}},
+		},
+		{
+			name:  "legacy parameter",
+			query: "project_id=7",
+			want:  &Filter{Conditions: []Condition{{Field: FieldProject, IDs: []int{7}}}},
+		},
+		{
+			name:  "parent with a subtask",
+			query: "q=parent:!none",
+			want:  &Filter{Conditions: []Condition{{Field: FieldParent, Null: true, Not: true}}},
+		},
+		{
+			name:  "due on a day",
+			query: "q=due:2026-11-01",
+			want: &Filter{Conditions: []Condition{{
+				Field: FieldDue,
+				Min:   &Bound{Value: date("2026-11-01"), Inclusive: true},
+				Max:   &Bound{Value: date("2026-11-02")},
+			}}},
+		},
+		{
+			name:  "due up to and including a day",
+			query: "q=due:<=2026-11-01",
+			want:  &Filter{Conditions: []Condition{{Field: FieldDue, Max: &Bound{Value: date("2026-11-02")}}}},
+		},
+		{
+			name:  "created after today",
+			query: "q=created:>today",
+			want:  &Filter{Conditions: []Condition{{Field: FieldCreated, Min: &Bound{Value: today.AddDate(0, 0, 1), Inclusive: true}}}},
+		},
+		{
+			name:  "date range",
+			query: "updated=2026-10-01..2026-10-31",
+			want: &Filter{Conditions: []Condition{{
+				Field: FieldUpdated,
+				Min:   &Bound{Value: date("2026-10-01"), Inclusive: true},
+				Max:   &Bound{Value: date("2026-11-01")},
+			}}},
+		},
+		{
+			name:  "without a due date",
+			query: "q=due:none",
+			want:  &Filter{Conditions: []Condition{{Field: FieldDue, Null: true}}},
+		},
+		{
+			name:  "estimate range",
+			query: "q=estimate:2..8.5",
+			want: &Filter{Conditions: []Condition{{
+				Field: FieldEstimate,
+				Min:   &Bound{Value: 2.0, Inclusive: true},
+				Max:   &Bound{Value: 8.5, Inclusive: true},
+			}}},
+		},
+		{
+			name:  "estimate below",
+			query: "q=estimate:<4",
+			want:  &Filter{Conditions: []Condition{{Field: FieldEstimate, Max: &Bound{Value: 4.0}}}},
+		},
+		{
+			name:  "overdue",
+			query: "q=is:overdue",
+			want: &Filter{Conditions: []Condition{
+				{Field: FieldDue, Max: &Bound{Value: today}},
+				{Field: FieldStatus, Values: []string{"done"}, Not: true},
+			}},
+		},
+		{
+			name:  "sort",
+			query: "q=sort:-priority,due",
+			want:  &Filter{Sort: []SortKey{{Field: FieldPriority, Desc: true}, {Field: FieldDue}}},
+		},
+		{
+			name:  "default sort",
+			query: "sort=-created",
+			want:  &Filter{},
+		},
+		{
+			name:  "text and quoted phrases",
+			query: "q=" + url.QueryEscape(`landing "hero copy" status:"in_progress" v2:beta`),
+			want: &Filter{
+				Conditions: []Condition{{Field: FieldStatus, Values: []string{"in_progress"}}},
+				Text:       []string{"landing", "hero copy", "v2:beta"},
+			},
+		},
+		{
+			name:  "parameters before the query",
+			query: "q=priority:high&status=todo",
+			want: &Filter{Conditions: []Condition{
+				{Field: FieldStatus, Values: []string{"todo"}},
+				{Field: FieldPriority, Values: []string{"high"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(query, me, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{name: "unknown key", query: "q=colour:red", err: `unknown filter "colour"`},
+		{name: "unknown status", query: "status=closed", err: "must be one of todo, in_progress, review, done"},
+		{name: "me for a project", query: "q=project:me", err: "must be IDs or none"},
+		{name: "negative ID", query: "assignee=-3", err: "must be IDs, me or none"},
+		{name: "bad date", query: "q=due:tomorrow", err: "dates must look like 2006-01-02"},
+		{name: "open range", query: "q=due:2026-10-01..", err: "ranges need both ends"},
+		{name: "none for a creation date", query: "q=created:none", err: "dates must look like"},
+		{name: "bad estimate", query: "q=estimate:lots", err: "must be a number of hours"},
+		{name: "unknown is", query: "q=is:late", err: "must be overdue"},
+		{name: "unknown sort", query: "sort=assignee", err: "can sort by"},
+		{name: "sorted twice", query: "sort=due,-due", err: "due is sorted by twice"},
+		{name: "unterminated quote", query: "q=" + url.QueryEscape(`"hero copy`), err: "unterminated quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			filter, err := Parse(query, 1, time.Now())
+			if err == nil {
+				t.Fatalf("got %+v, want an error", filter)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %q, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSortString(t *testing.T) {
+	filter := &Filter{Sort: []SortKey{{Field: FieldPriority, Desc: true}, {Field: FieldDue}}}
+	if got := filter.SortString(); got != "-priority,due" {
+		t.Fatalf("got %q", got)
+	}
+	if got := (&Filter{}).SortString(); got != "" {
+		t.Fatalf("got %q for the default order", got)
+	}
+}
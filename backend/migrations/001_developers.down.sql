@@ -0,0 +1,2 @@
+-- Drop developers table
+DROP TABLE IF EXISTS developers;
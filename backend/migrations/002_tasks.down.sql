@@ -0,0 +1,2 @@
+-- Drop tasks table
+DROP TABLE IF EXISTS tasks;
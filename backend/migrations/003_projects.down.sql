@@ -0,0 +1,3 @@
+-- Drop project tables
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS projects;
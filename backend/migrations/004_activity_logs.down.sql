@@ -0,0 +1,2 @@
+-- Drop activity_logs table
+DROP TABLE IF EXISTS activity_logs;
@@ -0,0 +1,2 @@
+-- Remove password_hash column from developers table
+ALTER TABLE developers DROP COLUMN IF EXISTS password_hash;
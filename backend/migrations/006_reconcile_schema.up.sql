@@ -0,0 +1,96 @@
+-- Reconcile the schema with internal/models.
+--
+-- Migrations 001-004 created UUID primary keys and an activity_logs table,
+-- while the API has always used integer IDs and an activities table. Rows in
+-- the UUID tables could never be read or written by the server, so they are
+-- replaced rather than converted.
+DROP TABLE IF EXISTS activity_logs CASCADE;
+DROP TABLE IF EXISTS project_members CASCADE;
+DROP TABLE IF EXISTS tasks CASCADE;
+DROP TABLE IF EXISTS projects CASCADE;
+DROP TABLE IF EXISTS developers CASCADE;
+
+-- Developers
+CREATE TABLE developers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    password_hash VARCHAR(255),
+    role VARCHAR(100) DEFAULT 'developer',
+    team_id INTEGER,
+    avatar_url VARCHAR(500),
+    status VARCHAR(50) NOT NULL DEFAULT 'offline',
+    last_active TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_developers_status ON developers(status);
+CREATE INDEX idx_developers_team ON developers(team_id);
+CREATE INDEX idx_developers_last_active ON developers(last_active);
+
+-- Projects
+CREATE TABLE projects (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL DEFAULT 'active',
+    start_date DATE,
+    end_date DATE,
+    team_id INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_projects_status ON projects(status);
+CREATE INDEX idx_projects_team ON projects(team_id);
+
+-- Project members
+CREATE TABLE project_members (
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    developer_id INTEGER NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL DEFAULT 'member',
+    joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_id, developer_id)
+);
+
+CREATE INDEX idx_project_members_developer ON project_members(developer_id);
+
+-- Tasks
+CREATE TABLE tasks (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL DEFAULT 'todo',
+    priority VARCHAR(50) NOT NULL DEFAULT 'medium',
+    project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL,
+    assignee_id INTEGER REFERENCES developers(id) ON DELETE SET NULL,
+    due_date TIMESTAMP,
+    estimated_hours NUMERIC(8, 2),
+    actual_hours NUMERIC(8, 2),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_tasks_project ON tasks(project_id);
+CREATE INDEX idx_tasks_assignee ON tasks(assignee_id);
+CREATE INDEX idx_tasks_status ON tasks(status);
+CREATE INDEX idx_tasks_priority ON tasks(priority);
+CREATE INDEX idx_tasks_due_date ON tasks(due_date);
+
+-- Activities
+-- task_id is deliberately not a foreign key: deleting a task still records
+-- a task_deleted entry that points at the removed ID.
+CREATE TABLE activities (
+    id SERIAL PRIMARY KEY,
+    developer_id INTEGER REFERENCES developers(id) ON DELETE SET NULL,
+    task_id INTEGER,
+    action VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    metadata JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_activities_developer ON activities(developer_id);
+CREATE INDEX idx_activities_task ON activities(task_id);
+CREATE INDEX idx_activities_created ON activities(created_at DESC);
@@ -0,0 +1,4 @@
+-- Drop teams table
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS fk_projects_team;
+ALTER TABLE developers DROP CONSTRAINT IF EXISTS fk_developers_team;
+DROP TABLE IF EXISTS teams;
@@ -0,0 +1,18 @@
+-- Create teams table
+CREATE TABLE IF NOT EXISTS teams (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Link developers and projects to teams. Team IDs stored before this table
+-- existed cannot point at a real team, so they are cleared first.
+UPDATE developers SET team_id = NULL WHERE team_id IS NOT NULL;
+UPDATE projects SET team_id = NULL WHERE team_id IS NOT NULL;
+
+ALTER TABLE developers
+    ADD CONSTRAINT fk_developers_team FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE SET NULL;
+ALTER TABLE projects
+    ADD CONSTRAINT fk_projects_team FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE SET NULL;
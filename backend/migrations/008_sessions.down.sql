@@ -0,0 +1,3 @@
+-- Drop session tables
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS sessions;
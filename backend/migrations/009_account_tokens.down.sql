@@ -0,0 +1,3 @@
+-- Drop account tokens and email verification
+DROP TABLE IF EXISTS account_tokens;
+ALTER TABLE developers DROP COLUMN IF EXISTS email_verified_at;
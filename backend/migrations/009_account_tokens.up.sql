@@ -0,0 +1,16 @@
+-- Track email verification
+ALTER TABLE developers ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+-- Create account_tokens table: single-use tokens for email verification and
+-- password reset. Only the SHA-256 hash of a token is stored.
+CREATE TABLE IF NOT EXISTS account_tokens (
+    id SERIAL PRIMARY KEY,
+    developer_id INTEGER NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    purpose VARCHAR(32) NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX idx_account_tokens_developer ON account_tokens(developer_id, purpose);
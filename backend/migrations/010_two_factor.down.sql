@@ -0,0 +1,6 @@
+-- Drop two-factor authentication
+DROP TABLE IF EXISTS two_factor_required_roles;
+DROP TABLE IF EXISTS recovery_codes;
+ALTER TABLE developers DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE developers DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE developers DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,23 @@
+-- TOTP two-factor authentication. A secret without totp_enabled_at is an
+-- enrollment that has not been confirmed yet.
+ALTER TABLE developers ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE developers ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP;
+ALTER TABLE developers ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
+
+-- Create recovery_codes table: single-use codes for when the authenticator
+-- is lost. Only the SHA-256 hash of a code is stored.
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id SERIAL PRIMARY KEY,
+    developer_id INTEGER NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX idx_recovery_codes_developer ON recovery_codes(developer_id);
+
+-- Roles whose members must use two-factor authentication
+CREATE TABLE IF NOT EXISTS two_factor_required_roles (
+    role VARCHAR(50) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
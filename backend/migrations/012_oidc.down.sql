@@ -0,0 +1,5 @@
+-- Drop OIDC login support
+DROP TABLE IF EXISTS oidc_login_states;
+DROP INDEX IF EXISTS idx_developers_oidc;
+ALTER TABLE developers DROP COLUMN IF EXISTS oidc_subject;
+ALTER TABLE developers DROP COLUMN IF EXISTS oidc_issuer;
@@ -0,0 +1,15 @@
+-- Link developers to their identity provider account
+ALTER TABLE developers ADD COLUMN IF NOT EXISTS oidc_issuer VARCHAR(255);
+ALTER TABLE developers ADD COLUMN IF NOT EXISTS oidc_subject VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_developers_oidc ON developers(oidc_issuer, oidc_subject);
+
+-- Create oidc_login_states table: logins that were sent to the identity
+-- provider and have not returned yet. Only the SHA-256 hash of the state is stored.
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state_hash CHAR(64) PRIMARY KEY,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Drop invitations
+DROP TABLE IF EXISTS invitations;
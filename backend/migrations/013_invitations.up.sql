@@ -0,0 +1,20 @@
+-- Create invitations table: admins and project owners invite people by email.
+-- The invitee redeems a single-use token; only its SHA-256 hash is stored.
+CREATE TABLE IF NOT EXISTS invitations (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL DEFAULT 'developer',
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    project_role VARCHAR(50),
+    team_id INTEGER REFERENCES teams(id) ON DELETE SET NULL,
+    invited_by INTEGER REFERENCES developers(id) ON DELETE SET NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    accepted_by INTEGER REFERENCES developers(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_invitations_email ON invitations(LOWER(email));
+CREATE INDEX idx_invitations_invited_by ON invitations(invited_by);
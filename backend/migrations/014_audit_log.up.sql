@@ -0,0 +1,17 @@
+-- Create audit_log table: field-level before/after changes of tasks,
+-- projects and developers, with the actor, request ID and IP that made them.
+-- entity_id is not a foreign key so the history outlives the entity.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor_id INTEGER REFERENCES developers(id) ON DELETE SET NULL,
+    request_id VARCHAR(100),
+    ip_address VARCHAR(45),
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at DESC);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id);
@@ -0,0 +1,4 @@
+-- Drop full-text search columns and their indexes
+ALTER TABLE activities DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE projects DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE tasks DROP COLUMN IF EXISTS search_vector;
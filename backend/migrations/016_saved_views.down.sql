@@ -0,0 +1,2 @@
+-- Drop saved_views
+DROP TABLE IF EXISTS saved_views;
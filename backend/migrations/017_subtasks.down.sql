@@ -0,0 +1,4 @@
+-- Drop subtasks
+DROP INDEX IF EXISTS idx_tasks_parent;
+ALTER TABLE tasks DROP COLUMN IF EXISTS auto_complete;
+ALTER TABLE tasks DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,7 @@
+-- Subtasks: tasks may have a parent task, to any depth. Deleting a task
+-- promotes its subtasks to top-level tasks. auto_complete marks a task done
+-- when all of its subtasks are done.
+ALTER TABLE tasks ADD COLUMN parent_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN auto_complete BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_tasks_parent ON tasks(parent_id);
@@ -0,0 +1,16 @@
+-- Create task_links table: dependencies and other relations between tasks,
+-- read as "source <type> target", e.g. task 3 blocks task 5. "blocked by" is
+-- stored as the inverse "blocks" link; "relates_to" links have the lower ID
+-- as their source.
+CREATE TABLE IF NOT EXISTS task_links (
+    id SERIAL PRIMARY KEY,
+    source_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    target_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    created_by INTEGER REFERENCES developers(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (source_id, target_id, type),
+    CHECK (source_id <> target_id)
+);
+
+CREATE INDEX idx_task_links_target ON task_links(target_id, type);
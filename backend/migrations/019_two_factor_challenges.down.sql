@@ -0,0 +1,2 @@
+-- Drop two_factor_challenges
+DROP TABLE IF EXISTS two_factor_challenges;
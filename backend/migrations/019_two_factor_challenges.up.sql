@@ -0,0 +1,10 @@
+-- Create two_factor_challenges table: logins that passed the password step
+-- and wait for a second factor, by the ID of their challenge token. A
+-- challenge is removed when it is used and after too many wrong codes.
+CREATE TABLE IF NOT EXISTS two_factor_challenges (
+    id VARCHAR(64) PRIMARY KEY,
+    developer_id INTEGER NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    failures INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
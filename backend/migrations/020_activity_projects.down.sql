@@ -0,0 +1,3 @@
+-- Drop the project of activities
+DROP INDEX IF EXISTS idx_activities_project;
+ALTER TABLE activities DROP COLUMN IF EXISTS project_id;
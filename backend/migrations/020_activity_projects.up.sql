@@ -0,0 +1,10 @@
+-- Activities without a task record the project they concern, so they are
+-- only shown to its members. Older activities that name a project in their
+-- metadata get it from there; the others stay visible to their developer only.
+ALTER TABLE activities ADD COLUMN IF NOT EXISTS project_id INTEGER;
+
+UPDATE activities
+SET project_id = (metadata->>'project_id')::INTEGER
+WHERE task_id IS NULL AND metadata->>'project_id' ~ '^[0-9]+$';
+
+CREATE INDEX IF NOT EXISTS idx_activities_project ON activities(project_id);
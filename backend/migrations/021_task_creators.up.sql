@@ -0,0 +1,10 @@
+-- Record who created a task. Tasks without a project can only be edited by
+-- their assignee or creator. Existing tasks get their creator from the
+-- task_created activity, where there is one.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS created_by INTEGER REFERENCES developers(id) ON DELETE SET NULL;
+
+UPDATE tasks
+SET created_by = (
+    SELECT MIN(a.developer_id) FROM activities a
+    WHERE a.task_id = tasks.id AND a.action = 'task_created'
+);
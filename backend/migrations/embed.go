@@ -0,0 +1,29 @@
+// Package migrations embeds the SQL migration files into the server binary.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// FS contains every *.up.sql and *.down.sql file in this directory
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// ForDriver returns the migrations of a database driver: the files in this
+// directory for "postgres" and those in sqlite/ for "sqlite"
+func ForDriver(driver string) (fs.FS, error) {
+	switch driver {
+	case "postgres":
+		return FS, nil
+	case "sqlite":
+		return fs.Sub(sqliteFS, "sqlite")
+	default:
+		return nil, fmt.Errorf("no migrations for database driver %q", driver)
+	}
+}
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS invitations;
+DROP TABLE IF EXISTS oidc_login_states;
+DROP TABLE IF EXISTS api_tokens;
+DROP TABLE IF EXISTS two_factor_required_roles;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS account_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS activities;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS developers;
+DROP TABLE IF EXISTS teams;
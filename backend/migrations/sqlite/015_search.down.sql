@@ -0,0 +1,12 @@
+-- Drop full-text search tables and the triggers that fill them
+DROP TRIGGER IF EXISTS activities_fts_delete;
+DROP TRIGGER IF EXISTS activities_fts_insert;
+DROP TRIGGER IF EXISTS projects_fts_update;
+DROP TRIGGER IF EXISTS projects_fts_delete;
+DROP TRIGGER IF EXISTS projects_fts_insert;
+DROP TRIGGER IF EXISTS tasks_fts_update;
+DROP TRIGGER IF EXISTS tasks_fts_delete;
+DROP TRIGGER IF EXISTS tasks_fts_insert;
+DROP TABLE IF EXISTS activities_fts;
+DROP TABLE IF EXISTS projects_fts;
+DROP TABLE IF EXISTS tasks_fts;
@@ -0,0 +1,49 @@
+-- Full-text search with FTS5: external content tables over tasks, projects
+-- and activities, kept up to date by triggers
+CREATE VIRTUAL TABLE tasks_fts USING fts5(
+    title, description,
+    content = 'tasks', content_rowid = 'id', tokenize = 'porter unicode61'
+);
+
+CREATE VIRTUAL TABLE projects_fts USING fts5(
+    name, description,
+    content = 'projects', content_rowid = 'id', tokenize = 'porter unicode61'
+);
+
+CREATE VIRTUAL TABLE activities_fts USING fts5(
+    description,
+    content = 'activities', content_rowid = 'id', tokenize = 'porter unicode61'
+);
+
+INSERT INTO tasks_fts (rowid, title, description) SELECT id, title, description FROM tasks;
+INSERT INTO projects_fts (rowid, name, description) SELECT id, name, description FROM projects;
+INSERT INTO activities_fts (rowid, description) SELECT id, description FROM activities;
+
+CREATE TRIGGER tasks_fts_insert AFTER INSERT ON tasks BEGIN
+    INSERT INTO tasks_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+CREATE TRIGGER tasks_fts_delete AFTER DELETE ON tasks BEGIN
+    INSERT INTO tasks_fts (tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+END;
+CREATE TRIGGER tasks_fts_update AFTER UPDATE OF title, description ON tasks BEGIN
+    INSERT INTO tasks_fts (tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+    INSERT INTO tasks_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+CREATE TRIGGER projects_fts_insert AFTER INSERT ON projects BEGIN
+    INSERT INTO projects_fts (rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+CREATE TRIGGER projects_fts_delete AFTER DELETE ON projects BEGIN
+    INSERT INTO projects_fts (projects_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+END;
+CREATE TRIGGER projects_fts_update AFTER UPDATE OF name, description ON projects BEGIN
+    INSERT INTO projects_fts (projects_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+    INSERT INTO projects_fts (rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TRIGGER activities_fts_insert AFTER INSERT ON activities BEGIN
+    INSERT INTO activities_fts (rowid, description) VALUES (new.id, new.description);
+END;
+CREATE TRIGGER activities_fts_delete AFTER DELETE ON activities BEGIN
+    INSERT INTO activities_fts (activities_fts, rowid, description) VALUES ('delete', old.id, old.description);
+END;
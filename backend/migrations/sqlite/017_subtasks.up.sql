@@ -0,0 +1,5 @@
+-- Subtasks, see the Postgres migration 017
+ALTER TABLE tasks ADD COLUMN parent_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN auto_complete BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_tasks_parent ON tasks(parent_id);
@@ -0,0 +1,2 @@
+-- Drop task_links
+DROP TABLE IF EXISTS task_links;
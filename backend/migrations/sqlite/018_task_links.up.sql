@@ -0,0 +1,13 @@
+-- Task links, see the Postgres migration 018
+CREATE TABLE task_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    target_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    created_by INTEGER REFERENCES developers(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (source_id, target_id, type),
+    CHECK (source_id <> target_id)
+);
+
+CREATE INDEX idx_task_links_target ON task_links(target_id, type);
@@ -0,0 +1,8 @@
+-- Pending two-factor challenges, see the Postgres migration 019
+CREATE TABLE two_factor_challenges (
+    id VARCHAR(64) PRIMARY KEY,
+    developer_id INTEGER NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    failures INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,8 @@
+-- Project of activities, see the Postgres migration 020
+ALTER TABLE activities ADD COLUMN project_id INTEGER;
+
+UPDATE activities
+SET project_id = json_extract(CAST(metadata AS TEXT), '$.project_id')
+WHERE task_id IS NULL AND json_type(CAST(metadata AS TEXT), '$.project_id') = 'integer';
+
+CREATE INDEX idx_activities_project ON activities(project_id);
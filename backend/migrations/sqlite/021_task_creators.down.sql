@@ -0,0 +1,2 @@
+-- Drop the creator of tasks
+ALTER TABLE tasks DROP COLUMN created_by;
@@ -0,0 +1,8 @@
+-- Task creators, see the Postgres migration 021
+ALTER TABLE tasks ADD COLUMN created_by INTEGER REFERENCES developers(id) ON DELETE SET NULL;
+
+UPDATE tasks
+SET created_by = (
+    SELECT MIN(a.developer_id) FROM activities a
+    WHERE a.task_id = tasks.id AND a.action = 'task_created'
+);
@@ -2,7 +2,10 @@ package utils
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // JSON response helper
@@ -58,3 +61,59 @@ func ValidationErrorResponse(w http.ResponseWriter, errors []string) {
 		},
 	})
 }
+
+// ForbiddenResponse sends a 403 error with a machine-readable reason
+func ForbiddenResponse(w http.ResponseWriter, reason, message string) {
+	JSON(w, http.StatusForbidden, map[string]interface{}{
+		"success": false,
+		"error": map[string]interface{}{
+			"code":    http.StatusForbidden,
+			"message": message,
+			"reason":  reason,
+		},
+	})
+}
+
+// TooManyRequestsResponse sends a 429 error with a Retry-After header telling
+// the client how many seconds to wait
+func TooManyRequestsResponse(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	JSON(w, http.StatusTooManyRequests, map[string]interface{}{
+		"success": false,
+		"error": map[string]interface{}{
+			"code":        http.StatusTooManyRequests,
+			"message":     message,
+			"retry_after": seconds,
+		},
+	})
+}
+
+// CycleResponse sends a 409 error listing the IDs of tasks that form a cycle
+func CycleResponse(w http.ResponseWriter, cycle []int, message string) {
+	JSON(w, http.StatusConflict, map[string]interface{}{
+		"success": false,
+		"error": map[string]interface{}{
+			"code":    http.StatusConflict,
+			"message": message,
+			"cycle":   cycle,
+		},
+	})
+}
+
+// BlockedResponse sends a 409 error listing the IDs of the open tasks that
+// block the change
+func BlockedResponse(w http.ResponseWriter, blockedBy []int, message string) {
+	JSON(w, http.StatusConflict, map[string]interface{}{
+		"success": false,
+		"error": map[string]interface{}{
+			"code":       http.StatusConflict,
+			"message":    message,
+			"blocked_by": blockedBy,
+		},
+	})
+}